@@ -1 +1,26 @@
 package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TimeoutPolicy defines the action taken once a deadline passes.
+// +kubebuilder:validation:Enum=Reset;Fail
+type TimeoutPolicy string
+
+const (
+	// TimeoutPolicyReset resets the ElementalHost.
+	TimeoutPolicyReset = TimeoutPolicy("Reset")
+	// TimeoutPolicyFail sets the terminal failure fields.
+	TimeoutPolicyFail = TimeoutPolicy("Fail")
+)
+
+// Timeout defines a deadline and the action taken once it passes.
+type Timeout struct {
+	// Duration after which the deadline passes.
+	Duration metav1.Duration `json:"duration"`
+	// Policy defines the action taken once the deadline passes.
+	// +optional
+	// +kubebuilder:default:=Reset
+	Policy TimeoutPolicy `json:"policy,omitempty"`
+}
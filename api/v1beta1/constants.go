@@ -25,6 +25,16 @@ const (
 	AnnotationElementalRegistrationName      = "elementalregistration.infrastructure.cluster.x-k8s.io/name"
 	AnnotationElementalRegistrationNamespace = "elementalregistration.infrastructure.cluster.x-k8s.io/namespace"
 	AnnotationElementalHostPublicKey         = "elementalhost.infrastructure.cluster.x-k8s.io/pub-key"
+	// AnnotationElementalHostApproved is set on new ElementalHosts when the ElementalRegistration requires approval.
+	// A "false" value means that the ElementalHost is pending approval, "true" that it was approved.
+	AnnotationElementalHostApproved = "elementalhost.infrastructure.cluster.x-k8s.io/approved"
+	// AnnotationElementalHostForceDelete skips the reset of a to-be-deleted ElementalHost, when set to "true".
+	// This is needed to delete ElementalHosts whose elemental-agent is gone, for example if the host was wiped out of band.
+	AnnotationElementalHostForceDelete = "elementalhost.infrastructure.cluster.x-k8s.io/force-delete"
+	// Annotations set on the downstream cluster node to track the synchronized metadata.
+	AnnotationNodeSyncedLabels      = "elementalmachine.infrastructure.cluster.x-k8s.io/synced-labels"
+	AnnotationNodeSyncedAnnotations = "elementalmachine.infrastructure.cluster.x-k8s.io/synced-annotations"
+	AnnotationNodeSyncedTaints      = "elementalmachine.infrastructure.cluster.x-k8s.io/synced-taints"
 )
 
 // Labels.
@@ -38,6 +48,8 @@ const (
 	LabelElementalHostInPlaceUpdate        = "elementalhost.infrastructure.cluster.x-k8s.io/in-place-update"
 	InPlaceUpdatePending                   = "pending"
 	InPlaceUpdateDone                      = "done"
+	// LabelElementalHostSupportBundle is set on support bundle Secrets, with the ElementalHost name as value.
+	LabelElementalHostSupportBundle = "elementalhost.infrastructure.cluster.x-k8s.io/support-bundle"
 )
 
 // HostPhases.
@@ -46,6 +58,7 @@ type HostPhase string
 const (
 	PhaseRegistering            = HostPhase("Registering")
 	PhaseFinalizingRegistration = HostPhase("Finalizing Registration")
+	PhasePendingApproval        = HostPhase("Pending Approval")
 	PhaseInstalling             = HostPhase("Installing")
 	PhaseBootstrapping          = HostPhase("Bootstrapping")
 	PhaseRunning                = HostPhase("Running")
@@ -54,6 +67,17 @@ const (
 	PhaseOSVersionReconcile     = HostPhase("Reconciling OS Version")
 )
 
+// HostPhaseOutcomes.
+// +kubebuilder:validation:Enum=Succeeded;Failed
+type HostPhaseOutcome string
+
+const (
+	// PhaseSucceeded is the outcome of a phase left while the ElementalHost was ready, or only had warnings.
+	PhaseSucceeded = HostPhaseOutcome("Succeeded")
+	// PhaseFailed is the outcome of a phase left while the ElementalHost had errors.
+	PhaseFailed = HostPhaseOutcome("Failed")
+)
+
 // Conditions.
 // See: https://github.com/kubernetes-sigs/cluster-api/blob/main/docs/proposals/20200506-conditions.md
 
@@ -67,6 +91,12 @@ const (
 	// its identity file into the just registered host.
 	RegistrationFailedReason = "RegistrationFailed"
 
+	// Approved describes whether the Host was approved to be installed, when required by the ElementalRegistration.
+	Approved clusterv1.ConditionType = "Approved"
+	// WaitingForApprovalReason indicates that the Host is registered, but it needs approval before being installed.
+	WaitingForApprovalReason                                     = "WaitingForApproval"
+	WaitingForApprovalReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityInfo
+
 	// InstallationReady describes the Host installation phase.
 	InstallationReady clusterv1.ConditionType = "InstallationReady"
 	// WaitingForInstallationReason indicates that this Host was registered but no installation has taken place yet.
@@ -76,6 +106,8 @@ const (
 	CloudConfigInstallationFailedReason = "CloudConfigInstallationFailed"
 	// InstallationFailedReason indicates a failure within the installation process.
 	InstallationFailedReason = "InstallationFailed"
+	// InstallationTimeoutReason indicates that the Host was not installed before the deadline.
+	InstallationTimeoutReason = "InstallationTimeout"
 
 	// BootstrapReady describes the Host bootstrapping phase.
 	BootstrapReady clusterv1.ConditionType = "BootstrapReady"
@@ -106,6 +138,19 @@ const (
 	// WaitingForPostReconcileRebootReason indicates that the Host OS version was applied and the Host is going to reboot.
 	WaitingForPostReconcileRebootReason                                     = "WaitingForPostReconcileReboot"
 	WaitingForPostReconcileRebootReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityInfo
+
+	// AgentReachable describes whether the Host elemental-agent has been recently seen.
+	AgentReachable clusterv1.ConditionType = "AgentReachable"
+	// AgentUnreachableReason indicates that the elemental-agent has not been seen for longer than the grace period.
+	// This can happen if the Host is powered off, crashed, or lost network connectivity.
+	AgentUnreachableReason                                     = "AgentUnreachable"
+	AgentUnreachableReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityWarning
+
+	// RemediationReady describes the Host remediation status, following a MachineHealthCheck remediation request.
+	RemediationReady clusterv1.ConditionType = "RemediationReady"
+	// RemediationFailedReason indicates that neither rebooting nor resetting the Host did recover it.
+	// The Host is considered failed and the associated Machine is deleted to be replaced.
+	RemediationFailedReason = "RemediationFailed"
 )
 
 // ElementalMachine Conditions and Reasons.
@@ -128,6 +173,10 @@ const (
 	// This Reason should be transient as the provider should try to associate the ElementalMachine with a new available ElementalHost.
 	AssociatedHostNotFoundReason                                     = "AssociatedHostNotFound"
 	AssociatedHostNotFoundReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityWarning
+	// HostPoolNotAllowedReason indicates that the referenced ElementalHostPool does not exist,
+	// or that the ElementalMachine namespace is not allowed to claim hosts from it.
+	HostPoolNotAllowedReason                                     = "HostPoolNotAllowed"
+	HostPoolNotAllowedReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityWarning
 
 	// HostReady summarizes the status of the associated ElementalHost.
 	HostReady clusterv1.ConditionType = "HostReady"
@@ -139,18 +188,34 @@ const (
 	// and the provider is waiting for success confirmation.
 	HostWaitingForBootstrapReason                                     = "HostWaitingForBootstrap"
 	HostWaitingForBootstrapReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityInfo
+	// HostUnreachableReason indicates that the associated ElementalHost agent is not reachable.
+	HostUnreachableReason                                     = "HostUnreachable"
+	HostUnreachableReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityWarning
+	// HostFailedReason indicates that the associated ElementalHost has a terminal failure.
+	HostFailedReason                                     = "HostFailed"
+	HostFailedReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityError
+	// HostBootstrapTimeoutReason indicates that the associated ElementalHost was not bootstrapped before the deadline.
+	HostBootstrapTimeoutReason                                     = "HostBootstrapTimeout"
+	HostBootstrapTimeoutReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityError
 
 	// ProviderIDReady describes the ElementalMachine to downstream cluster node link status.
 	ProviderIDReady clusterv1.ConditionType = "ProviderIDReady"
 	// NodeNotFoundReason indicates that the downstream cluster node associated to this ElementalMachine is not found.
 	// This can happen if the node was manually deleted from the downstream cluster.
-	// This error is not recoverable, but it is possible to delete this ElementalMachine to rollout a new one.
+	// If the node is not found for longer than the NodeNotFoundGracePeriod, this error is considered terminal,
+	// it is then possible to delete this ElementalMachine to rollout a new one.
 	NodeNotFoundReason = "NodeNotFound"
 	// WaitingForControlPlaneReason indicates that the downstream cluster has no initialized control plane.
 	// This can happen if no CNI is running on the cluster
 	// or if there is any other problem initializing the control plane.
 	WaitingForControlPlaneReason                                     = "WaitingForControlPlaneInitialized"
 	WaitingForControlPlaneReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityInfo
+
+	// NodeSyncReady describes the synchronization of the ElementalHost metadata to the downstream cluster node.
+	NodeSyncReady clusterv1.ConditionType = "NodeSyncReady"
+	// NodeSyncFailedReason indicates that the metadata could not be synchronized to the downstream cluster node.
+	NodeSyncFailedReason                                     = "NodeSyncFailed"
+	NodeSyncFailedReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityWarning
 )
 
 // ElementalCluster Conditions and Reasons.
@@ -164,3 +229,35 @@ const (
 	// MissingControlPlaneEndpointReason indicates that the ElementalCluster.spec.controlPlaneEndpoint was not defined.
 	MissingControlPlaneEndpointReason = "MissingControlPlaneEndpoint"
 )
+
+// ElementalRegistration Conditions and Reasons.
+const (
+	// CARotationReady describes the progress of the certificate authority rotation.
+	CARotationReady clusterv1.ConditionType = "CARotationReady"
+	// CARotationPendingHostsReason indicates that the rotation was marked as complete,
+	// but some installed ElementalHosts do not trust the next certificate authority yet.
+	CARotationPendingHostsReason                                     = "CARotationPendingHosts"
+	CARotationPendingHostsReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityWarning
+	// CARotationInvalidCertificateReason indicates that the rotation can not be executed,
+	// because the next CA or the registration 'caCert' is not a PEM encoded certificate, for example a file path.
+	CARotationInvalidCertificateReason                                     = "CARotationInvalidCertificate"
+	CARotationInvalidCertificateReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityError
+)
+
+// ElementalHostReservation Conditions and Reasons.
+const (
+	// MissingRegistrationReason indicates that the referenced ElementalRegistration was not found,
+	// or that it has no signing key or URI yet, so no registration token could be issued.
+	MissingRegistrationReason                                     = "MissingRegistration"
+	MissingRegistrationReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityWarning
+)
+
+// ElementalHostDiagnostic Conditions and Reasons.
+const (
+	// MissingHostReason indicates that the referenced ElementalHost was not found.
+	MissingHostReason                                     = "MissingHost"
+	MissingHostReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityWarning
+	// WaitingForAgentReason indicates that the probes were not executed by the elemental-agent yet.
+	WaitingForAgentReason                                     = "WaitingForAgent"
+	WaitingForAgentReasonSeverity clusterv1.ConditionSeverity = clusterv1.ConditionSeverityInfo
+)
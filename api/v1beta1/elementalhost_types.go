@@ -21,6 +21,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 )
 
 // ElementalHostSpec defines the desired state of ElementalHost.
@@ -43,6 +44,33 @@ type ElementalHostSpec struct {
 	// +kubebuilder:validation:Schemaless
 	// +kubebuilder:validation:XPreserveUnknownFields
 	OSVersionManagement map[string]runtime.RawExtension `json:"osVersionManagement,omitempty" yaml:"osVersionManagement,omitempty"`
+	// Operation is an optional one-shot operation to be executed on the host.
+	// Each operation is executed once, a new one can be requested by changing its ID.
+	// +optional
+	Operation *HostOperation `json:"operation,omitempty"`
+}
+
+// HostOperationType defines the type of a one-shot host operation.
+// +kubebuilder:validation:Enum=Reboot;PowerOff;SupportBundle
+type HostOperationType string
+
+const (
+	HostOperationReboot        = HostOperationType("Reboot")
+	HostOperationPowerOff      = HostOperationType("PowerOff")
+	HostOperationSupportBundle = HostOperationType("SupportBundle")
+)
+
+// HostOperation defines a one-shot operation to be executed on the host.
+type HostOperation struct {
+	// ID uniquely identifies this operation.
+	// +kubebuilder:validation:MinLength=1
+	ID string `json:"id" yaml:"id"`
+	// Type defines the operation to execute.
+	Type HostOperationType `json:"type" yaml:"type"`
+	// Drain the downstream cluster Node before executing the operation.
+	// The Node is left cordoned afterwards.
+	// +optional
+	Drain bool `json:"drain,omitempty" yaml:"drain,omitempty"`
 }
 
 // ElementalHostStatus defines the observed state of ElementalHost.
@@ -50,9 +78,113 @@ type ElementalHostStatus struct {
 	// Phase defines the current host phase
 	// +optional
 	Phase HostPhase `json:"phase,omitempty"`
+	// PhaseHistory records the most recent phases of the host, oldest first.
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	PhaseHistory []HostPhaseRecord `json:"phaseHistory,omitempty"`
 	// Conditions defines current service state of the ElementalHost.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+	// LastSeen is the last time the elemental-agent successfully authenticated
+	// a request for this host.
+	// +optional
+	LastSeen *metav1.Time `json:"lastSeen,omitempty"`
+	// Operation defines the status of the last requested one-shot operation.
+	// +optional
+	Operation *HostOperationStatus `json:"operation,omitempty"`
+	// SupportBundle references the last support bundle uploaded by the elemental-agent.
+	// +optional
+	SupportBundle *SupportBundleStatus `json:"supportBundle,omitempty"`
+	// SystemUUID is the SMBIOS system UUID reported by the elemental-agent.
+	// It is used to find the downstream cluster Node when its name does not match the host name.
+	// +optional
+	SystemUUID string `json:"systemUUID,omitempty"`
+	// SystemModel is the SMBIOS system product name, or hardware model, reported by the elemental-agent.
+	// +optional
+	SystemModel string `json:"systemModel,omitempty"`
+	// AgentVersion is the version of the running elemental-agent.
+	// +optional
+	AgentVersion string `json:"agentVersion,omitempty"`
+	// AgentUpdateFailure is the last elemental-agent update failure reported by the elemental-agent.
+	// It is cleared once the elemental-agent runs the desired version.
+	// +optional
+	AgentUpdateFailure *AgentUpdateFailure `json:"agentUpdateFailure,omitempty"`
+	// AgentConfigHash is the sha256 hash of the elemental-agent config applied on the host.
+	// +optional
+	AgentConfigHash string `json:"agentConfigHash,omitempty"`
+	// TrustedCAs are the sha256 fingerprints of the certificate authorities trusted by the elemental-agent.
+	// +optional
+	TrustedCAs []string `json:"trustedCAs,omitempty"`
+	// Addresses are the IP addresses reported by the elemental-agent.
+	// They are used to find the downstream cluster Node when its name does not match the host name.
+	// +optional
+	Addresses []string `json:"addresses,omitempty"`
+	// Capacity is the cpu and memory capacity reported by the elemental-agent.
+	// +optional
+	Capacity corev1.ResourceList `json:"capacity,omitempty"`
+	// FailureReason will be set in the event that there is a terminal problem
+	// with the ElementalHost and will contain a succinct value suitable
+	// for machine interpretation.
+	// Once set, the ElementalHost is no longer reconciled nor associated, until it is reset.
+	// +optional
+	FailureReason *capierrors.MachineStatusError `json:"failureReason,omitempty"`
+	// FailureMessage will be set in the event that there is a terminal problem
+	// with the ElementalHost and will contain a more verbose string suitable
+	// for logging and human consumption.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+}
+
+// HostOperationStatus defines the observed state of a one-shot operation.
+type HostOperationStatus struct {
+	// ID of the operation.
+	ID string `json:"id"`
+	// Drained is true when the downstream cluster Node was drained.
+	// +optional
+	Drained bool `json:"drained,omitempty"`
+	// AcknowledgedAt is the time the elemental-agent acknowledged the operation, before executing it.
+	// +optional
+	AcknowledgedAt *metav1.Time `json:"acknowledgedAt,omitempty"`
+}
+
+// SupportBundleStatus defines the last support bundle uploaded by the elemental-agent.
+type SupportBundleStatus struct {
+	// SecretName is the name of the Secret, in the ElementalHost namespace, containing the support bundle files.
+	SecretName string `json:"secretName"`
+	// Reason the support bundle was uploaded.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// UploadedAt is the time the support bundle was uploaded.
+	UploadedAt metav1.Time `json:"uploadedAt"`
+}
+
+// AgentUpdateFailure defines a failed elemental-agent update.
+type AgentUpdateFailure struct {
+	// Version of the elemental-agent that failed to be updated to.
+	Version string `json:"version"`
+	// Message describing the failure.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// FailedAt is the time the elemental-agent reported the failure.
+	FailedAt metav1.Time `json:"failedAt"`
+}
+
+// HostPhaseRecord defines a past or current phase of the host.
+type HostPhaseRecord struct {
+	// Phase of the host.
+	Phase HostPhase `json:"phase"`
+	// StartTime is the time the elemental-agent reported this phase.
+	StartTime metav1.Time `json:"startTime"`
+	// EndTime is the time the elemental-agent reported the next phase.
+	// It is not set for the current phase.
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+	// Outcome of the phase. It is not set for the current phase.
+	// +optional
+	Outcome HostPhaseOutcome `json:"outcome,omitempty"`
+	// Reason is the ElementalHost Ready condition reason, if the phase failed.
+	// +optional
+	Reason string `json:"reason,omitempty"`
 }
 
 // GetConditions returns the set of conditions for this object.
@@ -65,13 +197,22 @@ func (h *ElementalHost) SetConditions(conditions clusterv1.Conditions) {
 	h.Status.Conditions = conditions
 }
 
+// IsPendingApproval returns true if the ElementalHost needs approval before being installed.
+func (h *ElementalHost) IsPendingApproval() bool {
+	approved, found := h.Annotations[AnnotationElementalHostApproved]
+	return found && approved != "true"
+}
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:metadata:labels="clusterctl.cluster.x-k8s.io/move="
 //+kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".metadata.labels['cluster\\.x-k8s\\.io/cluster-name']",description="Cluster"
 //+kubebuilder:printcolumn:name="Machine",type="string",JSONPath=".metadata.labels['elementalhost\\.infrastructure\\.cluster\\.x-k8s\\.io/machine-name']",description="Machine object associated to this ElementalHost (through ElementalMachine)"
 //+kubebuilder:printcolumn:name="ElementalMachine",type="string",JSONPath=".spec.machineRef.name",description="ElementalMachine object associated to this ElementalHost"
 //+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="ElementalHost phase"
 //+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="ElementalHost ready condition"
+//+kubebuilder:printcolumn:name="Last Seen",type="date",JSONPath=".status.lastSeen",description="Time duration since the elemental-agent was last seen"
+//+kubebuilder:printcolumn:name="Agent Version",type="string",JSONPath=".status.agentVersion",description="Version of the running elemental-agent",priority=1
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of ElementalHost"
 
 // ElementalHost is the Schema for the elementalhosts API.
@@ -0,0 +1,121 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// DiagnosticProbe defines a read-only probe executed by the elemental-agent.
+// +kubebuilder:validation:Enum=RegistrationReachability;DiskList;ElementalState;TimeSync;DNSResolution
+type DiagnosticProbe string
+
+const (
+	// DiagnosticProbeRegistrationReachability checks the TCP reachability of the registration URI.
+	DiagnosticProbeRegistrationReachability = DiagnosticProbe("RegistrationReachability")
+	// DiagnosticProbeDiskList lists the block devices.
+	DiagnosticProbeDiskList = DiagnosticProbe("DiskList")
+	// DiagnosticProbeElementalState returns the `elemental state` output.
+	DiagnosticProbeElementalState = DiagnosticProbe("ElementalState")
+	// DiagnosticProbeTimeSync returns the system clock synchronization status.
+	DiagnosticProbeTimeSync = DiagnosticProbe("TimeSync")
+	// DiagnosticProbeDNSResolution resolves the registration URI host name.
+	DiagnosticProbeDNSResolution = DiagnosticProbe("DNSResolution")
+)
+
+// ElementalHostDiagnosticSpec defines the desired state of ElementalHostDiagnostic.
+type ElementalHostDiagnosticSpec struct {
+	// HostRef is the ElementalHost to diagnose.
+	// The ElementalHost must be in the same namespace as the diagnostic.
+	HostRef corev1.LocalObjectReference `json:"hostRef"`
+	// Probes to execute on the host.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	Probes []DiagnosticProbe `json:"probes"`
+}
+
+// DiagnosticProbeResult defines the result of a probe executed by the elemental-agent.
+type DiagnosticProbeResult struct {
+	// Probe executed.
+	Probe DiagnosticProbe `json:"probe"`
+	// Succeeded is true when the probe command exited successfully.
+	Succeeded bool `json:"succeeded"`
+	// Output of the probe command, truncated to its most recent content.
+	// +optional
+	Output string `json:"output,omitempty"`
+}
+
+// ElementalHostDiagnosticStatus defines the observed state of ElementalHostDiagnostic.
+type ElementalHostDiagnosticStatus struct {
+	// Results of the executed probes.
+	// +optional
+	Results []DiagnosticProbeResult `json:"results,omitempty"`
+	// CompletedAt is the time the elemental-agent posted the probe results.
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+	// Conditions defines current service state of the ElementalHostDiagnostic.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// IsCompleted returns true if the elemental-agent already posted the probe results.
+func (d *ElementalHostDiagnostic) IsCompleted() bool {
+	return d.Status.CompletedAt != nil
+}
+
+// GetConditions returns the set of conditions for this object.
+func (d *ElementalHostDiagnostic) GetConditions() clusterv1.Conditions {
+	return d.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (d *ElementalHostDiagnostic) SetConditions(conditions clusterv1.Conditions) {
+	d.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=elementalhostdiagnostics,scope=Namespaced,categories=cluster-api,shortName=ehd
+//+kubebuilder:printcolumn:name="Host",type="string",JSONPath=".spec.hostRef.name",description="ElementalHost to diagnose"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Probe results are available"
+//+kubebuilder:printcolumn:name="Completed",type="date",JSONPath=".status.completedAt",description="Time duration since the probe results were posted"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of ElementalHostDiagnostic"
+
+// ElementalHostDiagnostic is the Schema for the elementalhostdiagnostics API.
+// It requests the execution of read-only probes on an ElementalHost, and collects their results.
+type ElementalHostDiagnostic struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ElementalHostDiagnosticSpec   `json:"spec,omitempty"`
+	Status ElementalHostDiagnosticStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ElementalHostDiagnosticList contains a list of ElementalHostDiagnostic.
+type ElementalHostDiagnosticList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElementalHostDiagnostic `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElementalHostDiagnostic{}, &ElementalHostDiagnosticList{})
+}
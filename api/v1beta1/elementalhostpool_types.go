@@ -0,0 +1,107 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ElementalHostPoolSpec defines the desired state of ElementalHostPool.
+type ElementalHostPoolSpec struct {
+	// RegistrationRef selects the ElementalHosts registered through this ElementalRegistration.
+	// The ElementalRegistration must be in the same namespace as the pool.
+	// +optional
+	RegistrationRef *corev1.LocalObjectReference `json:"registrationRef,omitempty"`
+	// Selector selects the ElementalHosts matching these labels.
+	// If both RegistrationRef and Selector are defined, ElementalHosts must match both.
+	// If none is defined, all the ElementalHosts in the pool namespace are selected.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// AllowedNamespaces are the namespaces whose ElementalMachines are allowed to claim hosts from this pool.
+	// ElementalMachines in the pool namespace are always allowed.
+	// +optional
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+	// Spares defines a number of unassociated ElementalHosts to keep installed and on a target OS version,
+	// so that they are ready to be associated without reconciling the OS version during bootstrap.
+	// +optional
+	Spares *SparePolicy `json:"spares,omitempty"`
+}
+
+// SparePolicy defines the warm spare ElementalHosts of an ElementalHostPool.
+type SparePolicy struct {
+	// Count is the number of unassociated ElementalHosts to keep on the target OS version.
+	// +kubebuilder:validation:Minimum=0
+	Count int32 `json:"count"`
+	// OSVersionManagement defines the target OS version of the spare ElementalHosts.
+	// The supported schema depends on the OSPlugin in use by the elemental-agent.
+	// It should match the OSVersionManagement of the ElementalMachines claiming hosts from this pool.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:XPreserveUnknownFields
+	OSVersionManagement map[string]runtime.RawExtension `json:"osVersionManagement,omitempty"`
+}
+
+// ElementalHostPoolStatus defines the observed state of ElementalHostPool.
+type ElementalHostPoolStatus struct {
+	// FreeHosts is the number of ElementalHosts available for association.
+	// +optional
+	FreeHosts int32 `json:"freeHosts"`
+	// ClaimedHosts is the number of ElementalHosts associated to an ElementalMachine.
+	// +optional
+	ClaimedHosts int32 `json:"claimedHosts"`
+	// InstallingHosts is the number of ElementalHosts not installed yet.
+	// +optional
+	InstallingHosts int32 `json:"installingHosts"`
+	// SpareHosts is the number of free ElementalHosts already on the spares target OS version.
+	// +optional
+	SpareHosts int32 `json:"spareHosts"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=elementalhostpools,scope=Namespaced,categories=cluster-api,shortName=ehp
+//+kubebuilder:metadata:labels="clusterctl.cluster.x-k8s.io/move="
+//+kubebuilder:printcolumn:name="Free",type="integer",JSONPath=".status.freeHosts",description="Number of ElementalHosts available for association"
+//+kubebuilder:printcolumn:name="Claimed",type="integer",JSONPath=".status.claimedHosts",description="Number of ElementalHosts associated to an ElementalMachine"
+//+kubebuilder:printcolumn:name="Spare",type="integer",JSONPath=".status.spareHosts",description="Number of free ElementalHosts on the spares target OS version"
+//+kubebuilder:printcolumn:name="Installing",type="integer",JSONPath=".status.installingHosts",description="Number of ElementalHosts not installed yet"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of ElementalHostPool"
+
+// ElementalHostPool is the Schema for the elementalhostpools API.
+// It groups ElementalHosts that can be claimed by ElementalMachines in other namespaces.
+type ElementalHostPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ElementalHostPoolSpec   `json:"spec,omitempty"`
+	Status ElementalHostPoolStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ElementalHostPoolList contains a list of ElementalHostPool.
+type ElementalHostPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElementalHostPool `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElementalHostPool{}, &ElementalHostPoolList{})
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// ElementalHostReservationSpec defines the desired state of ElementalHostReservation.
+type ElementalHostReservationSpec struct {
+	// RegistrationRef is the ElementalRegistration the reserved host registers through.
+	// The ElementalRegistration must be in the same namespace as the reservation.
+	RegistrationRef corev1.LocalObjectReference `json:"registrationRef"`
+	// SerialNumber is the expected SMBIOS system serial number of the reserved host.
+	// If set, the host must report the same serial number to register.
+	// +optional
+	SerialNumber string `json:"serialNumber,omitempty"`
+	// MACAddresses are the expected network interfaces MAC addresses of the reserved host.
+	// If set, the host must report at least one of them to register.
+	// +optional
+	MACAddresses []string `json:"macAddresses,omitempty"`
+	// HostLabels are labels applied to the reserved ElementalHost, in addition to the ElementalRegistration ones.
+	// +optional
+	HostLabels map[string]string `json:"hostLabels,omitempty"`
+	// HostAnnotations are annotations applied to the reserved ElementalHost, in addition to the ElementalRegistration ones.
+	// +optional
+	HostAnnotations map[string]string `json:"hostAnnotations,omitempty"`
+	// Install overrides the ElementalRegistration install configuration for the reserved host.
+	// Each field replaces the same ElementalRegistration install field.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:XPreserveUnknownFields
+	Install map[string]runtime.RawExtension `json:"install,omitempty"`
+	// TokenDuration is the validity of the one-time registration token. If not set, the token does not expire.
+	// +optional
+	TokenDuration *metav1.Duration `json:"tokenDuration,omitempty"`
+}
+
+// ElementalHostReservationStatus defines the observed state of ElementalHostReservation.
+type ElementalHostReservationStatus struct {
+	// Token is the one-time registration token bound to this reservation.
+	// It can be used in place of the ElementalRegistration token to register the reserved host only.
+	// +optional
+	Token string `json:"token,omitempty"`
+	// HostRef references the ElementalHost registered with this reservation, once the token was consumed.
+	// +optional
+	HostRef *corev1.ObjectReference `json:"hostRef,omitempty"`
+	// Conditions defines current service state of the ElementalHostReservation.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// GetConditions returns the set of conditions for this object.
+func (r *ElementalHostReservation) GetConditions() clusterv1.Conditions {
+	return r.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (r *ElementalHostReservation) SetConditions(conditions clusterv1.Conditions) {
+	r.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=elementalhostreservations,scope=Namespaced,categories=cluster-api,shortName=ehr
+//+kubebuilder:printcolumn:name="Registration",type="string",JSONPath=".spec.registrationRef.name",description="ElementalRegistration the reserved host registers through"
+//+kubebuilder:printcolumn:name="Host",type="string",JSONPath=".status.hostRef.name",description="ElementalHost registered with this reservation"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Registration token is ready"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of ElementalHostReservation"
+
+// ElementalHostReservation is the Schema for the elementalhostreservations API.
+// It pre-declares an expected host, and provides a one-time registration token bound to it.
+type ElementalHostReservation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ElementalHostReservationSpec   `json:"spec,omitempty"`
+	Status ElementalHostReservationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ElementalHostReservationList contains a list of ElementalHostReservation.
+type ElementalHostReservationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElementalHostReservation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElementalHostReservation{}, &ElementalHostReservationList{})
+}
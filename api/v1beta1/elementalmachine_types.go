@@ -21,6 +21,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 )
 
 // ElementalMachineSpec defines the desired state of ElementalMachine.
@@ -34,6 +35,12 @@ type ElementalMachineSpec struct {
 	// +optional
 	Selector *metav1.LabelSelector `json:"selector,omitempty"`
 
+	// HostPoolRef is an optional reference to an ElementalHostPool to claim ElementalHosts from.
+	// The ElementalHostPool may be in a different namespace, as long as this namespace is allowed by the pool.
+	// If not set, ElementalHosts are selected from the ElementalMachine namespace.
+	// +optional
+	HostPoolRef *corev1.ObjectReference `json:"hostPoolRef,omitempty"`
+
 	// HostRef is an optional reference to a ElementalHost
 	// using this host.
 	// +optional
@@ -47,6 +54,32 @@ type ElementalMachineSpec struct {
 	// +kubebuilder:validation:Schemaless
 	// +kubebuilder:validation:XPreserveUnknownFields
 	OSVersionManagement map[string]runtime.RawExtension `json:"osVersionManagement,omitempty" yaml:"osVersionManagement,omitempty"`
+
+	// BootstrapTimeout is the deadline for the associated ElementalHost to be bootstrapped,
+	// starting from the association. If not set, there is no deadline.
+	// Once the deadline passes, the ElementalHost is reset and a new one is associated,
+	// or the ElementalMachine fails, depending on the policy.
+	// +optional
+	BootstrapTimeout *Timeout `json:"bootstrapTimeout,omitempty"`
+
+	// NodeSync defines which metadata is continuously synchronized
+	// from the associated ElementalHost to the downstream cluster node.
+	// +optional
+	NodeSync *NodeSyncPolicy `json:"nodeSync,omitempty"`
+}
+
+// NodeSyncPolicy defines the metadata synchronized to the downstream cluster node.
+// Metadata previously synchronized, but no longer selected, is removed from the node.
+type NodeSyncPolicy struct {
+	// LabelPrefixes selects the ElementalHost labels starting with any of the prefixes.
+	// +optional
+	LabelPrefixes []string `json:"labelPrefixes,omitempty"`
+	// AnnotationPrefixes selects the ElementalHost annotations starting with any of the prefixes.
+	// +optional
+	AnnotationPrefixes []string `json:"annotationPrefixes,omitempty"`
+	// Taints are applied to the downstream cluster node.
+	// +optional
+	Taints []corev1.Taint `json:"taints,omitempty"`
 }
 
 // ElementalMachineStatus defines the observed state of ElementalMachine.
@@ -62,6 +95,23 @@ type ElementalMachineStatus struct {
 	// FailureDomains defines the failure domains that machines should be placed in.
 	// +optional
 	FailureDomains clusterv1.FailureDomains `json:"failureDomains,omitempty"`
+
+	// NodeName is the name of the downstream cluster Node matching the associated ElementalHost.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+
+	// FailureReason will be set in the event that there is a terminal problem
+	// reconciling the ElementalMachine and will contain a succinct value suitable
+	// for machine interpretation.
+	// Once set, the ElementalMachine is no longer reconciled, it must be deleted to rollout a new one.
+	// +optional
+	FailureReason *capierrors.MachineStatusError `json:"failureReason,omitempty"`
+
+	// FailureMessage will be set in the event that there is a terminal problem
+	// reconciling the ElementalMachine and will contain a more verbose string suitable
+	// for logging and human consumption.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
 }
 
 // GetConditions returns the set of conditions for this object.
@@ -17,6 +17,7 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -34,16 +35,42 @@ type InfraMachineTemplateResource struct {
 	Spec ElementalMachineSpec `json:"spec"`
 }
 
+// ElementalMachineTemplateStatus defines the observed state of ElementalMachineTemplate.
+// It is computed from the ElementalHosts matching the template selector,
+// and it can be used by the cluster-autoscaler to scale from zero.
+type ElementalMachineTemplateStatus struct {
+	// Capacity defines the resource capacity of the Nodes created from this template.
+	// It is the lowest capacity reported by any of the matching ElementalHosts,
+	// including the default maximum number of pods.
+	// +optional
+	Capacity corev1.ResourceList `json:"capacity,omitempty"`
+	// NodeLabels are the labels shared by all the matching ElementalHosts,
+	// that are synchronized to the Nodes created from this template.
+	// +optional
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+	// MatchingHosts is the number of ElementalHosts matching the template selector.
+	// +optional
+	MatchingHosts int32 `json:"matchingHosts"`
+	// AvailableHosts is the number of matching ElementalHosts available for association.
+	// +optional
+	AvailableHosts int32 `json:"availableHosts"`
+}
+
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 // +kubebuilder:resource:path=elementalmachinetemplates,scope=Namespaced,categories=cluster-api,shortName=emt
 // +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Matching",type="integer",JSONPath=".status.matchingHosts",description="Number of matching ElementalHosts"
+// +kubebuilder:printcolumn:name="Available",type="integer",JSONPath=".status.availableHosts",description="Number of matching ElementalHosts available for association"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of ElementalMachineTemplate"
 
 // ElementalMachineTemplate is the Schema for the elementalmachinetemplates API.
 type ElementalMachineTemplate struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec ElementalMachineTemplateSpec `json:"spec,omitempty"`
+	Spec   ElementalMachineTemplateSpec   `json:"spec,omitempty"`
+	Status ElementalMachineTemplateStatus `json:"status,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -38,15 +38,119 @@ type ElementalRegistrationSpec struct {
 	Config Config `json:"config,omitempty"`
 	// PrivateKeyRef is a reference to a secret containing the private key used to generate registration tokens
 	PrivateKeyRef *corev1.ObjectReference `json:"privateKeyRef,omitempty"`
+	// InstallTimeout is the deadline for each ElementalHost to be installed,
+	// starting from its registration. If not set, there is no deadline.
+	// Once the deadline passes, the ElementalHost is reset or fails, depending on the policy.
+	// +optional
+	InstallTimeout *Timeout `json:"installTimeout,omitempty"`
+	// RequireApproval makes new ElementalHosts wait for approval before being installed.
+	// ElementalHosts are approved by setting the 'elementalhost.infrastructure.cluster.x-k8s.io/approved' annotation to "true".
+	// +optional
+	RequireApproval bool `json:"requireApproval,omitempty"`
+	// AutoApprove defines the ElementalHosts approved on registration, when RequireApproval is true.
+	// +optional
+	AutoApprove *AutoApprovePolicy `json:"autoApprove,omitempty"`
+	// MaxHosts is the maximum number of ElementalHosts that can be registered through this registration.
+	// New registrations are rejected once the limit is reached. If not set, there is no limit.
+	// The limit is enforced on a best effort basis: concurrent registrations may briefly exceed it.
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	MaxHosts *int32 `json:"maxHosts,omitempty"`
+	// MaxRegistrationsPerMinute is the maximum rate of new ElementalHost registrations through this registration.
+	// Registrations exceeding the rate are rejected and should be retried later. If not set, there is no limit.
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	MaxRegistrationsPerMinute int32 `json:"maxRegistrationsPerMinute,omitempty"`
+	// AbandonedHostTTL is the time after which ElementalHosts that are not installed yet, and whose elemental-agent
+	// was not seen since, are considered abandoned and deleted without reset. If not set, abandoned hosts are never deleted.
+	// +optional
+	AbandonedHostTTL *metav1.Duration `json:"abandonedHostTTL,omitempty"`
+	// AgentUpdate is the desired elemental-agent binary of the ElementalHosts registered through this registration.
+	// Running ElementalHosts replace their elemental-agent binary, without upgrading the whole OS.
+	// If not set, ElementalHosts run the elemental-agent binary shipped with their OS.
+	// +optional
+	AgentUpdate *AgentUpdate `json:"agentUpdate,omitempty"`
+	// CARotation rotates the certificate authority trusted by the ElementalHosts registered through this registration,
+	// without re-imaging them.
+	// +optional
+	CARotation *CARotation `json:"caRotation,omitempty"`
+}
+
+// CARotation defines the next certificate authority of the Elemental API.
+//
+// While the rotation is in progress, the next certificate authority is appended to the registration 'caCert' bundle,
+// so that the ElementalHosts trust both the current and the next one.
+// Once no ElementalHost is pending, the Elemental API can be served with a certificate signed by the next certificate authority.
+// Completing the rotation removes the previous certificate authorities from the bundle.
+// The registration 'caCert' must be PEM encoded, the rotation is rejected if it is a file path.
+type CARotation struct {
+	// NextCACert is the PEM encoded next certificate authority.
+	// +kubebuilder:validation:MinLength=1
+	NextCACert string `json:"nextCACert"`
+	// Complete removes the previous certificate authorities from the registration 'caCert' bundle.
+	// It must only be set once the Elemental API is served with a certificate signed by the next certificate authority.
+	// Completing the rotation is refused as long as any installed ElementalHost does not trust the next certificate authority.
+	// +optional
+	Complete bool `json:"complete,omitempty"`
+}
+
+// AgentUpdate defines an elemental-agent binary to download.
+type AgentUpdate struct {
+	// Version of the elemental-agent binary, as reported by the 'elemental-agent version' command.
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+	// URL to download the elemental-agent binary from.
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+	// SHA256 is the hex encoded SHA256 checksum of the elemental-agent binary.
+	// +kubebuilder:validation:Pattern=`^[a-f0-9]{64}$`
+	SHA256 string `json:"sha256"`
+	// Signature is the base64 encoded ed25519 signature of the elemental-agent binary.
+	// It is verified with the public key in the agent 'updatePublicKey' config.
+	// +kubebuilder:validation:MinLength=1
+	Signature string `json:"signature"`
+}
+
+// AutoApprovePolicy defines the expected ElementalHosts to be approved on registration.
+// An ElementalHost matching any of the serial numbers or MAC addresses is approved.
+type AutoApprovePolicy struct {
+	// SerialNumbers are the expected SMBIOS system serial numbers.
+	// +optional
+	SerialNumbers []string `json:"serialNumbers,omitempty"`
+	// MACAddresses are the expected network interfaces MAC addresses.
+	// +optional
+	MACAddresses []string `json:"macAddresses,omitempty"`
 }
 
 // ElementalRegistrationStatus defines the observed state of ElementalRegistration.
 type ElementalRegistrationStatus struct {
+	// RegisteredHosts is the number of ElementalHosts registered through this registration.
+	// +optional
+	RegisteredHosts int32 `json:"registeredHosts,omitempty"`
+	// InstalledHosts is the number of ElementalHosts registered through this registration and installed.
+	// +optional
+	InstalledHosts int32 `json:"installedHosts,omitempty"`
+	// OrphanedHosts are the names of the ElementalHosts registered through this registration,
+	// whose MachineRef references an ElementalMachine that does not exist anymore.
+	// +optional
+	OrphanedHosts []string `json:"orphanedHosts,omitempty"`
+	// CARotation reports the progress of the certificate authority rotation, if any.
+	// +optional
+	CARotation *CARotationStatus `json:"caRotation,omitempty"`
 	// Conditions defines current service state of the ElementalRegistration.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 }
 
+// CARotationStatus defines the progress of the certificate authority rotation.
+type CARotationStatus struct {
+	// NextCAFingerprints are the sha256 fingerprints of the next certificate authority certificates.
+	// +optional
+	NextCAFingerprints []string `json:"nextCAFingerprints,omitempty"`
+	// PendingHosts is the number of installed ElementalHosts that do not trust the next certificate authority yet.
+	PendingHosts int32 `json:"pendingHosts"`
+}
+
 // GetConditions returns the set of conditions for this object.
 func (m *ElementalRegistration) GetConditions() clusterv1.Conditions {
 	return m.Status.Conditions
@@ -59,6 +163,10 @@ func (m *ElementalRegistration) SetConditions(conditions clusterv1.Conditions) {
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:metadata:labels="clusterctl.cluster.x-k8s.io/move-hierarchy="
+//+kubebuilder:printcolumn:name="Registered",type="integer",JSONPath=".status.registeredHosts",description="Number of registered ElementalHosts"
+//+kubebuilder:printcolumn:name="Installed",type="integer",JSONPath=".status.installedHosts",description="Number of installed ElementalHosts"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of ElementalRegistration"
 
 // ElementalRegistration is the Schema for the ElementalRegistrations API.
 type ElementalRegistration struct {
@@ -120,6 +228,9 @@ type Agent struct {
 	// +kubebuilder:default:=10000000000
 	Reconciliation time.Duration `json:"reconciliation,omitempty" yaml:"reconciliation,omitempty" mapstructure:"reconciliation"`
 	// +optional
+	// +kubebuilder:default:={}
+	Backoff Backoff `json:"backoff,omitempty" yaml:"backoff,omitempty" mapstructure:"backoff"`
+	// +optional
 	InsecureAllowHTTP bool `json:"insecureAllowHttp,omitempty" yaml:"insecureAllowHttp,omitempty" mapstructure:"insecureAllowHttp"`
 	// +optional
 	InsecureSkipTLSVerify bool `json:"insecureSkipTlsVerify,omitempty" yaml:"insecureSkipTlsVerify,omitempty" mapstructure:"insecureSkipTlsVerify"`
@@ -129,6 +240,36 @@ type Agent struct {
 	PostInstall PostAction `json:"postInstall,omitempty" yaml:"postInstall,omitempty" mapstructure:"postInstall"`
 	// +optional
 	PostReset PostAction `json:"postReset,omitempty" yaml:"postReset,omitempty" mapstructure:"postReset"`
+	// UpdatePublicKey is the PEM encoded ed25519 public key verifying the elemental-agent updates signature.
+	// If not set, the elemental-agent refuses any update.
+	// +optional
+	UpdatePublicKey string `json:"updatePublicKey,omitempty" yaml:"updatePublicKey,omitempty" mapstructure:"updatePublicKey"`
+}
+
+// Backoff configures the delay between agent retries on failures.
+// The delay starts from Initial and is multiplied by Factor after each consecutive failure, up to Max.
+// A random Jitter percentage is subtracted from each delay, so that hosts do not retry in lockstep.
+// Zero values fallback to the agent defaults, except for Jitter that is only defaulted when not set.
+type Backoff struct {
+	// Initial is the delay after the first failure. Defaults to the agent reconciliation interval.
+	// +optional
+	Initial time.Duration `json:"initial,omitempty" yaml:"initial,omitempty" mapstructure:"initial"`
+	// Max is the maximum delay between retries.
+	// +optional
+	// +kubebuilder:default:=300000000000
+	Max time.Duration `json:"max,omitempty" yaml:"max,omitempty" mapstructure:"max"`
+	// Factor is the multiplier applied to the delay after each consecutive failure.
+	// +optional
+	// +kubebuilder:default:=2
+	// +kubebuilder:validation:Minimum:=1
+	Factor int `json:"factor,omitempty" yaml:"factor,omitempty" mapstructure:"factor"`
+	// Jitter is the maximum percentage of the delay to be randomly subtracted.
+	// A zero Jitter disables it.
+	// +optional
+	// +kubebuilder:default:=20
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=100
+	Jitter *int `json:"jitter,omitempty" yaml:"jitter,omitempty" mapstructure:"jitter"`
 }
 
 // PostAction is used to return instructions to the cli after a Phase is handled.
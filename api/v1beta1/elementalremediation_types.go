@@ -0,0 +1,105 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RemediationPhase defines the current remediation step.
+type RemediationPhase string
+
+const (
+	// PhaseRemediationRebooting indicates that the ElementalHost was asked to reboot.
+	PhaseRemediationRebooting = RemediationPhase("Rebooting")
+	// PhaseRemediationResetting indicates that the ElementalHost was asked to reset.
+	PhaseRemediationResetting = RemediationPhase("Resetting")
+	// PhaseRemediationFailed indicates that all remediation steps failed and the Machine is going to be replaced.
+	PhaseRemediationFailed = RemediationPhase("Failed")
+)
+
+// ElementalRemediationSpec defines the desired state of ElementalRemediation.
+type ElementalRemediationSpec struct {
+	// Strategy defines the remediation steps.
+	// +optional
+	// +kubebuilder:default:={}
+	Strategy RemediationStrategy `json:"strategy,omitempty"`
+}
+
+// RemediationStrategy defines how the unhealthy ElementalHost is remediated.
+// Remediation escalates from rebooting the host, to resetting it,
+// to finally marking it as failed and letting the Machine be replaced.
+type RemediationStrategy struct {
+	// RetryLimit is the maximum number of reboots to attempt before resetting the host.
+	// +optional
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum=0
+	RetryLimit int `json:"retryLimit"`
+	// Timeout is the time to wait for the host to recover after each remediation step,
+	// before escalating to the next one.
+	// +optional
+	// +kubebuilder:default:="10m"
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ElementalRemediationStatus defines the observed state of ElementalRemediation.
+type ElementalRemediationStatus struct {
+	// Phase defines the current remediation step.
+	// +optional
+	Phase RemediationPhase `json:"phase,omitempty"`
+	// RetryCount is the number of reboots attempted so far.
+	// +optional
+	RetryCount int `json:"retryCount,omitempty"`
+	// LastRemediated is the time the last remediation step was taken.
+	// +optional
+	LastRemediated *metav1.Time `json:"lastRemediated,omitempty"`
+	// HostRef is a reference to the remediated ElementalHost.
+	// +optional
+	HostRef *corev1.ObjectReference `json:"hostRef,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=elementalremediations,scope=Namespaced,categories=cluster-api
+//+kubebuilder:printcolumn:name="Host",type="string",JSONPath=".status.hostRef.name",description="ElementalHost being remediated"
+//+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Remediation phase"
+//+kubebuilder:printcolumn:name="Retries",type="integer",JSONPath=".status.retryCount",description="Number of reboots attempted"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of ElementalRemediation"
+
+// ElementalRemediation is the Schema for the elementalremediations API.
+// This is created by the CAPI MachineHealthCheck controller from an ElementalRemediationTemplate.
+type ElementalRemediation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ElementalRemediationSpec   `json:"spec,omitempty"`
+	Status ElementalRemediationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ElementalRemediationList contains a list of ElementalRemediation.
+type ElementalRemediationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElementalRemediation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElementalRemediation{}, &ElementalRemediationList{})
+}
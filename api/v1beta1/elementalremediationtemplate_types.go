@@ -0,0 +1,59 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ElementalRemediationTemplateSpec defines the desired state of ElementalRemediationTemplate.
+type ElementalRemediationTemplateSpec struct {
+	Template ElementalRemediationTemplateResource `json:"template"`
+}
+
+type ElementalRemediationTemplateResource struct {
+	// Standard object's metadata.
+	// More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata
+	// +optional
+	ObjectMeta metav1.ObjectMeta        `json:"metadata,omitempty"`
+	Spec       ElementalRemediationSpec `json:"spec"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=elementalremediationtemplates,scope=Namespaced,categories=cluster-api
+
+// ElementalRemediationTemplate is the Schema for the elementalremediationtemplates API.
+// It can be referenced by a CAPI MachineHealthCheck as remediationTemplate.
+type ElementalRemediationTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ElementalRemediationTemplateSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ElementalRemediationTemplateList contains a list of ElementalRemediationTemplate.
+type ElementalRemediationTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElementalRemediationTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElementalRemediationTemplate{}, &ElementalRemediationTemplateList{})
+}
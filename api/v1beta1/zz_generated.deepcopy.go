@@ -25,12 +25,14 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	apiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/errors"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Agent) DeepCopyInto(out *Agent) {
 	*out = *in
 	out.Hostname = in.Hostname
+	in.Backoff.DeepCopyInto(&out.Backoff)
 	out.PostInstall = in.PostInstall
 	out.PostReset = in.PostReset
 }
@@ -45,6 +47,117 @@ func (in *Agent) DeepCopy() *Agent {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentUpdate) DeepCopyInto(out *AgentUpdate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentUpdate.
+func (in *AgentUpdate) DeepCopy() *AgentUpdate {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentUpdate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentUpdateFailure) DeepCopyInto(out *AgentUpdateFailure) {
+	*out = *in
+	in.FailedAt.DeepCopyInto(&out.FailedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentUpdateFailure.
+func (in *AgentUpdateFailure) DeepCopy() *AgentUpdateFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentUpdateFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoApprovePolicy) DeepCopyInto(out *AutoApprovePolicy) {
+	*out = *in
+	if in.SerialNumbers != nil {
+		in, out := &in.SerialNumbers, &out.SerialNumbers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MACAddresses != nil {
+		in, out := &in.MACAddresses, &out.MACAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoApprovePolicy.
+func (in *AutoApprovePolicy) DeepCopy() *AutoApprovePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoApprovePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Backoff) DeepCopyInto(out *Backoff) {
+	*out = *in
+	if in.Jitter != nil {
+		in, out := &in.Jitter, &out.Jitter
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Backoff.
+func (in *Backoff) DeepCopy() *Backoff {
+	if in == nil {
+		return nil
+	}
+	out := new(Backoff)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CARotation) DeepCopyInto(out *CARotation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CARotation.
+func (in *CARotation) DeepCopy() *CARotation {
+	if in == nil {
+		return nil
+	}
+	out := new(CARotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CARotationStatus) DeepCopyInto(out *CARotationStatus) {
+	*out = *in
+	if in.NextCAFingerprints != nil {
+		in, out := &in.NextCAFingerprints, &out.NextCAFingerprints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CARotationStatus.
+func (in *CARotationStatus) DeepCopy() *CARotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CARotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Config) DeepCopyInto(out *Config) {
 	*out = *in
@@ -68,6 +181,21 @@ func (in *Config) DeepCopy() *Config {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiagnosticProbeResult) DeepCopyInto(out *DiagnosticProbeResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiagnosticProbeResult.
+func (in *DiagnosticProbeResult) DeepCopy() *DiagnosticProbeResult {
+	if in == nil {
+		return nil
+	}
+	out := new(DiagnosticProbeResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Elemental) DeepCopyInto(out *Elemental) {
 	*out = *in
@@ -86,7 +214,7 @@ func (in *Elemental) DeepCopyInto(out *Elemental) {
 		}
 	}
 	out.Registration = in.Registration
-	out.Agent = in.Agent
+	in.Agent.DeepCopyInto(&out.Agent)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Elemental.
@@ -322,31 +450,58 @@ func (in *ElementalHost) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalHostList) DeepCopyInto(out *ElementalHostList) {
+func (in *ElementalHostDiagnostic) DeepCopyInto(out *ElementalHostDiagnostic) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostDiagnostic.
+func (in *ElementalHostDiagnostic) DeepCopy() *ElementalHostDiagnostic {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalHostDiagnostic)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElementalHostDiagnostic) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalHostDiagnosticList) DeepCopyInto(out *ElementalHostDiagnosticList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ElementalHost, len(*in))
+		*out = make([]ElementalHostDiagnostic, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostList.
-func (in *ElementalHostList) DeepCopy() *ElementalHostList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostDiagnosticList.
+func (in *ElementalHostDiagnosticList) DeepCopy() *ElementalHostDiagnosticList {
 	if in == nil {
 		return nil
 	}
-	out := new(ElementalHostList)
+	out := new(ElementalHostDiagnosticList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElementalHostList) DeepCopyObject() runtime.Object {
+func (in *ElementalHostDiagnosticList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -354,40 +509,38 @@ func (in *ElementalHostList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalHostSpec) DeepCopyInto(out *ElementalHostSpec) {
+func (in *ElementalHostDiagnosticSpec) DeepCopyInto(out *ElementalHostDiagnosticSpec) {
 	*out = *in
-	if in.BootstrapSecret != nil {
-		in, out := &in.BootstrapSecret, &out.BootstrapSecret
-		*out = new(v1.ObjectReference)
-		**out = **in
-	}
-	if in.MachineRef != nil {
-		in, out := &in.MachineRef, &out.MachineRef
-		*out = new(v1.ObjectReference)
-		**out = **in
-	}
-	if in.OSVersionManagement != nil {
-		in, out := &in.OSVersionManagement, &out.OSVersionManagement
-		*out = make(map[string]runtime.RawExtension, len(*in))
-		for key, val := range *in {
-			(*out)[key] = *val.DeepCopy()
-		}
+	out.HostRef = in.HostRef
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = make([]DiagnosticProbe, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostSpec.
-func (in *ElementalHostSpec) DeepCopy() *ElementalHostSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostDiagnosticSpec.
+func (in *ElementalHostDiagnosticSpec) DeepCopy() *ElementalHostDiagnosticSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ElementalHostSpec)
+	out := new(ElementalHostDiagnosticSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalHostStatus) DeepCopyInto(out *ElementalHostStatus) {
+func (in *ElementalHostDiagnosticStatus) DeepCopyInto(out *ElementalHostDiagnosticStatus) {
 	*out = *in
+	if in.Results != nil {
+		in, out := &in.Results, &out.Results
+		*out = make([]DiagnosticProbeResult, len(*in))
+		copy(*out, *in)
+	}
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(apiv1beta1.Conditions, len(*in))
@@ -397,37 +550,69 @@ func (in *ElementalHostStatus) DeepCopyInto(out *ElementalHostStatus) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostStatus.
-func (in *ElementalHostStatus) DeepCopy() *ElementalHostStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostDiagnosticStatus.
+func (in *ElementalHostDiagnosticStatus) DeepCopy() *ElementalHostDiagnosticStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ElementalHostStatus)
+	out := new(ElementalHostDiagnosticStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalMachine) DeepCopyInto(out *ElementalMachine) {
+func (in *ElementalHostList) DeepCopyInto(out *ElementalHostList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElementalHost, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostList.
+func (in *ElementalHostList) DeepCopy() *ElementalHostList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalHostList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElementalHostList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalHostPool) DeepCopyInto(out *ElementalHostPool) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	out.Status = in.Status
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachine.
-func (in *ElementalMachine) DeepCopy() *ElementalMachine {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostPool.
+func (in *ElementalHostPool) DeepCopy() *ElementalHostPool {
 	if in == nil {
 		return nil
 	}
-	out := new(ElementalMachine)
+	out := new(ElementalHostPool)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElementalMachine) DeepCopyObject() runtime.Object {
+func (in *ElementalHostPool) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -435,31 +620,31 @@ func (in *ElementalMachine) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalMachineList) DeepCopyInto(out *ElementalMachineList) {
+func (in *ElementalHostPoolList) DeepCopyInto(out *ElementalHostPoolList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ElementalMachine, len(*in))
+		*out = make([]ElementalHostPool, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachineList.
-func (in *ElementalMachineList) DeepCopy() *ElementalMachineList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostPoolList.
+func (in *ElementalHostPoolList) DeepCopy() *ElementalHostPoolList {
 	if in == nil {
 		return nil
 	}
-	out := new(ElementalMachineList)
+	out := new(ElementalHostPoolList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElementalMachineList) DeepCopyObject() runtime.Object {
+func (in *ElementalHostPoolList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -467,11 +652,11 @@ func (in *ElementalMachineList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalMachineSpec) DeepCopyInto(out *ElementalMachineSpec) {
+func (in *ElementalHostPoolSpec) DeepCopyInto(out *ElementalHostPoolSpec) {
 	*out = *in
-	if in.ProviderID != nil {
-		in, out := &in.ProviderID, &out.ProviderID
-		*out = new(string)
+	if in.RegistrationRef != nil {
+		in, out := &in.RegistrationRef, &out.RegistrationRef
+		*out = new(v1.LocalObjectReference)
 		**out = **in
 	}
 	if in.Selector != nil {
@@ -479,79 +664,64 @@ func (in *ElementalMachineSpec) DeepCopyInto(out *ElementalMachineSpec) {
 		*out = new(metav1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.HostRef != nil {
-		in, out := &in.HostRef, &out.HostRef
-		*out = new(v1.ObjectReference)
-		**out = **in
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.OSVersionManagement != nil {
-		in, out := &in.OSVersionManagement, &out.OSVersionManagement
-		*out = make(map[string]runtime.RawExtension, len(*in))
-		for key, val := range *in {
-			(*out)[key] = *val.DeepCopy()
-		}
+	if in.Spares != nil {
+		in, out := &in.Spares, &out.Spares
+		*out = new(SparePolicy)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachineSpec.
-func (in *ElementalMachineSpec) DeepCopy() *ElementalMachineSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostPoolSpec.
+func (in *ElementalHostPoolSpec) DeepCopy() *ElementalHostPoolSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ElementalMachineSpec)
+	out := new(ElementalHostPoolSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalMachineStatus) DeepCopyInto(out *ElementalMachineStatus) {
+func (in *ElementalHostPoolStatus) DeepCopyInto(out *ElementalHostPoolStatus) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make(apiv1beta1.Conditions, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.FailureDomains != nil {
-		in, out := &in.FailureDomains, &out.FailureDomains
-		*out = make(apiv1beta1.FailureDomains, len(*in))
-		for key, val := range *in {
-			(*out)[key] = *val.DeepCopy()
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachineStatus.
-func (in *ElementalMachineStatus) DeepCopy() *ElementalMachineStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostPoolStatus.
+func (in *ElementalHostPoolStatus) DeepCopy() *ElementalHostPoolStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ElementalMachineStatus)
+	out := new(ElementalHostPoolStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalMachineTemplate) DeepCopyInto(out *ElementalMachineTemplate) {
+func (in *ElementalHostReservation) DeepCopyInto(out *ElementalHostReservation) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachineTemplate.
-func (in *ElementalMachineTemplate) DeepCopy() *ElementalMachineTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostReservation.
+func (in *ElementalHostReservation) DeepCopy() *ElementalHostReservation {
 	if in == nil {
 		return nil
 	}
-	out := new(ElementalMachineTemplate)
+	out := new(ElementalHostReservation)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElementalMachineTemplate) DeepCopyObject() runtime.Object {
+func (in *ElementalHostReservation) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -559,31 +729,31 @@ func (in *ElementalMachineTemplate) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalMachineTemplateList) DeepCopyInto(out *ElementalMachineTemplateList) {
+func (in *ElementalHostReservationList) DeepCopyInto(out *ElementalHostReservationList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ElementalMachineTemplate, len(*in))
+		*out = make([]ElementalHostReservation, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachineTemplateList.
-func (in *ElementalMachineTemplateList) DeepCopy() *ElementalMachineTemplateList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostReservationList.
+func (in *ElementalHostReservationList) DeepCopy() *ElementalHostReservationList {
 	if in == nil {
 		return nil
 	}
-	out := new(ElementalMachineTemplateList)
+	out := new(ElementalHostReservationList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElementalMachineTemplateList) DeepCopyObject() runtime.Object {
+func (in *ElementalHostReservationList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -591,13 +761,408 @@ func (in *ElementalMachineTemplateList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalMachineTemplateSpec) DeepCopyInto(out *ElementalMachineTemplateSpec) {
+func (in *ElementalHostReservationSpec) DeepCopyInto(out *ElementalHostReservationSpec) {
 	*out = *in
-	in.Template.DeepCopyInto(&out.Template)
+	out.RegistrationRef = in.RegistrationRef
+	if in.MACAddresses != nil {
+		in, out := &in.MACAddresses, &out.MACAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HostLabels != nil {
+		in, out := &in.HostLabels, &out.HostLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.HostAnnotations != nil {
+		in, out := &in.HostAnnotations, &out.HostAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Install != nil {
+		in, out := &in.Install, &out.Install
+		*out = make(map[string]runtime.RawExtension, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.TokenDuration != nil {
+		in, out := &in.TokenDuration, &out.TokenDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachineTemplateSpec.
-func (in *ElementalMachineTemplateSpec) DeepCopy() *ElementalMachineTemplateSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostReservationSpec.
+func (in *ElementalHostReservationSpec) DeepCopy() *ElementalHostReservationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalHostReservationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalHostReservationStatus) DeepCopyInto(out *ElementalHostReservationStatus) {
+	*out = *in
+	if in.HostRef != nil {
+		in, out := &in.HostRef, &out.HostRef
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostReservationStatus.
+func (in *ElementalHostReservationStatus) DeepCopy() *ElementalHostReservationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalHostReservationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalHostSpec) DeepCopyInto(out *ElementalHostSpec) {
+	*out = *in
+	if in.BootstrapSecret != nil {
+		in, out := &in.BootstrapSecret, &out.BootstrapSecret
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.MachineRef != nil {
+		in, out := &in.MachineRef, &out.MachineRef
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.OSVersionManagement != nil {
+		in, out := &in.OSVersionManagement, &out.OSVersionManagement
+		*out = make(map[string]runtime.RawExtension, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Operation != nil {
+		in, out := &in.Operation, &out.Operation
+		*out = new(HostOperation)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostSpec.
+func (in *ElementalHostSpec) DeepCopy() *ElementalHostSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalHostSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalHostStatus) DeepCopyInto(out *ElementalHostStatus) {
+	*out = *in
+	if in.PhaseHistory != nil {
+		in, out := &in.PhaseHistory, &out.PhaseHistory
+		*out = make([]HostPhaseRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSeen != nil {
+		in, out := &in.LastSeen, &out.LastSeen
+		*out = (*in).DeepCopy()
+	}
+	if in.Operation != nil {
+		in, out := &in.Operation, &out.Operation
+		*out = new(HostOperationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SupportBundle != nil {
+		in, out := &in.SupportBundle, &out.SupportBundle
+		*out = new(SupportBundleStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AgentUpdateFailure != nil {
+		in, out := &in.AgentUpdateFailure, &out.AgentUpdateFailure
+		*out = new(AgentUpdateFailure)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TrustedCAs != nil {
+		in, out := &in.TrustedCAs, &out.TrustedCAs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.FailureReason != nil {
+		in, out := &in.FailureReason, &out.FailureReason
+		*out = new(errors.MachineStatusError)
+		**out = **in
+	}
+	if in.FailureMessage != nil {
+		in, out := &in.FailureMessage, &out.FailureMessage
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalHostStatus.
+func (in *ElementalHostStatus) DeepCopy() *ElementalHostStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalHostStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalMachine) DeepCopyInto(out *ElementalMachine) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachine.
+func (in *ElementalMachine) DeepCopy() *ElementalMachine {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalMachine)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElementalMachine) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalMachineList) DeepCopyInto(out *ElementalMachineList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElementalMachine, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachineList.
+func (in *ElementalMachineList) DeepCopy() *ElementalMachineList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalMachineList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElementalMachineList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalMachineSpec) DeepCopyInto(out *ElementalMachineSpec) {
+	*out = *in
+	if in.ProviderID != nil {
+		in, out := &in.ProviderID, &out.ProviderID
+		*out = new(string)
+		**out = **in
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HostPoolRef != nil {
+		in, out := &in.HostPoolRef, &out.HostPoolRef
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.HostRef != nil {
+		in, out := &in.HostRef, &out.HostRef
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.OSVersionManagement != nil {
+		in, out := &in.OSVersionManagement, &out.OSVersionManagement
+		*out = make(map[string]runtime.RawExtension, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.BootstrapTimeout != nil {
+		in, out := &in.BootstrapTimeout, &out.BootstrapTimeout
+		*out = new(Timeout)
+		**out = **in
+	}
+	if in.NodeSync != nil {
+		in, out := &in.NodeSync, &out.NodeSync
+		*out = new(NodeSyncPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachineSpec.
+func (in *ElementalMachineSpec) DeepCopy() *ElementalMachineSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalMachineSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalMachineStatus) DeepCopyInto(out *ElementalMachineStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FailureDomains != nil {
+		in, out := &in.FailureDomains, &out.FailureDomains
+		*out = make(apiv1beta1.FailureDomains, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.FailureReason != nil {
+		in, out := &in.FailureReason, &out.FailureReason
+		*out = new(errors.MachineStatusError)
+		**out = **in
+	}
+	if in.FailureMessage != nil {
+		in, out := &in.FailureMessage, &out.FailureMessage
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachineStatus.
+func (in *ElementalMachineStatus) DeepCopy() *ElementalMachineStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalMachineStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalMachineTemplate) DeepCopyInto(out *ElementalMachineTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachineTemplate.
+func (in *ElementalMachineTemplate) DeepCopy() *ElementalMachineTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalMachineTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElementalMachineTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalMachineTemplateList) DeepCopyInto(out *ElementalMachineTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElementalMachineTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachineTemplateList.
+func (in *ElementalMachineTemplateList) DeepCopy() *ElementalMachineTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalMachineTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElementalMachineTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalMachineTemplateSpec) DeepCopyInto(out *ElementalMachineTemplateSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachineTemplateSpec.
+func (in *ElementalMachineTemplateSpec) DeepCopy() *ElementalMachineTemplateSpec {
 	if in == nil {
 		return nil
 	}
@@ -607,7 +1172,192 @@ func (in *ElementalMachineTemplateSpec) DeepCopy() *ElementalMachineTemplateSpec
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalRegistration) DeepCopyInto(out *ElementalRegistration) {
+func (in *ElementalMachineTemplateStatus) DeepCopyInto(out *ElementalMachineTemplateStatus) {
+	*out = *in
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.NodeLabels != nil {
+		in, out := &in.NodeLabels, &out.NodeLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalMachineTemplateStatus.
+func (in *ElementalMachineTemplateStatus) DeepCopy() *ElementalMachineTemplateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalMachineTemplateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalRegistration) DeepCopyInto(out *ElementalRegistration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRegistration.
+func (in *ElementalRegistration) DeepCopy() *ElementalRegistration {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalRegistration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElementalRegistration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalRegistrationList) DeepCopyInto(out *ElementalRegistrationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElementalRegistration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRegistrationList.
+func (in *ElementalRegistrationList) DeepCopy() *ElementalRegistrationList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalRegistrationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElementalRegistrationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalRegistrationSpec) DeepCopyInto(out *ElementalRegistrationSpec) {
+	*out = *in
+	if in.HostLabels != nil {
+		in, out := &in.HostLabels, &out.HostLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.HostAnnotations != nil {
+		in, out := &in.HostAnnotations, &out.HostAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Config.DeepCopyInto(&out.Config)
+	if in.PrivateKeyRef != nil {
+		in, out := &in.PrivateKeyRef, &out.PrivateKeyRef
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.InstallTimeout != nil {
+		in, out := &in.InstallTimeout, &out.InstallTimeout
+		*out = new(Timeout)
+		**out = **in
+	}
+	if in.AutoApprove != nil {
+		in, out := &in.AutoApprove, &out.AutoApprove
+		*out = new(AutoApprovePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxHosts != nil {
+		in, out := &in.MaxHosts, &out.MaxHosts
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AbandonedHostTTL != nil {
+		in, out := &in.AbandonedHostTTL, &out.AbandonedHostTTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.AgentUpdate != nil {
+		in, out := &in.AgentUpdate, &out.AgentUpdate
+		*out = new(AgentUpdate)
+		**out = **in
+	}
+	if in.CARotation != nil {
+		in, out := &in.CARotation, &out.CARotation
+		*out = new(CARotation)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRegistrationSpec.
+func (in *ElementalRegistrationSpec) DeepCopy() *ElementalRegistrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalRegistrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalRegistrationStatus) DeepCopyInto(out *ElementalRegistrationStatus) {
+	*out = *in
+	if in.OrphanedHosts != nil {
+		in, out := &in.OrphanedHosts, &out.OrphanedHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CARotation != nil {
+		in, out := &in.CARotation, &out.CARotation
+		*out = new(CARotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRegistrationStatus.
+func (in *ElementalRegistrationStatus) DeepCopy() *ElementalRegistrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalRegistrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalRemediation) DeepCopyInto(out *ElementalRemediation) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -615,18 +1365,18 @@ func (in *ElementalRegistration) DeepCopyInto(out *ElementalRegistration) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRegistration.
-func (in *ElementalRegistration) DeepCopy() *ElementalRegistration {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRemediation.
+func (in *ElementalRemediation) DeepCopy() *ElementalRemediation {
 	if in == nil {
 		return nil
 	}
-	out := new(ElementalRegistration)
+	out := new(ElementalRemediation)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElementalRegistration) DeepCopyObject() runtime.Object {
+func (in *ElementalRemediation) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -634,31 +1384,31 @@ func (in *ElementalRegistration) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalRegistrationList) DeepCopyInto(out *ElementalRegistrationList) {
+func (in *ElementalRemediationList) DeepCopyInto(out *ElementalRemediationList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ElementalRegistration, len(*in))
+		*out = make([]ElementalRemediation, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRegistrationList.
-func (in *ElementalRegistrationList) DeepCopy() *ElementalRegistrationList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRemediationList.
+func (in *ElementalRemediationList) DeepCopy() *ElementalRemediationList {
 	if in == nil {
 		return nil
 	}
-	out := new(ElementalRegistrationList)
+	out := new(ElementalRemediationList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElementalRegistrationList) DeepCopyObject() runtime.Object {
+func (in *ElementalRemediationList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -666,58 +1416,186 @@ func (in *ElementalRegistrationList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalRegistrationSpec) DeepCopyInto(out *ElementalRegistrationSpec) {
+func (in *ElementalRemediationSpec) DeepCopyInto(out *ElementalRemediationSpec) {
 	*out = *in
-	if in.HostLabels != nil {
-		in, out := &in.HostLabels, &out.HostLabels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	in.Strategy.DeepCopyInto(&out.Strategy)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRemediationSpec.
+func (in *ElementalRemediationSpec) DeepCopy() *ElementalRemediationSpec {
+	if in == nil {
+		return nil
 	}
-	if in.HostAnnotations != nil {
-		in, out := &in.HostAnnotations, &out.HostAnnotations
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	out := new(ElementalRemediationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalRemediationStatus) DeepCopyInto(out *ElementalRemediationStatus) {
+	*out = *in
+	if in.LastRemediated != nil {
+		in, out := &in.LastRemediated, &out.LastRemediated
+		*out = (*in).DeepCopy()
 	}
-	in.Config.DeepCopyInto(&out.Config)
-	if in.PrivateKeyRef != nil {
-		in, out := &in.PrivateKeyRef, &out.PrivateKeyRef
+	if in.HostRef != nil {
+		in, out := &in.HostRef, &out.HostRef
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRegistrationSpec.
-func (in *ElementalRegistrationSpec) DeepCopy() *ElementalRegistrationSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRemediationStatus.
+func (in *ElementalRemediationStatus) DeepCopy() *ElementalRemediationStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ElementalRegistrationSpec)
+	out := new(ElementalRemediationStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElementalRegistrationStatus) DeepCopyInto(out *ElementalRegistrationStatus) {
+func (in *ElementalRemediationTemplate) DeepCopyInto(out *ElementalRemediationTemplate) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make(apiv1beta1.Conditions, len(*in))
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRemediationTemplate.
+func (in *ElementalRemediationTemplate) DeepCopy() *ElementalRemediationTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalRemediationTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElementalRemediationTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalRemediationTemplateList) DeepCopyInto(out *ElementalRemediationTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElementalRemediationTemplate, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRegistrationStatus.
-func (in *ElementalRegistrationStatus) DeepCopy() *ElementalRegistrationStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRemediationTemplateList.
+func (in *ElementalRemediationTemplateList) DeepCopy() *ElementalRemediationTemplateList {
 	if in == nil {
 		return nil
 	}
-	out := new(ElementalRegistrationStatus)
+	out := new(ElementalRemediationTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElementalRemediationTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalRemediationTemplateResource) DeepCopyInto(out *ElementalRemediationTemplateResource) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRemediationTemplateResource.
+func (in *ElementalRemediationTemplateResource) DeepCopy() *ElementalRemediationTemplateResource {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalRemediationTemplateResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElementalRemediationTemplateSpec) DeepCopyInto(out *ElementalRemediationTemplateSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElementalRemediationTemplateSpec.
+func (in *ElementalRemediationTemplateSpec) DeepCopy() *ElementalRemediationTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElementalRemediationTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostOperation) DeepCopyInto(out *HostOperation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostOperation.
+func (in *HostOperation) DeepCopy() *HostOperation {
+	if in == nil {
+		return nil
+	}
+	out := new(HostOperation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostOperationStatus) DeepCopyInto(out *HostOperationStatus) {
+	*out = *in
+	if in.AcknowledgedAt != nil {
+		in, out := &in.AcknowledgedAt, &out.AcknowledgedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostOperationStatus.
+func (in *HostOperationStatus) DeepCopy() *HostOperationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HostOperationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostPhaseRecord) DeepCopyInto(out *HostPhaseRecord) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostPhaseRecord.
+func (in *HostPhaseRecord) DeepCopy() *HostPhaseRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(HostPhaseRecord)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -754,6 +1632,38 @@ func (in *InfraMachineTemplateResource) DeepCopy() *InfraMachineTemplateResource
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeSyncPolicy) DeepCopyInto(out *NodeSyncPolicy) {
+	*out = *in
+	if in.LabelPrefixes != nil {
+		in, out := &in.LabelPrefixes, &out.LabelPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AnnotationPrefixes != nil {
+		in, out := &in.AnnotationPrefixes, &out.AnnotationPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Taints != nil {
+		in, out := &in.Taints, &out.Taints
+		*out = make([]v1.Taint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSyncPolicy.
+func (in *NodeSyncPolicy) DeepCopy() *NodeSyncPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeSyncPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostAction) DeepCopyInto(out *PostAction) {
 	*out = *in
@@ -783,3 +1693,77 @@ func (in *Registration) DeepCopy() *Registration {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemediationStrategy) DeepCopyInto(out *RemediationStrategy) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemediationStrategy.
+func (in *RemediationStrategy) DeepCopy() *RemediationStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(RemediationStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SparePolicy) DeepCopyInto(out *SparePolicy) {
+	*out = *in
+	if in.OSVersionManagement != nil {
+		in, out := &in.OSVersionManagement, &out.OSVersionManagement
+		*out = make(map[string]runtime.RawExtension, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SparePolicy.
+func (in *SparePolicy) DeepCopy() *SparePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SparePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SupportBundleStatus) DeepCopyInto(out *SupportBundleStatus) {
+	*out = *in
+	in.UploadedAt.DeepCopyInto(&out.UploadedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SupportBundleStatus.
+func (in *SupportBundleStatus) DeepCopy() *SupportBundleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SupportBundleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Timeout) DeepCopyInto(out *Timeout) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Timeout.
+func (in *Timeout) DeepCopy() *Timeout {
+	if in == nil {
+		return nil
+	}
+	out := new(Timeout)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -1,9 +1,18 @@
 package agent
 
 import (
+	"errors"
+
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/phase"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/sysinfo"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/tls"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/cert"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin"
+	"github.com/twpayne/go-vfs/v4"
 )
 
 // handlePost handles post conditions such as Reboot or PowerOff.
@@ -24,3 +33,83 @@ func handlePost(osPlugin osplugin.Plugin, post infrastructurev1.PostAction) bool
 	}
 	return false
 }
+
+// triggerResetOnAbort triggers the host reset if the installation was aborted.
+// A host that was never installed is still running from the live media, with no installed system to reset:
+// the remote ElementalHost is directly reset and deleted, then the host reboots from the live media to register again.
+func triggerResetOnAbort(agentContext context.AgentContext, err error) {
+	if !errors.Is(err, phase.ErrInstallationAborted) {
+		log.Fatal(err, "Could not install host")
+	}
+	resetHandler := phase.NewResetHandler(agentContext)
+	agentState, err := agentContext.State.Load()
+	if err != nil {
+		log.Error(err, "Could not load agent state")
+	}
+	if err == nil && !agentState.Installation.Installed {
+		log.Info("Host was never installed, resetting ElementalHost")
+		resetHandler.ResetUninstalled()
+		log.Info("Rebooting system")
+		if err := agentContext.Plugin.Reboot(); err != nil {
+			log.Fatal(err, "Could not reboot system")
+		}
+		return
+	}
+	log.Info("Triggering reset")
+	if err := resetHandler.TriggerReset(); err != nil {
+		log.Fatal(err, "Could not trigger reset")
+	}
+	log.Info("Reset was triggered successfully. Exiting program.")
+}
+
+// setHostInfo adds the SMBIOS system UUID and product name, the IP addresses, the capacity, and the trusted CAs of this host to the patch request.
+// The system UUID and the addresses are used to find the downstream cluster Node, when its name does not match the host name.
+// The trusted CAs are used to follow the progress of a CA rotation.
+func setHostInfo(agentContext context.AgentContext, patchRequest *api.HostPatchRequest) {
+	if !agentContext.Config.Agent.NoSMBIOS {
+		systemUUID, err := sysinfo.SystemUUID(vfs.OSFS)
+		switch {
+		case errors.Is(err, sysinfo.ErrNoSystemUUID):
+			log.Debug("System UUID not available")
+		case err != nil:
+			log.Error(err, "Could not read system UUID")
+		default:
+			patchRequest.SystemUUID = &systemUUID
+		}
+		productName, err := sysinfo.ProductName(vfs.OSFS)
+		switch {
+		case errors.Is(err, sysinfo.ErrNoProductName):
+			log.Debug("Product name not available")
+		case err != nil:
+			log.Error(err, "Could not read product name")
+		default:
+			patchRequest.SystemModel = &productName
+		}
+	}
+	addresses, err := sysinfo.Addresses()
+	if err != nil {
+		log.Error(err, "Could not list IP addresses")
+	} else {
+		patchRequest.Addresses = addresses
+	}
+	capacity, err := sysinfo.Capacity(vfs.OSFS)
+	if err != nil {
+		log.Error(err, "Could not determine capacity")
+	} else {
+		patchRequest.Capacity = capacity
+	}
+	if len(agentContext.Config.Registration.CACert) == 0 {
+		return
+	}
+	caCert, err := tls.GetCACert(vfs.OSFS, agentContext.Config.Registration.CACert)
+	if err != nil {
+		log.Error(err, "Could not read CA certificate")
+		return
+	}
+	fingerprints, err := cert.Fingerprints(caCert)
+	if err != nil {
+		log.Error(err, "Could not fingerprint CA certificate")
+		return
+	}
+	patchRequest.TrustedCAs = fingerprints
+}
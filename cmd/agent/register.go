@@ -36,7 +36,10 @@ var registerCmd = &cobra.Command{
 		if installFlag {
 			log.Info("Installing host")
 			installationHandler := phase.NewInstallHandler(*agentContext)
-			installationHandler.Install()
+			if err := installationHandler.Install(); err != nil {
+				triggerResetOnAbort(*agentContext, err)
+				return
+			}
 			if handlePost(agentContext.Plugin, agentContext.Config.Agent.PostInstall) {
 				// Program should exit
 				return
@@ -8,6 +8,7 @@ import (
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/config"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/utils"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/identity"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/version"
@@ -91,6 +92,7 @@ func InitAgent() (*context.AgentContext, error) {
 		Config:     conf,
 		ConfigPath: cfgFile,
 		Hostname:   hostname,
+		State:      state.NewManager(fs, conf.Agent.WorkDir),
 	}, nil
 
 }
@@ -1,15 +1,24 @@
 package agent
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/backoff"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/config"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/phase"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/update"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
 	"github.com/spf13/cobra"
+	"github.com/twpayne/go-vfs/v4"
 )
 
+// configSyncInterval is the minimum interval between agent config drift checks.
+const configSyncInterval = 1 * time.Minute
+
 // runCmd represents the run command.
 var runCmd = &cobra.Command{
 	Use:   "run",
@@ -24,21 +33,42 @@ This command will reconcile the remote ElementalHost resource describing this ho
 		if err != nil {
 			log.Fatal(err, "Could not initialize agent")
 		}
+		// Start the updated elemental-agent, if any.
+		// Update failures are reported on the next ElementalHost patch.
+		updater := update.NewUpdater(vfs.OSFS, agentContext.Config, agentContext.State)
+		err = updater.Resume()
+		if err != nil {
+			log.Error(err, "resuming elemental-agent update")
+		}
+		updateFailure := agentUpdateFailure(err)
 		// Normal reconcile
 		log.Info("Entering reconciliation loop")
 		runningPhase := infrastructurev1.PhaseRunning
+		retry := backoff.NewBackoff(agentContext.Config.Agent)
+		configSyncHandler := phase.NewConfigSyncHandler(agentContext)
+		var lastConfigSync time.Time
 		for {
 			// Patch the host and receive the patched remote host back
 			log.Debug("Patching host")
-			host, err := agentContext.Client.PatchHost(api.HostPatchRequest{
-				Phase: &runningPhase,
-			}, agentContext.Hostname)
+			runningPatch := api.HostPatchRequest{
+				Phase:              &runningPhase,
+				AgentUpdateFailure: updateFailure,
+			}
+			setHostInfo(*agentContext, &runningPatch)
+			if configHash, err := config.Hash(agentContext.Config); err != nil {
+				log.Error(err, "Could not hash agent config")
+			} else {
+				runningPatch.AgentConfigHash = &configHash
+			}
+			host, err := agentContext.Client.PatchHost(runningPatch, agentContext.Hostname)
 			if err != nil {
 				log.Error(err, "Could not patch ElementalHost during normal reconcile")
-				log.Debugf("Waiting %s...", agentContext.Config.Agent.Reconciliation.String())
-				time.Sleep(agentContext.Config.Agent.Reconciliation)
+				retry.Wait(err)
 				continue
 			}
+			updateFailure = nil
+			// The updated elemental-agent, if any, can reach the Elemental API
+			updater.Confirm()
 
 			// Handle Reset trigger
 			//
@@ -50,8 +80,7 @@ This command will reconcile the remote ElementalHost resource describing this ho
 				resetHandler := phase.NewResetHandler(*agentContext)
 				if err := resetHandler.TriggerReset(); err != nil {
 					log.Error(err, "handling reset trigger")
-					log.Debugf("Waiting %s...", agentContext.Config.Agent.Reconciliation.String())
-					time.Sleep(agentContext.Config.Agent.Reconciliation)
+					retry.Wait(err)
 					continue
 				}
 				// If Reset was triggered successfully, exit the program.
@@ -59,6 +88,69 @@ This command will reconcile the remote ElementalHost resource describing this ho
 				return
 			}
 
+			// Handle config drift
+			//
+			// Failures are not retried immediately, the config is synced again on the next interval.
+			if time.Since(lastConfigSync) >= configSyncInterval {
+				lastConfigSync = time.Now()
+				updated, err := configSyncHandler.Sync()
+				if err != nil {
+					log.Error(err, "syncing agent config")
+				}
+				if updated {
+					// Apply the updated backoff config
+					retry = backoff.NewBackoff(agentContext.Config.Agent)
+				}
+			}
+
+			// Handle elemental-agent update
+			//
+			// On success the updated elemental-agent replaces this process, and continues the reconciliation.
+			if err := updater.Reconcile(host.AgentUpdate); err != nil {
+				log.Error(err, "updating elemental-agent")
+				updateFailure = agentUpdateFailure(err)
+			}
+
+			// Handle diagnostics
+			//
+			// Diagnostic probes are read-only, they are executed before any operation that could reboot the host.
+			// Failures are not retried immediately, pending diagnostics are returned again on the next reconcile.
+			for _, diagnostic := range host.Diagnostics {
+				log.Infof("Running diagnostic '%s'", diagnostic.Name)
+				diagnosticHandler := phase.NewDiagnosticHandler(*agentContext)
+				if err := diagnosticHandler.Run(diagnostic); err != nil {
+					log.Error(err, "running diagnostic")
+				}
+			}
+
+			// Handle one-shot operations
+			//
+			// The operation is acknowledged before executing it, so that it is only executed once.
+			if host.Operation != nil {
+				log.Infof("Executing '%s' operation '%s'", host.Operation.Type, host.Operation.ID)
+				if _, err := agentContext.Client.PatchHost(api.HostPatchRequest{
+					OperationID: &host.Operation.ID,
+				}, agentContext.Hostname); err != nil {
+					log.Error(err, "acknowledging operation")
+					retry.Wait(err)
+					continue
+				}
+				if host.Operation.Type == infrastructurev1.HostOperationSupportBundle {
+					supportBundleHandler := phase.NewSupportBundleHandler(*agentContext)
+					if err := supportBundleHandler.Upload(fmt.Sprintf("Requested by operation '%s'", host.Operation.ID)); err != nil {
+						log.Error(err, "uploading support bundle")
+					}
+				}
+				post := infrastructurev1.PostAction{
+					Reboot:   host.Operation.Type == infrastructurev1.HostOperationReboot,
+					PowerOff: host.Operation.Type == infrastructurev1.HostOperationPowerOff,
+				}
+				if handlePost(agentContext.Plugin, post) {
+					// Exit the program if we are rebooting or powering off
+					return
+				}
+			}
+
 			// Handle Upgrade
 			needsInplaceUpdate := host.InPlaceUpgrade == infrastructurev1.InPlaceUpdatePending
 			if !host.Bootstrapped || needsInplaceUpdate {
@@ -67,8 +159,7 @@ This command will reconcile the remote ElementalHost resource describing this ho
 				post, err := osVersionHandler.Reconcile(host.OSVersionManagement, needsInplaceUpdate)
 				if err != nil {
 					log.Error(err, "handling OS reconciliation")
-					log.Debugf("Waiting %s...", agentContext.Config.Agent.Reconciliation.String())
-					time.Sleep(agentContext.Config.Agent.Reconciliation)
+					retry.Wait(err)
 					continue
 				}
 				if handlePost(agentContext.Plugin, post) {
@@ -84,8 +175,7 @@ This command will reconcile the remote ElementalHost resource describing this ho
 				post, err := bootstrapHandler.Bootstrap()
 				if err != nil {
 					log.Error(err, "handling bootstrap")
-					log.Debugf("Waiting %s...", agentContext.Config.Agent.Reconciliation.String())
-					time.Sleep(agentContext.Config.Agent.Reconciliation)
+					retry.Wait(err)
 					continue
 				}
 				if handlePost(agentContext.Plugin, post) {
@@ -94,6 +184,8 @@ This command will reconcile the remote ElementalHost resource describing this ho
 				}
 			}
 
+			// Reset the backoff on successful reconciliation
+			retry.Reset()
 			log.Debugf("Waiting %s...", agentContext.Config.Agent.Reconciliation.String())
 			time.Sleep(agentContext.Config.Agent.Reconciliation)
 		}
@@ -103,3 +195,19 @@ This command will reconcile the remote ElementalHost resource describing this ho
 func init() {
 	rootCmd.AddCommand(runCmd)
 }
+
+// agentUpdateFailure returns the elemental-agent update failure to be reported, if any.
+// Other errors, for example download errors, are attempted again and not reported.
+func agentUpdateFailure(err error) *infrastructurev1.AgentUpdateFailure {
+	if err == nil {
+		return nil
+	}
+	var updateErr *update.Error
+	if !errors.As(err, &updateErr) {
+		return nil
+	}
+	return &infrastructurev1.AgentUpdateFailure{
+		Version: updateErr.Version,
+		Message: updateErr.Err.Error(),
+	}
+}
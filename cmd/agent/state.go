@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
+	"github.com/spf13/cobra"
+	"github.com/twpayne/go-vfs/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// stateCmd represents the state command.
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Prints the local state of the elemental-agent",
+	Long:  "Prints the local state of the elemental-agent, as persisted in the agent work directory",
+	Run: func(cmd *cobra.Command, _ []string) {
+		conf := initConfig()
+		agentState, err := state.NewManager(vfs.OSFS, conf.Agent.WorkDir).Load()
+		if err != nil {
+			log.Fatal(err, "Could not load agent state")
+		}
+		bytes, err := yaml.Marshal(agentState)
+		if err != nil {
+			log.Fatal(err, "Could not marshal agent state")
+		}
+		if _, err := cmd.OutOrStdout().Write(bytes); err != nil {
+			log.Fatal(err, "Could not print agent state")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(stateCmd)
+}
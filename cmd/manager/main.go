@@ -22,12 +22,14 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	"go.uber.org/zap/zapcore"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -61,6 +63,9 @@ const (
 	envAPITLSCA          = "ELEMENTAL_API_TLS_CA"
 	envAPITLSPrivateKey  = "ELEMENTAL_API_TLS_PRIVATE_KEY"
 	envAPITLSCertificate = "ELEMENTAL_API_TLS_CERTIFICATE"
+	envHeartbeatGrace    = "ELEMENTAL_HEARTBEAT_GRACE_PERIOD"
+	envPodName           = "POD_NAME"
+	envPodNamespace      = "POD_NAMESPACE"
 )
 
 // Errors.
@@ -158,16 +163,8 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&controller.ElementalHostReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ElementalHost")
-		os.Exit(1)
-	}
-
 	// Setup a ClusterCacheTracker to access the downstream clusters.
-	// This is going to be used to set the Node.spec.ProviderID.
+	// This is going to be used to set the Node.spec.ProviderID and to drain Nodes.
 	var tracker *remote.ClusterCacheTracker
 	if tracker, err = remote.NewClusterCacheTracker(
 		mgr,
@@ -188,10 +185,30 @@ func main() {
 	}
 	remoteTracker := utils.NewRemoteTracker(tracker)
 
+	heartbeatGracePeriod := controller.DefaultHeartbeatGracePeriod
+	if value := os.Getenv(envHeartbeatGrace); len(value) > 0 {
+		heartbeatGracePeriod, err = time.ParseDuration(value)
+		if err != nil {
+			setupLog.Error(err, "parsing heartbeat grace period", "value", value)
+			os.Exit(1)
+		}
+	}
+	if err = (&controller.ElementalHostReconciler{
+		Client:               mgr.GetClient(),
+		Scheme:               mgr.GetScheme(),
+		HeartbeatGracePeriod: heartbeatGracePeriod,
+		Tracker:              remoteTracker,
+		Recorder:             mgr.GetEventRecorderFor("elementalhost-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ElementalHost")
+		os.Exit(1)
+	}
+
 	if err = (&controller.ElementalMachineReconciler{
 		Client:        mgr.GetClient(),
 		Scheme:        mgr.GetScheme(),
 		Tracker:       remoteTracker,
+		Recorder:      mgr.GetEventRecorderFor("elementalmachine-controller"),
 		RequeuePeriod: controller.DefaultRequeuePeriod,
 	}).SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ElementalMachine")
@@ -205,6 +222,27 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "ElementalMachineTemplate")
 		os.Exit(1)
 	}
+	if err = (&controller.ElementalHostPoolReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ElementalHostPool")
+		os.Exit(1)
+	}
+	if err = (&controller.ElementalHostReservationReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ElementalHostReservation")
+		os.Exit(1)
+	}
+	if err = (&controller.ElementalHostDiagnosticReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ElementalHostDiagnostic")
+		os.Exit(1)
+	}
 	if err = (&controller.ElementalClusterReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
@@ -241,10 +279,18 @@ func main() {
 		Scheme:        mgr.GetScheme(),
 		APIUrl:        elementalAPIURL,
 		DefaultCACert: defaultCACert,
-	}).SetupWithManager(mgr); err != nil {
+		Recorder:      mgr.GetEventRecorderFor("elementalregistration-controller"),
+	}).SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ElementalRegistration")
 		os.Exit(1)
 	}
+	if err = (&controller.ElementalRemediationReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ElementalRemediation")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -257,10 +303,17 @@ func main() {
 	}
 
 	// Start Elemental API
-	privateKey := os.Getenv(envAPITLSPrivateKey)
-	certificate := os.Getenv(envAPITLSCertificate)
-	useTLS := os.Getenv(envAPITLSEnable) == "true"
-	elementalAPIServer := api.NewServer(ctx, mgr.GetClient(), defaultAPIPort, useTLS, privateKey, certificate)
+	apiRecorder := mgr.GetEventRecorderFor("elemental-api")
+	var certificateLoader *api.CertificateLoader
+	if os.Getenv(envAPITLSEnable) == "true" {
+		certificateLoader, err = api.NewCertificateLoader(ctrl.Log.WithName("elemental-api"), apiRecorder, managerPodReference(),
+			os.Getenv(envAPITLSCertificate), os.Getenv(envAPITLSPrivateKey))
+		if err != nil {
+			setupLog.Error(err, "loading Elemental API TLS certificate")
+			os.Exit(1)
+		}
+	}
+	elementalAPIServer := api.NewServer(ctx, mgr.GetClient(), apiRecorder, defaultAPIPort, heartbeatGracePeriod, certificateLoader)
 	go func() {
 		if err := elementalAPIServer.Start(ctx); err != nil {
 			setupLog.Error(err, "running Elemental API server")
@@ -274,3 +327,18 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// managerPodReference returns a reference to the manager Pod, if its name and namespace are exposed through the environment.
+func managerPodReference() *corev1.ObjectReference {
+	name := os.Getenv(envPodName)
+	namespace := os.Getenv(envPodNamespace)
+	if len(name) == 0 || len(namespace) == 0 {
+		return nil
+	}
+	return &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Name:       name,
+		Namespace:  namespace,
+	}
+}
@@ -0,0 +1,112 @@
+package backoff
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"k8s.io/utils/clock"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
+)
+
+// Defaults.
+const (
+	defaultMax    = 5 * time.Minute
+	defaultFactor = 2
+	defaultJitter = 20
+)
+
+// RetryAfter can be implemented by errors carrying a server-provided delay (ex. the 'Retry-After' HTTP header).
+type RetryAfter interface {
+	RetryAfter() time.Duration
+}
+
+// Backoff computes the delay between retries of a failing operation.
+type Backoff interface {
+	// Next returns the delay before the next retry, and increases the following one.
+	Next() time.Duration
+	// Wait sleeps before the next retry.
+	// If the error carries a server-provided delay, the longest of the two delays is used.
+	Wait(err error)
+	// Reset must be called on success, so that the next failure starts from the initial delay.
+	Reset()
+}
+
+var _ Backoff = (*backoff)(nil)
+
+type backoff struct {
+	initial time.Duration
+	max     time.Duration
+	factor  int
+	jitter  int
+	current time.Duration
+	clock   clock.Clock
+	random  func() float64
+}
+
+// NewBackoff returns a Backoff using the agent config.
+// The agent reconciliation interval is used as initial delay, unless configured otherwise.
+func NewBackoff(conf infrastructurev1.Agent) Backoff {
+	return NewBackoffWithClock(conf, clock.RealClock{}, rand.Float64)
+}
+
+// NewBackoffWithClock returns a Backoff using the given clock and random generator.
+// The random function must return a number in the [0.0,1.0) interval.
+func NewBackoffWithClock(conf infrastructurev1.Agent, clock clock.Clock, random func() float64) Backoff {
+	b := &backoff{
+		initial: conf.Backoff.Initial,
+		max:     conf.Backoff.Max,
+		factor:  conf.Backoff.Factor,
+		jitter:  defaultJitter,
+		clock:   clock,
+		random:  random,
+	}
+	if b.initial <= 0 {
+		b.initial = conf.Reconciliation
+	}
+	if b.max <= 0 {
+		b.max = defaultMax
+	}
+	if b.max < b.initial {
+		b.max = b.initial
+	}
+	if b.factor < 1 {
+		b.factor = defaultFactor
+	}
+	// A zero jitter is valid and disables it, only an unset or invalid one is defaulted
+	if jitter := conf.Backoff.Jitter; jitter != nil && *jitter >= 0 && *jitter <= 100 {
+		b.jitter = *jitter
+	}
+	b.Reset()
+	return b
+}
+
+func (b *backoff) Next() time.Duration {
+	delay := b.current
+	// Increase the delay for the next attempt, preventing overflows
+	if b.current > b.max/time.Duration(b.factor) {
+		b.current = b.max
+	} else {
+		b.current *= time.Duration(b.factor)
+	}
+	// Subtract a random jitter
+	jitter := time.Duration(float64(delay) * float64(b.jitter) / 100 * b.random())
+	return delay - jitter
+}
+
+func (b *backoff) Wait(err error) {
+	delay := b.Next()
+	var retryAfter RetryAfter
+	if errors.As(err, &retryAfter) && retryAfter.RetryAfter() > delay {
+		log.Debugf("Server requested to retry after '%s'", retryAfter.RetryAfter())
+		delay = retryAfter.RetryAfter()
+	}
+	log.Debugf("Waiting '%s' before retrying", delay)
+	b.clock.Sleep(delay)
+}
+
+func (b *backoff) Reset() {
+	b.current = b.initial
+}
@@ -0,0 +1,111 @@
+package backoff
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+)
+
+func TestBackoff(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Agent Backoff Suite")
+}
+
+type retryAfterError struct {
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return fmt.Sprintf("retry after %s", e.delay)
+}
+
+func (e *retryAfterError) RetryAfter() time.Duration {
+	return e.delay
+}
+
+var _ = Describe("backoff", Label("cli", "backoff"), func() {
+	var clock *clocktesting.FakeClock
+	var random float64
+	conf := infrastructurev1.Agent{
+		Reconciliation: 10 * time.Second,
+		Backoff: infrastructurev1.Backoff{
+			Initial: time.Second,
+			Max:     10 * time.Second,
+			Factor:  2,
+			Jitter:  ptr.To(50),
+		},
+	}
+	randomFunc := func() float64 { return random }
+
+	BeforeEach(func() {
+		clock = clocktesting.NewFakeClock(time.Now())
+		random = 0
+	})
+	It("should increase delay exponentially up to max", func() {
+		backoff := NewBackoffWithClock(conf, clock, randomFunc)
+		Expect(backoff.Next()).To(Equal(time.Second))
+		Expect(backoff.Next()).To(Equal(2 * time.Second))
+		Expect(backoff.Next()).To(Equal(4 * time.Second))
+		Expect(backoff.Next()).To(Equal(8 * time.Second))
+		Expect(backoff.Next()).To(Equal(10 * time.Second))
+		Expect(backoff.Next()).To(Equal(10 * time.Second))
+	})
+	It("should subtract jitter", func() {
+		backoff := NewBackoffWithClock(conf, clock, randomFunc)
+		random = 0.5
+		// 50% jitter * 0.5 random = 25% of the delay is subtracted
+		Expect(backoff.Next()).To(Equal(750 * time.Millisecond))
+		random = 0.99
+		Expect(backoff.Next()).To(Equal(2*time.Second - 990*time.Millisecond))
+	})
+	It("should reset to initial delay", func() {
+		backoff := NewBackoffWithClock(conf, clock, randomFunc)
+		backoff.Next()
+		backoff.Next()
+		backoff.Reset()
+		Expect(backoff.Next()).To(Equal(time.Second))
+	})
+	It("should wait for the next delay", func() {
+		backoff := NewBackoffWithClock(conf, clock, randomFunc)
+		start := clock.Now()
+		backoff.Wait(errors.New("test error"))
+		Expect(clock.Since(start)).To(Equal(time.Second))
+		backoff.Wait(errors.New("test error"))
+		Expect(clock.Since(start)).To(Equal(3 * time.Second))
+	})
+	It("should honour longer server requested delays", func() {
+		backoff := NewBackoffWithClock(conf, clock, randomFunc)
+		start := clock.Now()
+		backoff.Wait(fmt.Errorf("wrapped: %w", &retryAfterError{delay: time.Minute}))
+		Expect(clock.Since(start)).To(Equal(time.Minute))
+		// Shorter server delays are ignored
+		start = clock.Now()
+		backoff.Wait(&retryAfterError{delay: time.Millisecond})
+		Expect(clock.Since(start)).To(Equal(2 * time.Second))
+	})
+	It("should use defaults on zero values", func() {
+		backoff := NewBackoffWithClock(infrastructurev1.Agent{Reconciliation: time.Minute}, clock, randomFunc)
+		Expect(backoff.Next()).To(Equal(time.Minute))
+		Expect(backoff.Next()).To(Equal(2 * time.Minute))
+		Expect(backoff.Next()).To(Equal(4 * time.Minute))
+		Expect(backoff.Next()).To(Equal(5 * time.Minute))
+		// Default 20% jitter
+		random = 1
+		Expect(backoff.Next()).To(Equal(4 * time.Minute))
+	})
+	It("should disable zero jitter", func() {
+		noJitterConf := conf
+		noJitterConf.Backoff.Jitter = ptr.To(0)
+		backoff := NewBackoffWithClock(noJitterConf, clock, randomFunc)
+		random = 0.99
+		Expect(backoff.Next()).To(Equal(time.Second))
+	})
+})
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,13 +27,34 @@ var (
 	ErrInvalidScheme  = errors.New("invalid scheme, use 'https' instead")
 )
 
+// RetryAfterError is returned when the Elemental API responds with an unexpected code and a 'Retry-After' header.
+type RetryAfterError struct {
+	Delay time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%s, retry after '%s'", ErrUnexpectedCode.Error(), e.Delay)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return ErrUnexpectedCode
+}
+
+// RetryAfter returns the server requested delay.
+func (e *RetryAfterError) RetryAfter() time.Duration {
+	return e.Delay
+}
+
 type Client interface {
 	Init(vfs.FS, identity.Identity, config.Config) error
 	GetRegistration() (*api.RegistrationResponse, error)
+	GetHostRegistration(hostname string) (*api.RegistrationResponse, error)
 	CreateHost(newHost api.HostCreateRequest) error
 	DeleteHost(hostname string) error
 	PatchHost(patch api.HostPatchRequest, hostname string) (*api.HostResponse, error)
 	GetBootstrap(hostname string) (*api.BootstrapResponse, error)
+	UploadSupportBundle(bundle api.SupportBundleRequest, hostname string) error
+	PostDiagnosticResults(results api.DiagnosticResultsRequest, diagnosticName string, hostname string) error
 }
 
 var _ Client = (*client)(nil)
@@ -104,7 +126,7 @@ func (c *client) GetRegistration() (*api.RegistrationResponse, error) {
 	}
 	defer response.Body.Close()
 	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("getting registration returned code '%d': %w", response.StatusCode, ErrUnexpectedCode)
+		return nil, fmt.Errorf("getting registration returned code '%d': %w", response.StatusCode, unexpectedCodeError(response))
 	}
 
 	responseBody, err := io.ReadAll(response.Body)
@@ -120,6 +142,35 @@ func (c *client) GetRegistration() (*api.RegistrationResponse, error) {
 	return &registration, nil
 }
 
+func (c *client) GetHostRegistration(hostname string) (*api.RegistrationResponse, error) {
+	log.Debugf("Getting registration for host: %s", hostname)
+	url := fmt.Sprintf("%s/hosts/%s/registration", c.registrationURI, hostname)
+	request, err := c.newAuthenticatedRequest(hostname, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("preparing GET host registration request: %w", err)
+	}
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("getting host registration: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("getting host registration returned code '%d': %w", response.StatusCode, unexpectedCodeError(response))
+	}
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading host registration response body: %w", err)
+	}
+
+	registration := api.RegistrationResponse{}
+	if err := json.Unmarshal(responseBody, &registration); err != nil {
+		return nil, fmt.Errorf("unmarshalling host registration response: %w", err)
+	}
+
+	return &registration, nil
+}
+
 func (c *client) CreateHost(newHost api.HostCreateRequest) error {
 	log.Debugf("Creating new host: %s", newHost.Name)
 	requestBody, err := json.Marshal(newHost)
@@ -140,7 +191,7 @@ func (c *client) CreateHost(newHost api.HostCreateRequest) error {
 	}
 	defer response.Body.Close()
 	if response.StatusCode != http.StatusCreated {
-		return fmt.Errorf("creating new host returned code '%d': %w", response.StatusCode, ErrUnexpectedCode)
+		return fmt.Errorf("creating new host returned code '%d': %w", response.StatusCode, unexpectedCodeError(response))
 	}
 
 	return nil
@@ -161,7 +212,7 @@ func (c *client) DeleteHost(hostname string) error {
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusAccepted {
-		return fmt.Errorf("deleting host returned code '%d': %w", response.StatusCode, ErrUnexpectedCode)
+		return fmt.Errorf("deleting host returned code '%d': %w", response.StatusCode, unexpectedCodeError(response))
 	}
 	return nil
 }
@@ -186,7 +237,7 @@ func (c *client) PatchHost(patch api.HostPatchRequest, hostname string) (*api.Ho
 	}
 	defer response.Body.Close()
 	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("patching host returned code '%d': %w", response.StatusCode, ErrUnexpectedCode)
+		return nil, fmt.Errorf("patching host returned code '%d': %w", response.StatusCode, unexpectedCodeError(response))
 	}
 
 	responseBody, err := io.ReadAll(response.Body)
@@ -215,7 +266,7 @@ func (c *client) GetBootstrap(hostname string) (*api.BootstrapResponse, error) {
 	}
 	defer response.Body.Close()
 	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("getting bootstrap returned code '%d': %w", response.StatusCode, ErrUnexpectedCode)
+		return nil, fmt.Errorf("getting bootstrap returned code '%d': %w", response.StatusCode, unexpectedCodeError(response))
 	}
 
 	responseBody, err := io.ReadAll(response.Body)
@@ -231,6 +282,56 @@ func (c *client) GetBootstrap(hostname string) (*api.BootstrapResponse, error) {
 	return &bootstrap, nil
 }
 
+func (c *client) UploadSupportBundle(bundle api.SupportBundleRequest, hostname string) error {
+	log.Debugf("Uploading support bundle for host: %s", hostname)
+	requestBody, err := json.Marshal(bundle)
+	if err != nil {
+		return fmt.Errorf("marshalling support bundle request body: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/hosts/%s/support-bundle", c.registrationURI, hostname)
+	request, err := c.newAuthenticatedRequest(hostname, http.MethodPost, url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return fmt.Errorf("preparing POST support bundle request: %w", err)
+	}
+	request.Header.Add("Content-Type", "application/json")
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("uploading support bundle: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusCreated {
+		return fmt.Errorf("uploading support bundle returned code '%d': %w", response.StatusCode, unexpectedCodeError(response))
+	}
+	return nil
+}
+
+func (c *client) PostDiagnosticResults(results api.DiagnosticResultsRequest, diagnosticName string, hostname string) error {
+	log.Debugf("Posting results of diagnostic '%s' for host: %s", diagnosticName, hostname)
+	requestBody, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("marshalling diagnostic results request body: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/hosts/%s/diagnostics/%s", c.registrationURI, hostname, diagnosticName)
+	request, err := c.newAuthenticatedRequest(hostname, http.MethodPost, url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return fmt.Errorf("preparing POST diagnostic results request: %w", err)
+	}
+	request.Header.Add("Content-Type", "application/json")
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("posting diagnostic results: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusCreated {
+		return fmt.Errorf("posting diagnostic results returned code '%d': %w", response.StatusCode, unexpectedCodeError(response))
+	}
+	return nil
+}
+
 func (c *client) newRequest(method string, url string, body io.Reader) (*http.Request, error) {
 	request, err := http.NewRequest(method, url, body)
 	if err != nil {
@@ -284,3 +385,21 @@ func (c *client) newToken(hostname string) (string, error) {
 	}
 	return token, nil
 }
+
+// unexpectedCodeError returns a RetryAfterError if the response contains a valid 'Retry-After' header,
+// or ErrUnexpectedCode otherwise.
+func unexpectedCodeError(response *http.Response) error {
+	retryAfter := response.Header.Get("Retry-After")
+	if retryAfter == "" {
+		return ErrUnexpectedCode
+	}
+	// The header value can either be a number of seconds, or a HTTP date.
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return &RetryAfterError{Delay: time.Duration(seconds) * time.Second}
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		return &RetryAfterError{Delay: time.Until(date)}
+	}
+	log.Debugf("Ignoring malformed Retry-After header: %s", retryAfter)
+	return ErrUnexpectedCode
+}
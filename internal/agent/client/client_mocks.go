@@ -101,6 +101,21 @@ func (mr *MockClientMockRecorder) GetBootstrap(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBootstrap", reflect.TypeOf((*MockClient)(nil).GetBootstrap), arg0)
 }
 
+// GetHostRegistration mocks base method.
+func (m *MockClient) GetHostRegistration(arg0 string) (*api.RegistrationResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHostRegistration", arg0)
+	ret0, _ := ret[0].(*api.RegistrationResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHostRegistration indicates an expected call of GetHostRegistration.
+func (mr *MockClientMockRecorder) GetHostRegistration(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHostRegistration", reflect.TypeOf((*MockClient)(nil).GetHostRegistration), arg0)
+}
+
 // GetRegistration mocks base method.
 func (m *MockClient) GetRegistration() (*api.RegistrationResponse, error) {
 	m.ctrl.T.Helper()
@@ -144,3 +159,31 @@ func (mr *MockClientMockRecorder) PatchHost(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchHost", reflect.TypeOf((*MockClient)(nil).PatchHost), arg0, arg1)
 }
+
+// PostDiagnosticResults mocks base method.
+func (m *MockClient) PostDiagnosticResults(arg0 api.DiagnosticResultsRequest, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostDiagnosticResults", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PostDiagnosticResults indicates an expected call of PostDiagnosticResults.
+func (mr *MockClientMockRecorder) PostDiagnosticResults(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostDiagnosticResults", reflect.TypeOf((*MockClient)(nil).PostDiagnosticResults), arg0, arg1, arg2)
+}
+
+// UploadSupportBundle mocks base method.
+func (m *MockClient) UploadSupportBundle(arg0 api.SupportBundleRequest, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadSupportBundle", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UploadSupportBundle indicates an expected call of UploadSupportBundle.
+func (mr *MockClientMockRecorder) UploadSupportBundle(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadSupportBundle", reflect.TypeOf((*MockClient)(nil).UploadSupportBundle), arg0, arg1)
+}
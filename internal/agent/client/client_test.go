@@ -1,7 +1,14 @@
 package client
 
 import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -10,6 +17,8 @@ import (
 
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/config"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/cert"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/cert/certtest"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/identity"
 )
 
@@ -79,3 +88,130 @@ wcHkvD3kEU33TR9VnkHUwgC9jDyDa62sef84S5MUAiAJfWf5G5PqtN+AE4XJgg2K
 		Expect(client.Init(fs, identity, unknownProtocolConf)).Should(MatchError(ErrInvalidScheme))
 	})
 })
+
+var _ = Describe("Elemental API Client Retry-After", Label("agent", "client"), func() {
+	var client Client
+	var fs vfs.FS
+	var err error
+	var fsCleanup func()
+	var server *httptest.Server
+	var retryAfter string
+
+	BeforeEach(func() {
+		client = NewClient("v0.0.0-test")
+		fs, fsCleanup, err = vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		DeferCleanup(server.Close)
+		Expect(client.Init(fs, nil, config.Config{
+			Registration: v1beta1.Registration{URI: server.URL},
+			Agent:        v1beta1.Agent{InsecureAllowHTTP: true},
+		})).Should(Succeed())
+	})
+	It("should return the requested delay in seconds", func() {
+		retryAfter = "30"
+		_, err := client.GetRegistration()
+		Expect(err).Should(MatchError(ErrUnexpectedCode))
+		retryAfterErr := &RetryAfterError{}
+		Expect(errors.As(err, &retryAfterErr)).To(BeTrue())
+		Expect(retryAfterErr.RetryAfter()).To(Equal(30 * time.Second))
+	})
+	It("should return the requested delay as HTTP date", func() {
+		retryAfter = time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+		_, err := client.GetRegistration()
+		retryAfterErr := &RetryAfterError{}
+		Expect(errors.As(err, &retryAfterErr)).To(BeTrue())
+		Expect(retryAfterErr.RetryAfter()).To(BeNumerically("~", time.Hour, time.Minute))
+	})
+	It("should ignore missing or malformed header", func() {
+		for _, retryAfter = range []string{"", "not a valid value"} {
+			_, err := client.GetRegistration()
+			Expect(err).Should(MatchError(ErrUnexpectedCode))
+			retryAfterErr := &RetryAfterError{}
+			Expect(errors.As(err, &retryAfterErr)).To(BeFalse())
+		}
+	})
+})
+
+var _ = Describe("Elemental API Client CA rotation", Label("agent", "client"), func() {
+	var fs vfs.FS
+	var err error
+	var fsCleanup func()
+	var server *httptest.Server
+	var servedCert atomic.Pointer[tls.Certificate]
+	var currentCA, nextCA *certtest.Certificate
+	var conf config.Config
+
+	BeforeEach(func() {
+		fs, fsCleanup, err = vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		currentCA, err = certtest.NewCA("current")
+		Expect(err).ToNot(HaveOccurred())
+		nextCA, err = certtest.NewCA("next")
+		Expect(err).ToNot(HaveOccurred())
+		servedCert.Store(newServerCert(currentCA))
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte("{}"))
+		}))
+		// The httptest TLS server always serves its own certificate to clients not using SNI, as for IP addresses.
+		server.Listener = tls.NewListener(server.Listener, &tls.Config{
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				return servedCert.Load(), nil
+			},
+		})
+		// Every request must handshake again, to verify the currently served certificate.
+		server.Config.SetKeepAlivesEnabled(false)
+		server.Start()
+		DeferCleanup(server.Close)
+		conf = config.Config{
+			Registration: v1beta1.Registration{
+				URI:    fmt.Sprintf("https://%s", server.Listener.Addr().String()),
+				CACert: string(currentCA.CertPEM()),
+			},
+		}
+	})
+	It("should keep trusting the Elemental API during the rotation sequence", func() {
+		// Agent trusting the current CA only
+		oldClient := NewClient("v0.0.0-test")
+		Expect(oldClient.Init(fs, nil, conf)).Should(Succeed())
+		_, err := oldClient.GetRegistration()
+		Expect(err).ToNot(HaveOccurred())
+
+		// Agent synced the CA bundle, with overlapping current and next CAs
+		bundleConf := conf
+		bundleConf.Registration.CACert = cert.Bundle(string(currentCA.CertPEM()), string(nextCA.CertPEM()))
+		syncedClient := NewClient("v0.0.0-test")
+		Expect(syncedClient.Init(fs, nil, bundleConf)).Should(Succeed())
+		_, err = syncedClient.GetRegistration()
+		Expect(err).ToNot(HaveOccurred())
+
+		// The Elemental API is served with a certificate signed by the next CA
+		servedCert.Store(newServerCert(nextCA))
+		_, err = syncedClient.GetRegistration()
+		Expect(err).ToNot(HaveOccurred(), "Agents trusting the CA bundle must trust the new certificate")
+		_, err = oldClient.GetRegistration()
+		Expect(err).To(HaveOccurred(), "Agents that did not sync the CA bundle can not trust the new certificate")
+
+		// The rotation is complete, the previous CA is removed from the bundle
+		completedConf := conf
+		completedConf.Registration.CACert = string(nextCA.CertPEM())
+		Expect(syncedClient.Init(fs, nil, completedConf)).Should(Succeed())
+		_, err = syncedClient.GetRegistration()
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
+
+// newServerCert returns a certificate for the local test server, signed by the CA.
+func newServerCert(ca *certtest.Certificate) *tls.Certificate {
+	serverCert, err := certtest.NewServer(2, []string{"127.0.0.1"}, ca)
+	Expect(err).ToNot(HaveOccurred())
+	return serverCert.TLSCertificate()
+}
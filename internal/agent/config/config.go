@@ -1,10 +1,14 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"time"
 
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
+	"gopkg.in/yaml.v3"
 )
 
 // Defaults.
@@ -49,3 +53,30 @@ func DefaultConfig() Config {
 		},
 	}
 }
+
+// WithDefaults returns the config with the default values applied to the unset fields,
+// the same way they are applied when loading the agent config file.
+func WithDefaults(conf Config) Config {
+	defaults := DefaultConfig()
+	if len(conf.Agent.WorkDir) == 0 {
+		conf.Agent.WorkDir = defaults.Agent.WorkDir
+	}
+	if conf.Agent.Reconciliation == 0 {
+		conf.Agent.Reconciliation = defaults.Agent.Reconciliation
+	}
+	if len(conf.Agent.OSPlugin) == 0 {
+		conf.Agent.OSPlugin = defaults.Agent.OSPlugin
+	}
+	return conf
+}
+
+// Hash returns the hex encoded sha256 hash of the config.
+// It is reported to the Elemental API to highlight which config is applied on the host.
+func Hash(conf Config) (string, error) {
+	confBytes, err := yaml.Marshal(conf)
+	if err != nil {
+		return "", fmt.Errorf("marshalling agent config: %w", err)
+	}
+	hash := sha256.Sum256(confBytes)
+	return hex.EncodeToString(hash[:]), nil
+}
@@ -3,6 +3,7 @@ package context
 import (
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/client"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/config"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/identity"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin"
 )
@@ -14,4 +15,5 @@ type AgentContext struct {
 	Config     config.Config
 	ConfigPath string
 	Hostname   string
+	State      state.Manager
 }
@@ -1,7 +1,9 @@
 package elementalcli
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strconv"
@@ -13,6 +15,9 @@ import (
 
 const (
 	CorrelationIDLabelKey = "correlationID"
+
+	// outputLines is the number of elemental CLI output lines kept for support bundles.
+	outputLines = 500
 )
 
 type Install struct {
@@ -63,21 +68,28 @@ type Snapshot struct {
 }
 
 type Runner interface {
-	Install(Install) error
+	// Install runs the elemental install, killing it if the context is done before completion.
+	Install(context.Context, Install) error
 	Reset(Reset) error
 	Upgrade(Upgrade, string) error
 	GetState() (State, error)
+	// Output returns the last lines of the elemental CLI commands output.
+	Output() []byte
 }
 
 func NewRunner() Runner {
-	return &runner{}
+	return &runner{
+		output: log.NewTailBuffer(outputLines),
+	}
 }
 
 var _ Runner = (*runner)(nil)
 
-type runner struct{}
+type runner struct {
+	output *log.TailBuffer
+}
 
-func (r *runner) Install(conf Install) error {
+func (r *runner) Install(ctx context.Context, conf Install) error {
 	log.Debug("Running elemental install")
 	installerOpts := []string{"elemental"}
 	// There are no env var bindings in elemental-cli for elemental root options
@@ -91,15 +103,18 @@ func (r *runner) Install(conf Install) error {
 	}
 	installerOpts = append(installerOpts, "install")
 
-	cmd := exec.Command("elemental")
+	cmd := exec.CommandContext(ctx, "elemental")
 	environmentVariables := mapToInstallEnv(conf)
 	cmd.Env = append(os.Environ(), environmentVariables...)
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = io.MultiWriter(os.Stdout, r.output)
 	cmd.Args = installerOpts
 	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = io.MultiWriter(os.Stderr, r.output)
 	log.Debugf("running: %s\n with ENV:\n%s", strings.Join(installerOpts, " "), strings.Join(environmentVariables, "\n"))
 	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("running elemental install: %w", ctx.Err())
+		}
 		return fmt.Errorf("running elemental install: %w", err)
 	}
 	return nil
@@ -118,10 +133,10 @@ func (r *runner) Reset(conf Reset) error {
 	cmd := exec.Command("elemental")
 	environmentVariables := mapToResetEnv(conf)
 	cmd.Env = append(os.Environ(), environmentVariables...)
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = io.MultiWriter(os.Stdout, r.output)
 	cmd.Args = installerOpts
 	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = io.MultiWriter(os.Stderr, r.output)
 	log.Debugf("running: %s\n with ENV:\n%s", strings.Join(installerOpts, " "), strings.Join(environmentVariables, "\n"))
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("running elemental reset: %w", err)
@@ -142,10 +157,10 @@ func (r *runner) Upgrade(conf Upgrade, correlationID string) error {
 	cmd := exec.Command("elemental")
 	environmentVariables := mapToUpgradeEnv(conf, correlationID)
 	cmd.Env = append(os.Environ(), environmentVariables...)
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = io.MultiWriter(os.Stdout, r.output)
 	cmd.Args = installerOpts
 	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = io.MultiWriter(os.Stderr, r.output)
 	log.Debugf("running: %s\n with ENV:\n%s", strings.Join(installerOpts, " "), strings.Join(environmentVariables, "\n"))
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("running elemental upgrade: %w", err)
@@ -161,7 +176,7 @@ func (r *runner) GetState() (State, error) {
 	cmd := exec.Command("elemental")
 	cmd.Args = installerOpts
 	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = io.MultiWriter(os.Stderr, r.output)
 	log.Debugf("running: %s", strings.Join(installerOpts, " "))
 
 	var commandOutput []byte
@@ -176,6 +191,10 @@ func (r *runner) GetState() (State, error) {
 	return state, nil
 }
 
+func (r *runner) Output() []byte {
+	return r.output.Bytes()
+}
+
 func mapToInstallEnv(conf Install) []string {
 	var variables []string
 	// See GetInstallKeyEnvMap() in https://github.com/rancher/elemental-toolkit/blob/main/pkg/constants/constants.go
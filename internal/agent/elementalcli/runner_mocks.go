@@ -26,6 +26,7 @@
 package elementalcli
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -70,17 +71,31 @@ func (mr *MockRunnerMockRecorder) GetState() *gomock.Call {
 }
 
 // Install mocks base method.
-func (m *MockRunner) Install(arg0 Install) error {
+func (m *MockRunner) Install(arg0 context.Context, arg1 Install) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Install", arg0)
+	ret := m.ctrl.Call(m, "Install", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Install indicates an expected call of Install.
-func (mr *MockRunnerMockRecorder) Install(arg0 any) *gomock.Call {
+func (mr *MockRunnerMockRecorder) Install(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Install", reflect.TypeOf((*MockRunner)(nil).Install), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Install", reflect.TypeOf((*MockRunner)(nil).Install), arg0, arg1)
+}
+
+// Output mocks base method.
+func (m *MockRunner) Output() []byte {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Output")
+	ret0, _ := ret[0].([]byte)
+	return ret0
+}
+
+// Output indicates an expected call of Output.
+func (mr *MockRunnerMockRecorder) Output() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Output", reflect.TypeOf((*MockRunner)(nil).Output))
 }
 
 // Reset mocks base method.
@@ -13,9 +13,14 @@ import (
 var log logr.Logger
 var config zap.Config
 
+// history keeps the most recent agent logs, to be included in support bundles.
+var history = NewTailBuffer(historyLines)
+
 const (
 	DebugLevel = 1
 	InfoLevel  = 0
+
+	historyLines = 1000
 )
 
 func init() {
@@ -26,16 +31,29 @@ func init() {
 	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	config.EncoderConfig.EncodeDuration = zapcore.StringDurationEncoder
 	// Build the logger
-	zap, err := config.Build()
+	zap, err := build()
 	if err != nil {
 		panic(fmt.Sprintf("initializing logger (%v)?", err))
 	}
 	log = zapr.NewLogger(zap)
 }
 
+// build returns a logger writing to the configured outputs and to the logs history.
+func build() (*zap.Logger, error) {
+	return config.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		historyCore := zapcore.NewCore(zapcore.NewConsoleEncoder(config.EncoderConfig), zapcore.AddSync(history), config.Level)
+		return zapcore.NewTee(core, historyCore)
+	}))
+}
+
+// History returns the most recent agent logs.
+func History() []byte {
+	return history.Bytes()
+}
+
 func EnableDebug() {
 	config.Level = zap.NewAtomicLevelAt(zapcore.Level(-1))
-	zap, err := config.Build()
+	zap, err := build()
 	if err != nil {
 		panic(fmt.Sprintf("enabling debug on logger (%v)?", err))
 	}
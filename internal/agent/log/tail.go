@@ -0,0 +1,47 @@
+package log
+
+import (
+	"bytes"
+	"sync"
+)
+
+// TailBuffer is an io.Writer keeping the last lines written to it.
+// It is used to attach recent output to support bundles.
+type TailBuffer struct {
+	lock     sync.Mutex
+	maxLines int
+	lines    [][]byte
+	partial  []byte
+}
+
+// NewTailBuffer returns a TailBuffer keeping up to maxLines lines.
+func NewTailBuffer(maxLines int) *TailBuffer {
+	return &TailBuffer{maxLines: maxLines}
+}
+
+// Write splits the input in lines, dropping the oldest lines once the buffer is full.
+func (b *TailBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	data := append(b.partial, p...)
+	for {
+		index := bytes.IndexByte(data, '\n')
+		if index < 0 {
+			break
+		}
+		b.lines = append(b.lines, bytes.Clone(data[:index+1]))
+		data = data[index+1:]
+	}
+	b.partial = bytes.Clone(data)
+	if len(b.lines) > b.maxLines {
+		b.lines = b.lines[len(b.lines)-b.maxLines:]
+	}
+	return len(p), nil
+}
+
+// Bytes returns the buffered lines, including any trailing incomplete line.
+func (b *TailBuffer) Bytes() []byte {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append(bytes.Join(b.lines, nil), b.partial...)
+}
@@ -0,0 +1,28 @@
+package log
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLog(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Agent Log Suite")
+}
+
+var _ = Describe("tail buffer", Label("cli", "log"), func() {
+	It("should keep the last lines", func() {
+		buffer := NewTailBuffer(2)
+		_, err := buffer.Write([]byte("first\nsecond\nthi"))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = buffer.Write([]byte("rd\nfourth"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(buffer.Bytes())).To(Equal("second\nthird\nfourth"))
+	})
+	It("should record the agent logs history", func() {
+		Info("test history entry")
+		Expect(string(History())).To(ContainSubstring("test history entry"))
+	})
+})
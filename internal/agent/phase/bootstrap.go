@@ -3,9 +3,11 @@ package phase
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
 	"github.com/twpayne/go-vfs/v4"
 	corev1 "k8s.io/api/core/v1"
@@ -55,8 +57,12 @@ type bootstrapHandler struct {
 // from this state if possible, or to just return an error to highlight manual intervention is needed (and possibly a machine reset).
 func (b *bootstrapHandler) Bootstrap() (infrastructurev1.PostAction, error) {
 	setPhase(b.agentContext.Client, b.agentContext.Hostname, infrastructurev1.PhaseBootstrapping)
-	post, err := b.bootstrap()
+	agentState := loadState(b.agentContext.State)
+	agentState.Phase = infrastructurev1.PhaseBootstrapping
+	agentState.Bootstrap.Attempt(time.Now())
+	post, err := b.bootstrap(&agentState)
 	if err != nil {
+		agentState.Bootstrap.Fail(err)
 		updateCondition(b.agentContext.Client, b.agentContext.Hostname, clusterv1.Condition{
 			Type:     infrastructurev1.BootstrapReady,
 			Status:   corev1.ConditionFalse,
@@ -65,10 +71,11 @@ func (b *bootstrapHandler) Bootstrap() (infrastructurev1.PostAction, error) {
 			Message:  err.Error(),
 		})
 	}
+	saveState(b.agentContext.State, agentState)
 	return post, err
 }
 
-func (b *bootstrapHandler) bootstrap() (infrastructurev1.PostAction, error) {
+func (b *bootstrapHandler) bootstrap(agentState *state.State) (infrastructurev1.PostAction, error) {
 	_, err := b.fs.Stat(bootstrapSentinelFile)
 
 	// Assume system is successfully bootstrapped if sentinel file is found
@@ -78,11 +85,15 @@ func (b *bootstrapHandler) bootstrap() (infrastructurev1.PostAction, error) {
 			return infrastructurev1.PostAction{}, fmt.Errorf("updating bootstrapped status: %w", err)
 		}
 		log.Info("Bootstrap config applied successfully")
+		agentState.Bootstrap.Complete(time.Now())
 		return infrastructurev1.PostAction{}, nil
 	}
 
 	// Sentinel file not found, assume system needs bootstrapping
 	if os.IsNotExist(err) {
+		if agentState.Bootstrap.Applied {
+			log.Infof("Bootstrap config was already applied, but file '%s' was not found. Applying bootstrap config again.", bootstrapSentinelFile)
+		}
 		log.Debug("Fetching bootstrap config")
 		bootstrap, err := b.agentContext.Client.GetBootstrap(b.agentContext.Hostname)
 		if err != nil {
@@ -92,6 +103,7 @@ func (b *bootstrapHandler) bootstrap() (infrastructurev1.PostAction, error) {
 		if err := b.agentContext.Plugin.Bootstrap(bootstrap.Format, []byte(bootstrap.Config)); err != nil {
 			return infrastructurev1.PostAction{}, fmt.Errorf("applying bootstrap config: %w", err)
 		}
+		agentState.Bootstrap.Applied = true
 		updateCondition(b.agentContext.Client, b.agentContext.Hostname, clusterv1.Condition{
 			Type:     infrastructurev1.BootstrapReady,
 			Status:   corev1.ConditionFalse,
@@ -9,6 +9,7 @@ import (
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/client"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin"
 	"github.com/twpayne/go-vfs/v4"
@@ -46,6 +47,7 @@ var _ = Describe("bootstrap handler", Label("cli", "phases", "bootstrap"), func(
 			Config:     ConfigFixture,
 			ConfigPath: ConfigPathFixture,
 			Hostname:   HostResponseFixture.Name,
+			State:      state.NewManager(fs, ConfigFixture.Agent.WorkDir),
 		}
 		handler = &bootstrapHandler{
 			agentContext: agentContext,
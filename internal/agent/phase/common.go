@@ -5,7 +5,9 @@ import (
 
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/client"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -42,3 +44,30 @@ func setPhase(client client.Client, hostname string, phase infrastructurev1.Host
 		log.Errorf(err, "Could not report phase: %s", phase)
 	}
 }
+
+// loadState is a best-effort attempt to load the persisted agent state.
+// In case of failures, the phase will start from scratch.
+func loadState(manager state.Manager) state.State {
+	agentState, err := manager.Load()
+	if err != nil {
+		log.Error(err, "Could not load agent state, starting from scratch")
+		return state.State{}
+	}
+	return agentState
+}
+
+// saveState is a best-effort attempt to persist the agent state.
+// In case of failures, the phase will start from the last successfully persisted state after a restart.
+func saveState(manager state.Manager, agentState state.State) {
+	if err := manager.Save(agentState); err != nil {
+		log.Error(err, "Could not persist agent state")
+	}
+}
+
+// uploadSupportBundle is a best-effort attempt to upload a support bundle.
+// Failing to upload diagnostics should never block the current phase.
+func uploadSupportBundle(agentContext context.AgentContext, reason string) {
+	if err := NewSupportBundleHandler(agentContext).Upload(reason); err != nil {
+		log.Error(err, "Could not upload support bundle")
+	}
+}
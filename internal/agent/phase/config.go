@@ -0,0 +1,91 @@
+package phase
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/config"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
+	"github.com/twpayne/go-vfs/v4"
+	"gopkg.in/yaml.v3"
+)
+
+type ConfigSyncHandler interface {
+	// Sync updates the agent config if it drifted from the remote ElementalRegistration one.
+	// A true flag is returned if the config was updated.
+	Sync() (bool, error)
+}
+
+var _ ConfigSyncHandler = (*configSyncHandler)(nil)
+
+func NewConfigSyncHandler(agentContext *context.AgentContext) ConfigSyncHandler {
+	return &configSyncHandler{
+		agentContext: agentContext,
+		fs:           vfs.OSFS,
+	}
+}
+
+type configSyncHandler struct {
+	agentContext *context.AgentContext
+	fs           vfs.FS
+}
+
+// Sync fetches the remote ElementalRegistration and compares its config with the local one.
+// On drift the new config is installed, and the fields that are safe to change at runtime are applied immediately.
+// The Elemental API client is initialized again when the connection settings change, for example the CA certificate.
+// The OS plugin, the update public key, and disabling debug logging, only take effect when the agent is restarted.
+func (c *configSyncHandler) Sync() (bool, error) {
+	registration, err := c.agentContext.Client.GetHostRegistration(c.agentContext.Hostname)
+	if err != nil {
+		return false, fmt.Errorf("getting remote host registration: %w", err)
+	}
+	current := c.agentContext.Config
+	desired := config.WithDefaults(config.FromAPI(*registration))
+	// The registration token is not disclosed to registered hosts, and the work directory
+	// can not be moved without losing the host identity and state.
+	desired.Registration.Token = current.Registration.Token
+	desired.Agent.WorkDir = current.Agent.WorkDir
+	if reflect.DeepEqual(current, desired) {
+		log.Debug("Agent config is in sync")
+		return false, nil
+	}
+	log.Info("Agent config drifted from the remote registration. Updating it.")
+
+	// Validate the new connection settings before persisting them,
+	// otherwise the agent could not reach the Elemental API after a restart.
+	if current.Registration != desired.Registration ||
+		current.Agent.InsecureAllowHTTP != desired.Agent.InsecureAllowHTTP ||
+		current.Agent.InsecureSkipTLSVerify != desired.Agent.InsecureSkipTLSVerify ||
+		current.Agent.UseSystemCertPool != desired.Agent.UseSystemCertPool {
+		log.Info("Initializing Elemental API client with updated connection settings")
+		if err := c.agentContext.Client.Init(c.fs, c.agentContext.Identity, desired); err != nil {
+			return false, fmt.Errorf("initializing Elemental API client: %w", err)
+		}
+	}
+
+	// Persist agent config
+	agentConfigBytes, err := yaml.Marshal(desired)
+	if err != nil {
+		return false, fmt.Errorf("marshalling agent config: %w", err)
+	}
+	if err := c.agentContext.Plugin.InstallFile(agentConfigBytes, c.agentContext.ConfigPath, 0640, 0, 0); err != nil {
+		return false, fmt.Errorf("persisting agent config file '%s': %w", c.agentContext.ConfigPath, err)
+	}
+
+	// Hot apply
+	if desired.Agent.Debug && !current.Agent.Debug {
+		log.EnableDebug()
+	}
+	if !desired.Agent.Debug && current.Agent.Debug {
+		log.Info("Disabling debug logging will take effect on next restart")
+	}
+	if desired.Agent.OSPlugin != current.Agent.OSPlugin {
+		log.Infof("Plugin '%s' will be loaded on next restart", desired.Agent.OSPlugin)
+	}
+	if desired.Agent.UpdatePublicKey != current.Agent.UpdatePublicKey {
+		log.Info("Update public key will be used on next restart")
+	}
+	c.agentContext.Config = desired
+	return true, nil
+}
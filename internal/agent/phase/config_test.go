@@ -0,0 +1,129 @@
+package phase
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/client"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/config"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/identity"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin"
+	"github.com/twpayne/go-vfs/v4"
+	"github.com/twpayne/go-vfs/v4/vfst"
+	gomock "go.uber.org/mock/gomock"
+	"gopkg.in/yaml.v3"
+)
+
+var _ = Describe("config sync handler", Label("cli", "phases", "config"), func() {
+	var mockCtrl *gomock.Controller
+	var mClient *client.MockClient
+	var plugin *osplugin.MockPlugin
+	var id *identity.MockIdentity
+	var agentContext *context.AgentContext
+	var handler ConfigSyncHandler
+	var fs vfs.FS
+	var fsCleanup func()
+	var err error
+	var registration api.RegistrationResponse
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		fs, fsCleanup, err = vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		mClient = client.NewMockClient(mockCtrl)
+		plugin = osplugin.NewMockPlugin(mockCtrl)
+		id = identity.NewMockIdentity(mockCtrl)
+		agentContext = &context.AgentContext{
+			Identity:   id,
+			Plugin:     plugin,
+			Client:     mClient,
+			Config:     ConfigFixture,
+			ConfigPath: ConfigPathFixture,
+			Hostname:   HostResponseFixture.Name,
+		}
+		handler = &configSyncHandler{
+			agentContext: agentContext,
+			fs:           fs,
+		}
+		// The registration token is never returned to registered hosts
+		registration = RegistrationFixture
+		registration.Config.Elemental.Registration.Token = ""
+	})
+	It("should do nothing if config is in sync", func() {
+		mClient.EXPECT().GetHostRegistration(HostResponseFixture.Name).Return(&registration, nil)
+		updated, err := handler.Sync()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updated).To(BeFalse())
+		Expect(agentContext.Config).To(Equal(ConfigFixture))
+	})
+	It("should install and apply drifted config", func() {
+		registration.Config.Elemental.Agent.Reconciliation = time.Minute
+		registration.Config.Elemental.Agent.PostInstall.PowerOff = false
+		wantConfig := ConfigFixture
+		wantConfig.Agent.Reconciliation = time.Minute
+		wantConfig.Agent.PostInstall.PowerOff = false
+		wantConfigBytes, err := yaml.Marshal(wantConfig)
+		Expect(err).ToNot(HaveOccurred())
+		gomock.InOrder(
+			mClient.EXPECT().GetHostRegistration(HostResponseFixture.Name).Return(&registration, nil),
+			plugin.EXPECT().InstallFile(wantConfigBytes, ConfigPathFixture, uint32(0640), 0, 0).Return(nil),
+		)
+		updated, err := handler.Sync()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updated).To(BeTrue())
+		Expect(agentContext.Config).To(Equal(wantConfig))
+	})
+	It("should apply defaults and never change the work directory", func() {
+		registration.Config.Elemental.Agent.Reconciliation = 0
+		registration.Config.Elemental.Agent.OSPlugin = ""
+		registration.Config.Elemental.Agent.WorkDir = "/a/different/work/dir"
+		wantConfig := ConfigFixture
+		wantConfig.Agent.Reconciliation = config.DefaultConfig().Agent.Reconciliation
+		wantConfig.Agent.OSPlugin = config.DefaultConfig().Agent.OSPlugin
+		gomock.InOrder(
+			mClient.EXPECT().GetHostRegistration(HostResponseFixture.Name).Return(&registration, nil),
+			plugin.EXPECT().InstallFile(gomock.Any(), ConfigPathFixture, uint32(0640), 0, 0).Return(nil),
+		)
+		updated, err := handler.Sync()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updated).To(BeTrue())
+		Expect(agentContext.Config).To(Equal(wantConfig))
+	})
+	It("should initialize the client when connection settings change", func() {
+		registration.Config.Elemental.Registration.CACert = "just a new CA cert"
+		wantConfig := ConfigFixture
+		wantConfig.Registration.CACert = "just a new CA cert"
+		gomock.InOrder(
+			mClient.EXPECT().GetHostRegistration(HostResponseFixture.Name).Return(&registration, nil),
+			mClient.EXPECT().Init(fs, id, wantConfig).Return(nil),
+			plugin.EXPECT().InstallFile(gomock.Any(), ConfigPathFixture, uint32(0640), 0, 0).Return(nil),
+		)
+		updated, err := handler.Sync()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updated).To(BeTrue())
+		Expect(agentContext.Config).To(Equal(wantConfig))
+	})
+	It("should not persist connection settings the client can not be initialized with", func() {
+		registration.Config.Elemental.Registration.URI = "ftp://not.valid"
+		wantErr := errors.New("test init error")
+		gomock.InOrder(
+			mClient.EXPECT().GetHostRegistration(HostResponseFixture.Name).Return(&registration, nil),
+			mClient.EXPECT().Init(fs, id, gomock.Any()).Return(wantErr),
+		)
+		updated, err := handler.Sync()
+		Expect(errors.Is(err, wantErr)).To(BeTrue())
+		Expect(updated).To(BeFalse())
+		Expect(agentContext.Config).To(Equal(ConfigFixture))
+	})
+	It("should fail on remote registration error", func() {
+		wantErr := errors.New("test get host registration error")
+		mClient.EXPECT().GetHostRegistration(HostResponseFixture.Name).Return(nil, wantErr)
+		updated, err := handler.Sync()
+		Expect(errors.Is(err, wantErr)).To(BeTrue())
+		Expect(updated).To(BeFalse())
+	})
+})
@@ -0,0 +1,131 @@
+package phase
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/utils"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
+)
+
+// probeTimeoutSeconds is the maximum duration of each diagnostic probe command.
+const probeTimeoutSeconds = 30
+
+var ErrUnsupportedProbe = errors.New("unsupported diagnostic probe")
+
+type DiagnosticHandler interface {
+	Run(diagnostic api.HostDiagnostic) error
+}
+
+var _ DiagnosticHandler = (*diagnosticHandler)(nil)
+
+func NewDiagnosticHandler(agentContext context.AgentContext) DiagnosticHandler {
+	return &diagnosticHandler{
+		agentContext: agentContext,
+		cmdRunner:    utils.NewCommandRunner(),
+	}
+}
+
+type diagnosticHandler struct {
+	agentContext context.AgentContext
+	cmdRunner    utils.CommandRunner
+}
+
+// Run executes the diagnostic probes and posts their results to the Elemental API.
+// Each probe failure is reported in its result, so that all the requested probes are always executed.
+func (d *diagnosticHandler) Run(diagnostic api.HostDiagnostic) error {
+	results := []infrastructurev1.DiagnosticProbeResult{}
+	for _, probe := range diagnostic.Probes {
+		results = append(results, d.runProbe(probe))
+	}
+	if err := d.agentContext.Client.PostDiagnosticResults(api.DiagnosticResultsRequest{
+		Results: results,
+	}, diagnostic.Name, d.agentContext.Hostname); err != nil {
+		return fmt.Errorf("posting diagnostic results: %w", err)
+	}
+	return nil
+}
+
+func (d *diagnosticHandler) runProbe(probe infrastructurev1.DiagnosticProbe) infrastructurev1.DiagnosticProbeResult {
+	result := infrastructurev1.DiagnosticProbeResult{Probe: probe}
+	command, err := d.probeCommand(probe)
+	if err != nil {
+		log.Errorf(err, "Could not run diagnostic probe '%s'", probe)
+		result.Output = err.Error()
+		return result
+	}
+	log.Infof("Running diagnostic probe '%s'", probe)
+	output, err := d.cmdRunner.CommandOutput(command)
+	if err != nil {
+		output = append(output, []byte(fmt.Sprintf("\n%s\n", err.Error()))...)
+	} else {
+		result.Succeeded = true
+	}
+	// Keep the most recent output only
+	if len(output) > api.MaxDiagnosticOutputSize {
+		output = output[len(output)-api.MaxDiagnosticOutputSize:]
+	}
+	result.Output = string(output)
+	return result
+}
+
+// probeCommand returns the read-only command executing the probe.
+// This is the allowlist of commands that can be executed remotely, any other probe is rejected.
+func (d *diagnosticHandler) probeCommand(probe infrastructurev1.DiagnosticProbe) (string, error) {
+	var command string
+	switch probe {
+	case infrastructurev1.DiagnosticProbeRegistrationReachability:
+		host, port, err := d.registrationHostPort()
+		if err != nil {
+			return "", err
+		}
+		// Open a TCP connection through the bash /dev/tcp redirection, without sending any data.
+		command = fmt.Sprintf("bash -c %s", shellQuote(fmt.Sprintf("</dev/tcp/%s/%s && echo %s",
+			shellQuote(host), port, shellQuote(fmt.Sprintf("%s:%s is reachable", host, port)))))
+	case infrastructurev1.DiagnosticProbeDiskList:
+		command = "lsblk --output NAME,TYPE,SIZE,FSTYPE,MOUNTPOINT,MODEL"
+	case infrastructurev1.DiagnosticProbeElementalState:
+		command = "elemental state"
+	case infrastructurev1.DiagnosticProbeTimeSync:
+		command = "timedatectl status"
+	case infrastructurev1.DiagnosticProbeDNSResolution:
+		host, _, err := d.registrationHostPort()
+		if err != nil {
+			return "", err
+		}
+		command = fmt.Sprintf("getent ahosts %s", shellQuote(host))
+	default:
+		return "", fmt.Errorf("%w: '%s'", ErrUnsupportedProbe, probe)
+	}
+	return fmt.Sprintf("timeout %d %s 2>&1", probeTimeoutSeconds, command), nil
+}
+
+// registrationHostPort returns the host and port of the registration URI.
+func (d *diagnosticHandler) registrationHostPort() (string, string, error) {
+	registrationURL, err := url.Parse(d.agentContext.Config.Registration.URI)
+	if err != nil {
+		return "", "", fmt.Errorf("parsing registration URI: %w", err)
+	}
+	host := registrationURL.Hostname()
+	if len(host) == 0 {
+		return "", "", fmt.Errorf("registration URI '%s' has no host", d.agentContext.Config.Registration.URI)
+	}
+	port := registrationURL.Port()
+	if len(port) == 0 {
+		port = "443"
+		if registrationURL.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return host, port, nil
+}
+
+// shellQuote quotes the input, so that it is passed to bash as a single literal word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,117 @@
+package phase
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/client"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/utils"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
+	gomock "go.uber.org/mock/gomock"
+)
+
+var _ = Describe("diagnostic handler", Label("cli", "phases", "diagnostic"), func() {
+	var mockCtrl *gomock.Controller
+	var mClient *client.MockClient
+	var cmdRunner *utils.MockCommandRunner
+	var handler *diagnosticHandler
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mClient = client.NewMockClient(mockCtrl)
+		cmdRunner = utils.NewMockCommandRunner(mockCtrl)
+		handler = &diagnosticHandler{
+			agentContext: context.AgentContext{
+				Client:   mClient,
+				Config:   ConfigFixture,
+				Hostname: HostResponseFixture.Name,
+			},
+			cmdRunner: cmdRunner,
+		}
+	})
+	It("should only run the allowed commands", func() {
+		diagnostic := api.HostDiagnostic{
+			Name: "test-diagnostic",
+			Probes: []v1beta1.DiagnosticProbe{
+				v1beta1.DiagnosticProbeRegistrationReachability,
+				v1beta1.DiagnosticProbeDiskList,
+				v1beta1.DiagnosticProbeElementalState,
+				v1beta1.DiagnosticProbeTimeSync,
+				v1beta1.DiagnosticProbeDNSResolution,
+			},
+		}
+		gomock.InOrder(
+			cmdRunner.EXPECT().CommandOutput(`timeout 30 bash -c '</dev/tcp/'\''test.test'\''/443 && echo '\''test.test:443 is reachable'\''' 2>&1`).Return([]byte("test.test:443 is reachable\n"), nil),
+			cmdRunner.EXPECT().CommandOutput("timeout 30 lsblk --output NAME,TYPE,SIZE,FSTYPE,MOUNTPOINT,MODEL 2>&1").Return([]byte("test disks\n"), nil),
+			cmdRunner.EXPECT().CommandOutput("timeout 30 elemental state 2>&1").Return([]byte("test state\n"), nil),
+			cmdRunner.EXPECT().CommandOutput("timeout 30 timedatectl status 2>&1").Return([]byte("partial output\n"), errors.New("test timedatectl error")),
+			cmdRunner.EXPECT().CommandOutput("timeout 30 getent ahosts 'test.test' 2>&1").Return([]byte("127.0.0.1 test.test\n"), nil),
+			mClient.EXPECT().PostDiagnosticResults(gomock.Any(), "test-diagnostic", HostResponseFixture.Name).Do(func(request api.DiagnosticResultsRequest, _ string, _ string) {
+				Expect(request.Results).Should(HaveLen(5))
+				Expect(request.Results[0]).Should(Equal(v1beta1.DiagnosticProbeResult{
+					Probe:     v1beta1.DiagnosticProbeRegistrationReachability,
+					Succeeded: true,
+					Output:    "test.test:443 is reachable\n",
+				}))
+				Expect(request.Results[3].Probe).Should(Equal(v1beta1.DiagnosticProbeTimeSync))
+				Expect(request.Results[3].Succeeded).Should(BeFalse())
+				Expect(request.Results[3].Output).Should(HavePrefix("partial output\n"))
+				Expect(request.Results[3].Output).Should(ContainSubstring("test timedatectl error"))
+			}),
+		)
+		Expect(handler.Run(diagnostic)).Should(Succeed())
+	})
+	It("should never run unsupported probes", func() {
+		diagnostic := api.HostDiagnostic{
+			Name:   "test-diagnostic",
+			Probes: []v1beta1.DiagnosticProbe{"rm -rf /"},
+		}
+		mClient.EXPECT().PostDiagnosticResults(gomock.Any(), "test-diagnostic", HostResponseFixture.Name).Do(func(request api.DiagnosticResultsRequest, _ string, _ string) {
+			Expect(request.Results).Should(HaveLen(1))
+			Expect(request.Results[0].Succeeded).Should(BeFalse())
+			Expect(request.Results[0].Output).Should(ContainSubstring(ErrUnsupportedProbe.Error()))
+		})
+		Expect(handler.Run(diagnostic)).Should(Succeed())
+	})
+	It("should quote the registration host", func() {
+		handler.agentContext.Config.Registration.URI = "https://$(reboot):8443/elemental"
+		diagnostic := api.HostDiagnostic{
+			Name:   "test-diagnostic",
+			Probes: []v1beta1.DiagnosticProbe{v1beta1.DiagnosticProbeDNSResolution},
+		}
+		gomock.InOrder(
+			cmdRunner.EXPECT().CommandOutput("timeout 30 getent ahosts '$(reboot)' 2>&1").Return(nil, errors.New("test getent error")),
+			mClient.EXPECT().PostDiagnosticResults(gomock.Any(), "test-diagnostic", HostResponseFixture.Name),
+		)
+		Expect(handler.Run(diagnostic)).Should(Succeed())
+	})
+	It("should keep the most recent output within the maximum size", func() {
+		diagnostic := api.HostDiagnostic{
+			Name:   "test-diagnostic",
+			Probes: []v1beta1.DiagnosticProbe{v1beta1.DiagnosticProbeElementalState},
+		}
+		largeOutput := append(bytes.Repeat([]byte("a"), api.MaxDiagnosticOutputSize), []byte("last line")...)
+		gomock.InOrder(
+			cmdRunner.EXPECT().CommandOutput("timeout 30 elemental state 2>&1").Return(largeOutput, nil),
+			mClient.EXPECT().PostDiagnosticResults(gomock.Any(), "test-diagnostic", HostResponseFixture.Name).Do(func(request api.DiagnosticResultsRequest, _ string, _ string) {
+				Expect(request.Results[0].Output).Should(HaveLen(api.MaxDiagnosticOutputSize))
+				Expect(request.Results[0].Output).Should(HaveSuffix("last line"))
+			}),
+		)
+		Expect(handler.Run(diagnostic)).Should(Succeed())
+	})
+	It("should return error if posting results fails", func() {
+		diagnostic := api.HostDiagnostic{
+			Name:   "test-diagnostic",
+			Probes: []v1beta1.DiagnosticProbe{v1beta1.DiagnosticProbeTimeSync},
+		}
+		gomock.InOrder(
+			cmdRunner.EXPECT().CommandOutput("timeout 30 timedatectl status 2>&1").Return([]byte("synchronized\n"), nil),
+			mClient.EXPECT().PostDiagnosticResults(gomock.Any(), "test-diagnostic", HostResponseFixture.Name).Return(errors.New("test post error")),
+		)
+		Expect(handler.Run(diagnostic)).ShouldNot(Succeed())
+	})
+})
@@ -5,20 +5,29 @@ import (
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 
+	gocontext "context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/backoff"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin"
 	"k8s.io/utils/ptr"
 )
 
+// ErrInstallationAborted is returned when the ElementalHost needs reset before being installed,
+// for example because the installation deadline passed.
+var ErrInstallationAborted = errors.New("installation aborted")
+
 type InstallHandler interface {
-	Install()
+	Install() error
 }
 
 var _ InstallHandler = (*installHandler)(nil)
@@ -33,36 +42,99 @@ type installHandler struct {
 	agentContext context.AgentContext
 }
 
-func (i *installHandler) Install() {
+func (i *installHandler) Install() error {
+	// An installation can only be resumed once approved
+	agentState := loadState(i.agentContext.State)
+	if !agentState.Installation.CloudConfigApplied && !agentState.Installation.Installed {
+		if err := i.waitForApproval(); err != nil {
+			return err
+		}
+	}
 	setPhase(i.agentContext.Client, i.agentContext.Hostname, infrastructurev1.PhaseInstalling)
-	i.installLoop()
+	return i.installLoop()
+}
+
+// waitForApproval **indefinitely** waits for the ElementalHost to be approved, if the ElementalRegistration requires approval.
+// The wait is aborted if the remote ElementalHost needs reset.
+func (i *installHandler) waitForApproval() error {
+	retry := backoff.NewBackoff(i.agentContext.Config.Agent)
+	phaseReported := false
+	for {
+		host, err := i.agentContext.Client.PatchHost(api.HostPatchRequest{}, i.agentContext.Hostname)
+		if err != nil {
+			log.Error(err, "getting remote ElementalHost")
+			retry.Wait(err)
+			continue
+		}
+		if host.NeedsReset {
+			log.Info("ElementalHost needs reset, aborting installation")
+			return ErrInstallationAborted
+		}
+		if !host.PendingApproval {
+			return nil
+		}
+		if !phaseReported {
+			setPhase(i.agentContext.Client, i.agentContext.Hostname, infrastructurev1.PhasePendingApproval)
+			phaseReported = true
+		}
+		retry.Reset()
+		log.Debugf("ElementalHost is pending approval. Waiting %s...", i.agentContext.Config.Agent.Reconciliation.String())
+		time.Sleep(i.agentContext.Config.Agent.Reconciliation)
+	}
 }
 
 // installLoop **indefinitely** tries to fetch the remote registration and install the ElementalHost.
-func (i *installHandler) installLoop() {
-	cloudConfigAlreadyApplied := false
-	alreadyInstalled := false
+// The loop is aborted if the remote ElementalHost needs reset.
+func (i *installHandler) installLoop() error {
+	agentState := loadState(i.agentContext.State)
+	agentState.Phase = infrastructurev1.PhaseInstalling
+	cloudConfigAlreadyApplied := agentState.Installation.CloudConfigApplied
+	alreadyInstalled := agentState.Installation.Installed
+	if cloudConfigAlreadyApplied || alreadyInstalled {
+		log.Infof("Resuming installation (cloud config applied: %t, installed: %t)", cloudConfigAlreadyApplied, alreadyInstalled)
+	}
 	var installationError error
+	var lastUploadedError string
+	retry := backoff.NewBackoff(i.agentContext.Config.Agent)
 	installationErrorReason := infrastructurev1.InstallationFailedReason
 	for {
 		if installationError != nil {
 			// Log error
 			log.Error(installationError, "installing host")
+			agentState.Installation.Fail(installationError)
+			saveState(i.agentContext.State, agentState)
 			// Attempt to report failed condition on management server
-			updateCondition(i.agentContext.Client, i.agentContext.Hostname, clusterv1.Condition{
-				Type:     infrastructurev1.InstallationReady,
-				Status:   corev1.ConditionFalse,
-				Severity: clusterv1.ConditionSeverityError,
-				Reason:   installationErrorReason,
-				Message:  installationError.Error(),
-			})
+			host, err := i.agentContext.Client.PatchHost(api.HostPatchRequest{
+				Condition: &clusterv1.Condition{
+					Type:     infrastructurev1.InstallationReady,
+					Status:   corev1.ConditionFalse,
+					Severity: clusterv1.ConditionSeverityError,
+					Reason:   installationErrorReason,
+					Message:  installationError.Error(),
+				},
+			}, i.agentContext.Hostname)
+			if err != nil {
+				log.Error(err, "Could not report condition", "conditionType", infrastructurev1.InstallationReady, "conditionReason", installationErrorReason)
+			}
+			// Upload a support bundle once for each different error, to not flood the Elemental API while retrying
+			if installationError.Error() != lastUploadedError {
+				uploadSupportBundle(i.agentContext, fmt.Sprintf("Installation failed: %s", installationError.Error()))
+				lastUploadedError = installationError.Error()
+			}
+			// Stop retrying if the host needs reset
+			if host != nil && host.NeedsReset {
+				log.Info("ElementalHost needs reset, aborting installation")
+				return ErrInstallationAborted
+			}
+			// Wait for recovery (end user may fix the remote installation instructions meanwhile)
+			log.Debug("Waiting on installation error for installation instructions to mutate")
+			retry.Wait(installationError)
 			// Clear error for next attempt
 			installationError = nil
 			installationErrorReason = infrastructurev1.InstallationFailedReason
-			// Wait for recovery (end user may fix the remote installation instructions meanwhile)
-			log.Debugf("Waiting '%s' on installation error for installation instructions to mutate", i.agentContext.Config.Agent.Reconciliation)
-			time.Sleep(i.agentContext.Config.Agent.Reconciliation)
 		}
+		agentState.Installation.Attempt(time.Now())
+		saveState(i.agentContext.State, agentState)
 		// Fetch remote Registration
 		var registration *api.RegistrationResponse
 		var err error
@@ -88,6 +160,8 @@ func (i *installHandler) installLoop() {
 				continue
 			}
 			cloudConfigAlreadyApplied = true
+			agentState.Installation.CloudConfigApplied = true
+			saveState(i.agentContext.State, agentState)
 		}
 		// Install
 		if !alreadyInstalled {
@@ -96,11 +170,16 @@ func (i *installHandler) installLoop() {
 				installationError = fmt.Errorf("marshalling install config: %w", err)
 				continue
 			}
-			if err := i.agentContext.Plugin.Install(installBytes); err != nil {
+			if err := i.install(installBytes); err != nil {
+				if errors.Is(err, ErrInstallationAborted) {
+					return err
+				}
 				installationError = fmt.Errorf("installing host: %w", err)
 				continue
 			}
 			alreadyInstalled = true
+			agentState.Installation.Installed = true
+			saveState(i.agentContext.State, agentState)
 		}
 		// Report installation success
 		patchRequest := api.HostPatchRequest{Installed: ptr.To(true)}
@@ -112,6 +191,53 @@ func (i *installHandler) installLoop() {
 			installationError = fmt.Errorf("patching host with installation successful: %w", err)
 			continue
 		}
-		break
+		agentState.Installation.Complete(time.Now())
+		saveState(i.agentContext.State, agentState)
+		return nil
+	}
+}
+
+// install runs the plugin installation.
+// If the plugin supports it, the running installation is interrupted as soon as the remote ElementalHost needs reset.
+func (i *installHandler) install(installBytes []byte) error {
+	installer, ok := i.agentContext.Plugin.(osplugin.InterruptibleInstaller)
+	if !ok {
+		return i.agentContext.Plugin.Install(installBytes)
+	}
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	defer cancel()
+	var aborted atomic.Bool
+	go i.watchNeedsReset(ctx, func() {
+		aborted.Store(true)
+		cancel()
+	})
+	err := installer.InstallContext(ctx, installBytes)
+	if aborted.Load() {
+		log.Info("ElementalHost needs reset, installation interrupted")
+		return ErrInstallationAborted
+	}
+	return err
+}
+
+// watchNeedsReset polls the remote ElementalHost every reconciliation interval, until the context is done.
+// The abort function is called if the remote ElementalHost needs reset.
+func (i *installHandler) watchNeedsReset(ctx gocontext.Context, abort func()) {
+	ticker := time.NewTicker(i.agentContext.Config.Agent.Reconciliation)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			host, err := i.agentContext.Client.PatchHost(api.HostPatchRequest{}, i.agentContext.Hostname)
+			if err != nil {
+				log.Error(err, "getting remote ElementalHost")
+				continue
+			}
+			if host.NeedsReset {
+				abort()
+				return
+			}
+		}
 	}
 }
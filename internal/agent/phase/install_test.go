@@ -1,6 +1,7 @@
 package phase
 
 import (
+	gocontext "context"
 	"encoding/json"
 	"errors"
 
@@ -9,15 +10,24 @@ import (
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/client"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/identity"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin"
+	"github.com/twpayne/go-vfs/v4"
+	"github.com/twpayne/go-vfs/v4/vfst"
 	gomock "go.uber.org/mock/gomock"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
+// interruptiblePlugin is an OS plugin implementing the optional InterruptibleInstaller interface.
+type interruptiblePlugin struct {
+	*osplugin.MockPlugin
+	*osplugin.MockInterruptibleInstaller
+}
+
 var _ = Describe("install handler", Label("cli", "phases", "install"), func() {
 	var mockCtrl *gomock.Controller
 	var mClient *client.MockClient
@@ -25,9 +35,15 @@ var _ = Describe("install handler", Label("cli", "phases", "install"), func() {
 	var id *identity.MockIdentity
 	var handler InstallHandler
 	var agentContext context.AgentContext
+	var fs vfs.FS
+	var fsCleanup func()
+	var err error
 
 	BeforeEach(func() {
 		mockCtrl = gomock.NewController(GinkgoT())
+		fs, fsCleanup, err = vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
 		mClient = client.NewMockClient(mockCtrl)
 		plugin = osplugin.NewMockPlugin(mockCtrl)
 		id = identity.NewMockIdentity(mockCtrl)
@@ -38,6 +54,7 @@ var _ = Describe("install handler", Label("cli", "phases", "install"), func() {
 			Config:     ConfigFixture,
 			ConfigPath: ConfigPathFixture,
 			Hostname:   HostResponseFixture.Name,
+			State:      state.NewManager(fs, ConfigFixture.Agent.WorkDir),
 		}
 		handler = NewInstallHandler(agentContext)
 	})
@@ -47,6 +64,8 @@ var _ = Describe("install handler", Label("cli", "phases", "install"), func() {
 		wantInstall, err := json.Marshal(RegistrationFixture.Config.Elemental.Install)
 		Expect(err).ToNot(HaveOccurred())
 		gomock.InOrder(
+			// Expect approval to be checked
+			mClient.EXPECT().PatchHost(api.HostPatchRequest{}, HostResponseFixture.Name).Return(&HostResponseFixture, nil),
 			// Expect phase to be updated
 			mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhaseInstalling)}, HostResponseFixture.Name),
 			// Make the first get registration call fail. Expect to recover by calling again
@@ -62,6 +81,10 @@ var _ = Describe("install handler", Label("cli", "phases", "install"), func() {
 					},
 				))
 			}),
+			mClient.EXPECT().UploadSupportBundle(gomock.Any(), HostResponseFixture.Name).Do(func(bundle api.SupportBundleRequest, _ string) {
+				Expect(bundle.Reason).Should(Equal("Installation failed: getting remote Registration: get registration test error"))
+				Expect(bundle.Files).Should(HaveKey(agentLogFile))
+			}),
 			mClient.EXPECT().GetRegistration().Return(&RegistrationFixture, nil),
 			// Make the cloud init apply fail. Expect to recover by getting registration and applying cloud init again
 			plugin.EXPECT().InstallCloudInit(wantCloudInit).Return(errors.New("cloud init test failed")),
@@ -76,6 +99,10 @@ var _ = Describe("install handler", Label("cli", "phases", "install"), func() {
 					},
 				))
 			}),
+			mClient.EXPECT().UploadSupportBundle(gomock.Any(), HostResponseFixture.Name).Do(func(bundle api.SupportBundleRequest, _ string) {
+				Expect(bundle.Reason).Should(Equal("Installation failed: installing cloud config: cloud init test failed"))
+				Expect(bundle.Files).Should(HaveKey(agentLogFile))
+			}),
 			mClient.EXPECT().GetRegistration().Return(&RegistrationFixture, nil),
 			plugin.EXPECT().InstallCloudInit(wantCloudInit).Return(nil),
 			// Make the install fail. Expect to recover by getting registration and installing again
@@ -91,6 +118,10 @@ var _ = Describe("install handler", Label("cli", "phases", "install"), func() {
 					},
 				))
 			}),
+			mClient.EXPECT().UploadSupportBundle(gomock.Any(), HostResponseFixture.Name).Do(func(bundle api.SupportBundleRequest, _ string) {
+				Expect(bundle.Reason).Should(Equal("Installation failed: installing host: install test fail"))
+				Expect(bundle.Files).Should(HaveKey(agentLogFile))
+			}),
 			mClient.EXPECT().GetRegistration().Return(&RegistrationFixture, nil),
 			plugin.EXPECT().Install(wantInstall).Return(nil),
 			// Make the patch host fail. Expect to recover by patching it again
@@ -106,6 +137,10 @@ var _ = Describe("install handler", Label("cli", "phases", "install"), func() {
 					},
 				))
 			}),
+			mClient.EXPECT().UploadSupportBundle(gomock.Any(), HostResponseFixture.Name).Do(func(bundle api.SupportBundleRequest, _ string) {
+				Expect(bundle.Reason).Should(Equal("Installation failed: patching host with installation successful: patch host test fail"))
+				Expect(bundle.Files).Should(HaveKey(agentLogFile))
+			}),
 			mClient.EXPECT().PatchHost(gomock.Any(), HostResponseFixture.Name).Return(&HostResponseFixture, nil).Do(func(patch api.HostPatchRequest, _ string) {
 				if patch.Installed == nil {
 					GinkgoT().Error("installation patch does not contain installed flag")
@@ -125,6 +160,128 @@ var _ = Describe("install handler", Label("cli", "phases", "install"), func() {
 			}),
 		)
 
-		handler.Install()
+		Expect(handler.Install()).To(Succeed())
+
+		agentState, err := agentContext.State.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(agentState.Phase).To(Equal(infrastructurev1.PhaseInstalling))
+		Expect(agentState.Installation.CloudConfigApplied).To(BeTrue())
+		Expect(agentState.Installation.Installed).To(BeTrue())
+		Expect(agentState.Installation.Attempts).To(Equal(5))
+		Expect(agentState.Installation.Completed()).To(BeTrue())
+	})
+	It("should resume installation from persisted state", func() {
+		wantInstall, err := json.Marshal(RegistrationFixture.Config.Elemental.Install)
+		Expect(err).ToNot(HaveOccurred())
+		// Cloud config was already applied before the agent restarted
+		Expect(agentContext.State.Save(state.State{
+			Installation: state.InstallationState{CloudConfigApplied: true},
+		})).To(Succeed())
+		gomock.InOrder(
+			mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhaseInstalling)}, HostResponseFixture.Name),
+			mClient.EXPECT().GetRegistration().Return(&RegistrationFixture, nil),
+			// Expect cloud config not to be applied again
+			plugin.EXPECT().Install(wantInstall).Return(nil),
+			mClient.EXPECT().PatchHost(gomock.Any(), HostResponseFixture.Name).Return(&HostResponseFixture, nil),
+		)
+
+		Expect(handler.Install()).To(Succeed())
+	})
+	It("should only mark the host as installed if already installed", func() {
+		Expect(agentContext.State.Save(state.State{
+			Installation: state.InstallationState{CloudConfigApplied: true, Installed: true},
+		})).To(Succeed())
+		gomock.InOrder(
+			mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhaseInstalling)}, HostResponseFixture.Name),
+			mClient.EXPECT().PatchHost(gomock.Any(), HostResponseFixture.Name).Return(&HostResponseFixture, nil).Do(func(patch api.HostPatchRequest, _ string) {
+				Expect(patch.Installed).ToNot(BeNil())
+				Expect(*patch.Installed).To(BeTrue())
+			}),
+		)
+
+		Expect(handler.Install()).To(Succeed())
+	})
+	It("should abort installation if host needs reset", func() {
+		wantInstall, err := json.Marshal(RegistrationFixture.Config.Elemental.Install)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(agentContext.State.Save(state.State{
+			Installation: state.InstallationState{CloudConfigApplied: true},
+		})).To(Succeed())
+		needsResetHost := HostResponseFixture
+		needsResetHost.NeedsReset = true
+		gomock.InOrder(
+			mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhaseInstalling)}, HostResponseFixture.Name),
+			mClient.EXPECT().GetRegistration().Return(&RegistrationFixture, nil),
+			plugin.EXPECT().Install(wantInstall).Return(errors.New("install test fail")),
+			// Expect no further attempt once the host needs reset
+			mClient.EXPECT().PatchHost(gomock.Any(), HostResponseFixture.Name).Return(&needsResetHost, nil),
+			// Expect a support bundle to be uploaded before aborting
+			mClient.EXPECT().UploadSupportBundle(gomock.Any(), HostResponseFixture.Name),
+		)
+
+		Expect(handler.Install()).To(MatchError(ErrInstallationAborted))
+		agentState, err := agentContext.State.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(agentState.Installation.Completed()).To(BeFalse())
+	})
+	It("should interrupt the running installation if host needs reset", func() {
+		installer := osplugin.NewMockInterruptibleInstaller(mockCtrl)
+		agentContext.Plugin = interruptiblePlugin{MockPlugin: plugin, MockInterruptibleInstaller: installer}
+		handler = NewInstallHandler(agentContext)
+		wantInstall, err := json.Marshal(RegistrationFixture.Config.Elemental.Install)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(agentContext.State.Save(state.State{
+			Installation: state.InstallationState{CloudConfigApplied: true},
+		})).To(Succeed())
+		needsResetHost := HostResponseFixture
+		needsResetHost.NeedsReset = true
+		gomock.InOrder(
+			mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhaseInstalling)}, HostResponseFixture.Name),
+			mClient.EXPECT().GetRegistration().Return(&RegistrationFixture, nil),
+			// Expect the installation to run until interrupted
+			installer.EXPECT().InstallContext(gomock.Any(), wantInstall).DoAndReturn(func(ctx gocontext.Context, _ []byte) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}),
+		)
+		// Expect the remote host to be watched while installing
+		mClient.EXPECT().PatchHost(api.HostPatchRequest{}, HostResponseFixture.Name).Return(&needsResetHost, nil)
+
+		Expect(handler.Install()).To(MatchError(ErrInstallationAborted))
+		agentState, err := agentContext.State.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(agentState.Installation.Installed).To(BeFalse())
+	})
+	It("should wait for approval before installing", func() {
+		pendingHost := HostResponseFixture
+		pendingHost.PendingApproval = true
+		wantCloudInit, err := json.Marshal(RegistrationFixture.Config.CloudConfig)
+		Expect(err).ToNot(HaveOccurred())
+		wantInstall, err := json.Marshal(RegistrationFixture.Config.Elemental.Install)
+		Expect(err).ToNot(HaveOccurred())
+		gomock.InOrder(
+			// Fail the first attempt. Expect to recover.
+			mClient.EXPECT().PatchHost(api.HostPatchRequest{}, HostResponseFixture.Name).Return(nil, errors.New("test patch host fail")),
+			mClient.EXPECT().PatchHost(api.HostPatchRequest{}, HostResponseFixture.Name).Return(&pendingHost, nil),
+			// Expect the pending approval phase to be reported once
+			mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhasePendingApproval)}, HostResponseFixture.Name),
+			mClient.EXPECT().PatchHost(api.HostPatchRequest{}, HostResponseFixture.Name).Return(&pendingHost, nil),
+			mClient.EXPECT().PatchHost(api.HostPatchRequest{}, HostResponseFixture.Name).Return(&HostResponseFixture, nil),
+			mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhaseInstalling)}, HostResponseFixture.Name),
+			mClient.EXPECT().GetRegistration().Return(&RegistrationFixture, nil),
+			plugin.EXPECT().InstallCloudInit(wantCloudInit).Return(nil),
+			plugin.EXPECT().Install(wantInstall).Return(nil),
+			mClient.EXPECT().PatchHost(gomock.Any(), HostResponseFixture.Name).Return(&HostResponseFixture, nil),
+		)
+
+		Expect(handler.Install()).To(Succeed())
+	})
+	It("should abort waiting for approval if host needs reset", func() {
+		needsResetHost := HostResponseFixture
+		needsResetHost.PendingApproval = true
+		needsResetHost.NeedsReset = true
+		mClient.EXPECT().PatchHost(api.HostPatchRequest{}, HostResponseFixture.Name).Return(&needsResetHost, nil)
+
+		Expect(handler.Install()).To(MatchError(ErrInstallationAborted))
 	})
 })
@@ -1,17 +1,24 @@
 package phase
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/backoff"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/config"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/hostname"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/sysinfo"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/utils"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/identity"
+	"github.com/twpayne/go-vfs/v4"
 	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -27,11 +34,15 @@ var _ RegistrationHandler = (*registrationHandler)(nil)
 func NewRegistrationHandler(agentContext *context.AgentContext) RegistrationHandler {
 	return &registrationHandler{
 		agentContext: agentContext,
+		fs:           vfs.OSFS,
+		macAddresses: sysinfo.MACAddresses,
 	}
 }
 
 type registrationHandler struct {
 	agentContext *context.AgentContext
+	fs           vfs.FS
+	macAddresses func() ([]string, error)
 }
 
 func (r *registrationHandler) Register() error {
@@ -39,8 +50,15 @@ func (r *registrationHandler) Register() error {
 	if err != nil {
 		return fmt.Errorf("marshalling host public key: %w", err)
 	}
-	hostname, config := r.registrationLoop(pubKey)
+	agentState := loadState(r.agentContext.State)
+	hostname, config := r.registrationLoop(pubKey, &agentState)
 	log.Infof("Successfully registered as '%s'", hostname)
+	// Persist the identity in the work directory, so that it can be re-used if the agent is restarted before finalizing the registration.
+	if err := r.persistIdentity(); err != nil {
+		log.Error(err, "persisting identity in work directory")
+	}
+	agentState.Registration.Registered = true
+	saveState(r.agentContext.State, agentState)
 	r.agentContext.Hostname = hostname
 	r.agentContext.Config = config
 	setPhase(r.agentContext.Client, r.agentContext.Hostname, infrastructurev1.PhaseRegistering) // Note that we set the phase **after* its conclusion, because we do not have any remote ElementalHost to patch before.
@@ -49,8 +67,13 @@ func (r *registrationHandler) Register() error {
 
 func (r *registrationHandler) FinalizeRegistration() error {
 	setPhase(r.agentContext.Client, r.agentContext.Hostname, infrastructurev1.PhaseFinalizingRegistration)
+	agentState := loadState(r.agentContext.State)
+	agentState.Phase = infrastructurev1.PhaseFinalizingRegistration
+	agentState.Registration.Finalize.Attempt(time.Now())
 	err := r.finalize(r.agentContext.Hostname, r.agentContext.ConfigPath, r.agentContext.Config)
 	if err != nil {
+		agentState.Registration.Finalize.Fail(err)
+		saveState(r.agentContext.State, agentState)
 		updateCondition(r.agentContext.Client, r.agentContext.Hostname, clusterv1.Condition{
 			Type:     infrastructurev1.RegistrationReady,
 			Status:   corev1.ConditionFalse,
@@ -63,10 +86,12 @@ func (r *registrationHandler) FinalizeRegistration() error {
 
 	// We try to catch and recover errors here since this is not recoverable once the cli exits with an error.
 	//
-	// If this steps fail and `elemental-agent register` is called again, it will try to register using a new identity,
-	// since the system is not installed yet and the previously registered identity lived in-memory.
+	// If this steps fail and `elemental-agent register` is called again, it will try to resume the registration
+	// using the identity and hostname persisted in the work directory. If they were lost (ex. live system rebooted),
+	// it will try to register using a new identity.
 	//
 	// Therefore we must prevent the entire registration process from failing on recoverable errors (in this case a network issue).
+	retry := backoff.NewBackoff(r.agentContext.Config.Agent)
 	for {
 		if err := updateConditionOrFail(r.agentContext.Client, r.agentContext.Hostname, clusterv1.Condition{
 			Type:     infrastructurev1.RegistrationReady,
@@ -74,12 +99,15 @@ func (r *registrationHandler) FinalizeRegistration() error {
 			Severity: clusterv1.ConditionSeverityInfo,
 		}); err != nil {
 			log.Error(err, "updating RegistrationReady True condition")
-			log.Debugf("Waiting '%s' on update condition error to recover", r.agentContext.Config.Agent.Reconciliation)
-			time.Sleep(r.agentContext.Config.Agent.Reconciliation)
+			log.Debug("Waiting on update condition error to recover")
+			retry.Wait(err)
 			continue
 		}
 		break
 	}
+	agentState.Registration.Finalized = true
+	agentState.Registration.Finalize.Complete(time.Now())
+	saveState(r.agentContext.State, agentState)
 
 	updateCondition(r.agentContext.Client, r.agentContext.Hostname, clusterv1.Condition{
 		Type:     infrastructurev1.InstallationReady,
@@ -116,37 +144,76 @@ func (r *registrationHandler) finalize(hostname string, configPath string, agent
 	return nil
 }
 
+// persistIdentity writes the in-memory identity to the agent work directory.
+func (r *registrationHandler) persistIdentity() error {
+	identityBytes, err := r.agentContext.Identity.Marshal()
+	if err != nil {
+		return fmt.Errorf("marshalling identity: %w", err)
+	}
+	workDir := r.agentContext.Config.Agent.WorkDir
+	if err := utils.CreateDirectory(r.fs, workDir); err != nil {
+		return fmt.Errorf("creating work directory '%s': %w", workDir, err)
+	}
+	privateKeyPath := fmt.Sprintf("%s/%s", workDir, identity.PrivateKeyFile)
+	if err := r.fs.WriteFile(privateKeyPath, identityBytes, os.FileMode(0600)); err != nil {
+		return fmt.Errorf("writing file '%s': %w", privateKeyPath, err)
+	}
+	return nil
+}
+
 // registrationLoop **indefinitely** tries to fetch the remote registration and register a new ElementalHost.
-func (r *registrationHandler) registrationLoop(pubKey []byte) (string, config.Config) {
+// If a hostname was persisted in the agent state, it will be re-used when the ElementalHost already exists.
+func (r *registrationHandler) registrationLoop(pubKey []byte, agentState *state.State) (string, config.Config) {
 	hostnameFormatter := hostname.NewFormatter(r.agentContext.Plugin)
 	var newHostname string
 	var registration *api.RegistrationResponse
 	var err error
-	registrationError := false
+	var registrationError error
+	retry := backoff.NewBackoff(r.agentContext.Config.Agent)
 	for {
 		// Wait for recovery
-		if registrationError {
-			log.Debugf("Waiting '%s' on registration error to recover", r.agentContext.Config.Agent.Reconciliation)
-			time.Sleep(r.agentContext.Config.Agent.Reconciliation)
+		if registrationError != nil {
+			log.Debug("Waiting on registration error to recover")
+			retry.Wait(registrationError)
+			registrationError = nil
 		}
+		agentState.Registration.Register.Attempt(time.Now())
+		saveState(r.agentContext.State, *agentState)
 		// Fetch remote Registration
 		log.Debug("Fetching remote registration")
 		registration, err = r.agentContext.Client.GetRegistration()
 		if err != nil {
 			log.Error(err, "getting remote Registration")
-			registrationError = true
+			agentState.Registration.Register.Fail(err)
+			registrationError = err
 			continue
 		}
+		// Resume a previously started registration
+		if agentState.Hostname != "" {
+			if _, err := r.agentContext.Client.PatchHost(api.HostPatchRequest{}, agentState.Hostname); err == nil {
+				log.Infof("Resuming registration of existing ElementalHost: %s", agentState.Hostname)
+				newHostname = agentState.Hostname
+				break
+			}
+			log.Infof("Could not resume registration of ElementalHost: %s. Starting a new registration.", agentState.Hostname)
+			*agentState = state.State{}
+		}
 		// Pick a new hostname
 		// There is a tiny chance the random hostname generation will collide with existing ones.
 		// It's safer to generate a new one in case of host creation failure.
-		newHostname, err = hostnameFormatter.FormatHostname(registration.Config.Elemental.Agent.Hostname)
-		log.Debugf("Selected hostname: %s", newHostname)
-		if err != nil {
-			log.Error(err, "picking new hostname")
-			registrationError = true
-			continue
+		// Hosts registering with a one-time token must use the reserved hostname instead.
+		if registration.HostName != "" {
+			newHostname = registration.HostName
+		} else {
+			newHostname, err = hostnameFormatter.FormatHostname(registration.Config.Elemental.Agent.Hostname)
+			if err != nil {
+				log.Error(err, "picking new hostname")
+				agentState.Registration.Register.Fail(err)
+				registrationError = err
+				continue
+			}
 		}
+		log.Debugf("Selected hostname: %s", newHostname)
 		// Check if Registration already happened
 		// This can happen if finalizing the registration failed and the agent is started again to re-attempt.
 		if _, err := r.agentContext.Client.PatchHost(api.HostPatchRequest{}, newHostname); err == nil {
@@ -155,18 +222,46 @@ func (r *registrationHandler) registrationLoop(pubKey []byte) (string, config.Co
 		}
 		// Register new Elemental Host
 		log.Debugf("Registering new host: %s", newHostname)
-		if err := r.agentContext.Client.CreateHost(api.HostCreateRequest{
+		createRequest := api.HostCreateRequest{
 			Name:        newHostname,
 			Annotations: registration.HostAnnotations,
 			Labels:      registration.HostLabels,
 			PubKey:      string(pubKey),
-		}); err != nil {
+		}
+		r.setHostIdentity(&createRequest, registration.Config.Elemental.Agent.NoSMBIOS)
+		if err := r.agentContext.Client.CreateHost(createRequest); err != nil {
 			log.Error(err, "registering new ElementalHost")
-			registrationError = true
+			agentState.Registration.Register.Fail(err)
+			registrationError = err
 			continue
 		}
 		break
 	}
+	agentState.Hostname = newHostname
+	agentState.Phase = infrastructurev1.PhaseRegistering
+	agentState.Registration.Register.Complete(time.Now())
 
 	return newHostname, config.FromAPI(*registration)
 }
+
+// setHostIdentity adds the SMBIOS serial number and the MAC addresses of this host to the create request.
+// They are used to automatically approve expected hosts, when the ElementalRegistration requires approval.
+func (r *registrationHandler) setHostIdentity(createRequest *api.HostCreateRequest, noSMBIOS bool) {
+	if !noSMBIOS {
+		serialNumber, err := sysinfo.SerialNumber(r.fs)
+		switch {
+		case errors.Is(err, sysinfo.ErrNoSerialNumber):
+			log.Debug("Serial number not available")
+		case err != nil:
+			log.Error(err, "Could not read serial number")
+		default:
+			createRequest.SerialNumber = serialNumber
+		}
+	}
+	macAddresses, err := r.macAddresses()
+	if err != nil {
+		log.Error(err, "Could not list MAC addresses")
+		return
+	}
+	createRequest.MACAddresses = macAddresses
+}
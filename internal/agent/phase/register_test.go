@@ -10,9 +10,12 @@ import (
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/client"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/config"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/identity"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin"
+	"github.com/twpayne/go-vfs/v4"
+	"github.com/twpayne/go-vfs/v4/vfst"
 	gomock "go.uber.org/mock/gomock"
 	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
@@ -27,9 +30,15 @@ var _ = Describe("registration handler", Label("cli", "phases", "registration"),
 	var id *identity.MockIdentity
 	var handler RegistrationHandler
 	var agentContext *context.AgentContext
+	var fs vfs.FS
+	var fsCleanup func()
+	var err error
 
 	BeforeEach(func() {
 		mockCtrl = gomock.NewController(GinkgoT())
+		fs, fsCleanup, err = vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
 		mClient = client.NewMockClient(mockCtrl)
 		plugin = osplugin.NewMockPlugin(mockCtrl)
 		id = identity.NewMockIdentity(mockCtrl)
@@ -40,17 +49,26 @@ var _ = Describe("registration handler", Label("cli", "phases", "registration"),
 			Config:     ConfigFixture,
 			ConfigPath: ConfigPathFixture,
 			Hostname:   HostResponseFixture.Name,
+			State:      state.NewManager(fs, ConfigFixture.Agent.WorkDir),
+		}
+		handler = &registrationHandler{
+			agentContext: agentContext,
+			fs:           fs,
+			macAddresses: func() ([]string, error) { return []string{"52:54:00:12:34:56"}, nil },
 		}
-		handler = NewRegistrationHandler(agentContext)
 	})
 	When("registering", func() {
 		wantPubKey := []byte("just a test pubkey")
+		wantIdentity := []byte("just a test identity")
+		wantIdentityFilePath := fmt.Sprintf("%s/%s", ConfigFixture.Agent.WorkDir, identity.PrivateKeyFile)
 
 		wantRequest := api.HostCreateRequest{
 			Name:        HostResponseFixture.Name,
 			Annotations: RegistrationFixture.HostAnnotations,
 			Labels:      RegistrationFixture.HostLabels,
 			PubKey:      string(wantPubKey),
+			// Serial number is not available on the test filesystem
+			MACAddresses: []string{"52:54:00:12:34:56"},
 		}
 
 		It("should fail on pubkey marshalling error", func() {
@@ -77,6 +95,8 @@ var _ = Describe("registration handler", Label("cli", "phases", "registration"),
 				plugin.EXPECT().GetHostname().Return("host", nil),
 				mClient.EXPECT().PatchHost(api.HostPatchRequest{}, HostResponseFixture.Name).Return(nil, errors.New("test not found")),
 				mClient.EXPECT().CreateHost(wantRequest).Return(nil),
+				// Expect identity to be persisted in the work directory
+				id.EXPECT().Marshal().Return(wantIdentity, nil),
 				// Expect phase to be updated
 				mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhaseRegistering)}, HostResponseFixture.Name),
 			)
@@ -85,16 +105,90 @@ var _ = Describe("registration handler", Label("cli", "phases", "registration"),
 			Expect(err).ToNot(HaveOccurred())
 			Expect(agentContext.Hostname).To(Equal(HostResponseFixture.Name))
 			Expect(agentContext.Config).To(Equal(config.FromAPI(RegistrationFixture)))
+			// Expect identity and state to be persisted
+			identityBytes, err := fs.ReadFile(wantIdentityFilePath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(identityBytes).To(Equal(wantIdentity))
+			agentState, err := agentContext.State.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(agentState.Hostname).To(Equal(HostResponseFixture.Name))
+			Expect(agentState.Phase).To(Equal(infrastructurev1.PhaseRegistering))
+			Expect(agentState.Registration.Registered).To(BeTrue())
+			Expect(agentState.Registration.Register.Attempts).To(Equal(3))
+			Expect(agentState.Registration.Register.LastError).To(BeEmpty())
+			Expect(agentState.Registration.Register.Completed()).To(BeTrue())
+			Expect(agentState.Registration.Finalize.Attempts).To(BeZero(), "Finalizing registration is tracked separately")
 		})
-		It("should not create ElementalHost twice", func() {
-			wantPubKey := []byte("just a test pubkey")
+		It("should resume registration from persisted state", func() {
+			Expect(agentContext.State.Save(state.State{
+				Hostname:     "persisted-hostname",
+				Registration: state.RegistrationState{Registered: true},
+			})).To(Succeed())
+			gomock.InOrder(
+				id.EXPECT().MarshalPublic().Return(wantPubKey, nil),
+				mClient.EXPECT().GetRegistration().Return(&RegistrationFixture, nil),
+				// No errors on patch request, means the persisted host exists and matches our current identity
+				mClient.EXPECT().PatchHost(api.HostPatchRequest{}, "persisted-hostname").Return(nil, nil),
+				id.EXPECT().Marshal().Return(wantIdentity, nil),
+				mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhaseRegistering)}, "persisted-hostname"),
+			)
+
+			err := handler.Register()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(agentContext.Hostname).To(Equal("persisted-hostname"))
+			Expect(agentContext.Config).To(Equal(config.FromAPI(RegistrationFixture)))
+		})
+		It("should register a new host if persisted one can not be resumed", func() {
+			Expect(agentContext.State.Save(state.State{
+				Hostname:     "persisted-hostname",
+				Registration: state.RegistrationState{Registered: true},
+			})).To(Succeed())
+			gomock.InOrder(
+				id.EXPECT().MarshalPublic().Return(wantPubKey, nil),
+				mClient.EXPECT().GetRegistration().Return(&RegistrationFixture, nil),
+				// Persisted host could not be patched (ex. identity was lost)
+				mClient.EXPECT().PatchHost(api.HostPatchRequest{}, "persisted-hostname").Return(nil, errors.New("test unauthorized")),
+				plugin.EXPECT().GetHostname().Return("host", nil),
+				mClient.EXPECT().PatchHost(api.HostPatchRequest{}, HostResponseFixture.Name).Return(nil, errors.New("test not found")),
+				mClient.EXPECT().CreateHost(wantRequest).Return(nil),
+				id.EXPECT().Marshal().Return(wantIdentity, nil),
+				mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhaseRegistering)}, HostResponseFixture.Name),
+			)
+
+			err := handler.Register()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(agentContext.Hostname).To(Equal(HostResponseFixture.Name))
+			agentState, err := agentContext.State.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(agentState.Hostname).To(Equal(HostResponseFixture.Name))
+		})
+		It("should register with the reserved hostname", func() {
+			reservedRegistration := RegistrationFixture
+			reservedRegistration.HostName = "reserved-host"
+			reservedRequest := wantRequest
+			reservedRequest.Name = "reserved-host"
+			gomock.InOrder(
+				id.EXPECT().MarshalPublic().Return(wantPubKey, nil),
+				// No hostname is formatted
+				mClient.EXPECT().GetRegistration().Return(&reservedRegistration, nil),
+				mClient.EXPECT().PatchHost(api.HostPatchRequest{}, "reserved-host").Return(nil, errors.New("test not found")),
+				mClient.EXPECT().CreateHost(reservedRequest).Return(nil),
+				id.EXPECT().Marshal().Return(wantIdentity, nil),
+				mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhaseRegistering)}, "reserved-host"),
+			)
 
+			err := handler.Register()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(agentContext.Hostname).To(Equal("reserved-host"))
+		})
+		It("should not create ElementalHost twice", func() {
 			gomock.InOrder(
 				id.EXPECT().MarshalPublic().Return(wantPubKey, nil),
 				mClient.EXPECT().GetRegistration().Return(&RegistrationFixture, nil),
 				plugin.EXPECT().GetHostname().Return("host", nil),
 				// No errors on patch request, means this host exists already and matches our current identity (due to authentication success)
 				mClient.EXPECT().PatchHost(api.HostPatchRequest{}, HostResponseFixture.Name).Return(nil, nil),
+				id.EXPECT().Marshal().Return(wantIdentity, nil),
 				// Expect phase to be updated
 				mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhaseRegistering)}, HostResponseFixture.Name),
 			)
@@ -143,6 +237,11 @@ var _ = Describe("registration handler", Label("cli", "phases", "registration"),
 			)
 
 			Expect(handler.FinalizeRegistration()).To(Succeed())
+			agentState, err := agentContext.State.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(agentState.Registration.Finalized).To(BeTrue())
+			Expect(agentState.Registration.Finalize.Completed()).To(BeTrue())
+			Expect(agentState.Registration.Finalize.Attempts).To(Equal(1))
 		})
 		It("should fail on finalizing registration error", func() {
 			wantErr := errors.New("test finalizing registration error")
@@ -166,6 +265,10 @@ var _ = Describe("registration handler", Label("cli", "phases", "registration"),
 			err := handler.FinalizeRegistration()
 			Expect(err).To(HaveOccurred())
 			Expect(errors.Is(err, wantErr)).To(BeTrue())
+			agentState, err := agentContext.State.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(agentState.Registration.Finalized).To(BeFalse())
+			Expect(agentState.Registration.Finalize.LastError).To(ContainSubstring(wantErr.Error()))
 		})
 		It("should recover from update Ready condition errors", func() {
 			wantMarshalledIdentity := []byte("test identity")
@@ -7,6 +7,7 @@ import (
 
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/backoff"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
@@ -17,6 +18,8 @@ import (
 
 type ResetHandler interface {
 	Reset()
+	// ResetUninstalled resets a host that was never installed, thus still running from the live media.
+	ResetUninstalled()
 	TriggerReset() error
 }
 
@@ -60,15 +63,32 @@ func (r *resetHandler) Reset() {
 	r.resetLoop()
 }
 
-// installLoop **indefinitely** tries to fetch the remote registration and reset the ElementalHost.
+// ResetUninstalled skips the OSPlugin reset, since there is no installed system to reset.
+// The remote ElementalHost is only marked for deletion and reported as reset.
+func (r *resetHandler) ResetUninstalled() {
+	agentState := loadState(r.agentContext.State)
+	agentState.Reset.Reset = true
+	saveState(r.agentContext.State, agentState)
+	r.Reset()
+}
+
+// resetLoop **indefinitely** tries to fetch the remote registration and reset the ElementalHost.
 func (r *resetHandler) resetLoop() {
+	agentState := loadState(r.agentContext.State)
+	agentState.Phase = infrastructurev1.PhaseResetting
+	alreadyReset := agentState.Reset.Reset
+	if alreadyReset {
+		log.Info("Resuming reset, host was already reset")
+	}
 	var resetError error
-	alreadyReset := false
+	retry := backoff.NewBackoff(r.agentContext.Config.Agent)
 	for {
 		// Wait for recovery (end user may fix the remote reset instructions meanwhile)
 		if resetError != nil {
 			// Log error
 			log.Error(resetError, "resetting")
+			agentState.Reset.Fail(resetError)
+			saveState(r.agentContext.State, agentState)
 			// Attempt to report failed condition on management server
 			updateCondition(r.agentContext.Client, r.agentContext.Hostname, clusterv1.Condition{
 				Type:     infrastructurev1.ResetReady,
@@ -77,11 +97,13 @@ func (r *resetHandler) resetLoop() {
 				Reason:   infrastructurev1.ResetFailedReason,
 				Message:  resetError.Error(),
 			})
+			log.Debug("Waiting on reset error for reset instructions to mutate")
+			retry.Wait(resetError)
 			// Clear error for next attempt
 			resetError = nil
-			log.Debugf("Waiting '%s' on reset error for reset instructions to mutate", r.agentContext.Config.Agent.Reconciliation)
-			time.Sleep(r.agentContext.Config.Agent.Reconciliation)
 		}
+		agentState.Reset.Attempt(time.Now())
+		saveState(r.agentContext.State, agentState)
 		// Mark ElementalHost for deletion
 		// Repeat in case of failures. May be exploited server side to track repeated attempts.
 		log.Debugf("Marking ElementalHost for deletion: %s", r.agentContext.Hostname)
@@ -109,6 +131,8 @@ func (r *resetHandler) resetLoop() {
 				continue
 			}
 			alreadyReset = true
+			agentState.Reset.Reset = true
+			saveState(r.agentContext.State, agentState)
 		}
 		// Report reset success
 		log.Debug("Patching ElementalHost as reset")
@@ -121,6 +145,8 @@ func (r *resetHandler) resetLoop() {
 			resetError = fmt.Errorf("patching host with reset successful: %w", err)
 			continue
 		}
+		agentState.Reset.Complete(time.Now())
+		saveState(r.agentContext.State, agentState)
 		break
 	}
 }
@@ -9,8 +9,11 @@ import (
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/client"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin"
+	"github.com/twpayne/go-vfs/v4"
+	"github.com/twpayne/go-vfs/v4/vfst"
 	gomock "go.uber.org/mock/gomock"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
@@ -23,9 +26,15 @@ var _ = Describe("reset handler", Label("cli", "phases", "reset"), func() {
 	var plugin *osplugin.MockPlugin
 	var handler ResetHandler
 	var agentContext context.AgentContext
+	var fs vfs.FS
+	var fsCleanup func()
+	var err error
 
 	BeforeEach(func() {
 		mockCtrl = gomock.NewController(GinkgoT())
+		fs, fsCleanup, err = vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
 		mClient = client.NewMockClient(mockCtrl)
 		plugin = osplugin.NewMockPlugin(mockCtrl)
 		agentContext = context.AgentContext{
@@ -34,6 +43,7 @@ var _ = Describe("reset handler", Label("cli", "phases", "reset"), func() {
 			Config:     ConfigFixture,
 			ConfigPath: ConfigPathFixture,
 			Hostname:   HostResponseFixture.Name,
+			State:      state.NewManager(fs, ConfigFixture.Agent.WorkDir),
 		}
 		handler = NewResetHandler(agentContext)
 	})
@@ -164,6 +174,45 @@ var _ = Describe("reset handler", Label("cli", "phases", "reset"), func() {
 			)
 
 			handler.Reset()
+
+			agentState, err := agentContext.State.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(agentState.Phase).To(Equal(infrastructurev1.PhaseResetting))
+			Expect(agentState.Reset.Reset).To(BeTrue())
+			Expect(agentState.Reset.Completed()).To(BeTrue())
+		})
+		It("should not reset again if already reset", func() {
+			Expect(agentContext.State.Save(state.State{
+				Reset: state.ResetState{Reset: true},
+			})).To(Succeed())
+			gomock.InOrder(
+				mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhaseResetting)}, HostResponseFixture.Name),
+				mClient.EXPECT().DeleteHost(HostResponseFixture.Name).Return(nil),
+				// Expect the plugin reset not to be invoked again
+				mClient.EXPECT().PatchHost(gomock.Any(), HostResponseFixture.Name).Return(&HostResponseFixture, nil).Do(func(patch api.HostPatchRequest, _ string) {
+					Expect(patch.Reset).ToNot(BeNil())
+					Expect(*patch.Reset).To(BeTrue())
+				}),
+			)
+
+			handler.Reset()
+		})
+		It("should not reset a host that was never installed", func() {
+			gomock.InOrder(
+				mClient.EXPECT().PatchHost(api.HostPatchRequest{Phase: ptr.To(infrastructurev1.PhaseResetting)}, HostResponseFixture.Name),
+				mClient.EXPECT().DeleteHost(HostResponseFixture.Name).Return(nil),
+				// Expect the plugin reset not to be invoked
+				mClient.EXPECT().PatchHost(gomock.Any(), HostResponseFixture.Name).Return(&HostResponseFixture, nil).Do(func(patch api.HostPatchRequest, _ string) {
+					Expect(patch.Reset).ToNot(BeNil())
+					Expect(*patch.Reset).To(BeTrue())
+				}),
+			)
+
+			handler.ResetUninstalled()
+
+			agentState, err := agentContext.State.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(agentState.Reset.Completed()).To(BeTrue())
 		})
 	})
 })
@@ -0,0 +1,66 @@
+package phase
+
+import (
+	"fmt"
+
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin"
+)
+
+// agentLogFile is the support bundle file containing the most recent elemental-agent logs.
+const agentLogFile = "elemental-agent.log"
+
+type SupportBundleHandler interface {
+	Upload(reason string) error
+}
+
+var _ SupportBundleHandler = (*supportBundleHandler)(nil)
+
+func NewSupportBundleHandler(agentContext context.AgentContext) SupportBundleHandler {
+	return &supportBundleHandler{
+		agentContext: agentContext,
+	}
+}
+
+type supportBundleHandler struct {
+	agentContext context.AgentContext
+}
+
+// Upload collects the elemental-agent logs, and the OS plugin diagnostic files if supported, and uploads them to the Elemental API.
+// Files are truncated to their most recent content, so that the support bundle does not exceed the maximum size.
+func (s *supportBundleHandler) Upload(reason string) error {
+	files := map[string][]byte{}
+	if collector, ok := s.agentContext.Plugin.(osplugin.SupportBundleCollector); ok {
+		pluginFiles, err := collector.CollectSupportFiles()
+		if err != nil {
+			log.Error(err, "Could not collect OS plugin support files")
+		}
+		for name, content := range pluginFiles {
+			files[name] = content
+		}
+	}
+	files[agentLogFile] = log.History()
+	truncateSupportFiles(files, api.MaxSupportBundleSize)
+	if err := s.agentContext.Client.UploadSupportBundle(api.SupportBundleRequest{
+		Reason: reason,
+		Files:  files,
+	}, s.agentContext.Hostname); err != nil {
+		return fmt.Errorf("uploading support bundle: %w", err)
+	}
+	return nil
+}
+
+// truncateSupportFiles keeps the tail of each file, sharing the maximum size equally among them.
+func truncateSupportFiles(files map[string][]byte, maxSize int) {
+	if len(files) == 0 {
+		return
+	}
+	maxFileSize := maxSize / len(files)
+	for name, content := range files {
+		if len(content) > maxFileSize {
+			files[name] = content[len(content)-maxFileSize:]
+		}
+	}
+}
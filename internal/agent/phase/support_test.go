@@ -0,0 +1,80 @@
+package phase
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/client"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// supportPlugin is an OS plugin implementing the optional SupportBundleCollector interface.
+type supportPlugin struct {
+	*osplugin.MockPlugin
+	*osplugin.MockSupportBundleCollector
+}
+
+var _ = Describe("support bundle handler", Label("cli", "phases", "support"), func() {
+	var mockCtrl *gomock.Controller
+	var mClient *client.MockClient
+	var plugin *osplugin.MockPlugin
+	var collector *osplugin.MockSupportBundleCollector
+	var agentContext context.AgentContext
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mClient = client.NewMockClient(mockCtrl)
+		plugin = osplugin.NewMockPlugin(mockCtrl)
+		collector = osplugin.NewMockSupportBundleCollector(mockCtrl)
+		agentContext = context.AgentContext{
+			Plugin:   plugin,
+			Client:   mClient,
+			Config:   ConfigFixture,
+			Hostname: HostResponseFixture.Name,
+		}
+	})
+	It("should upload the agent logs", func() {
+		mClient.EXPECT().UploadSupportBundle(gomock.Any(), HostResponseFixture.Name).Do(func(bundle api.SupportBundleRequest, _ string) {
+			Expect(bundle.Reason).Should(Equal("test reason"))
+			Expect(bundle.Files).Should(HaveLen(1))
+			Expect(bundle.Files).Should(HaveKey(agentLogFile))
+		})
+		Expect(NewSupportBundleHandler(agentContext).Upload("test reason")).Should(Succeed())
+	})
+	It("should include the OS plugin support files", func() {
+		agentContext.Plugin = supportPlugin{MockPlugin: plugin, MockSupportBundleCollector: collector}
+		gomock.InOrder(
+			collector.EXPECT().CollectSupportFiles().Return(map[string][]byte{"journal.log": []byte("test journal")}, nil),
+			mClient.EXPECT().UploadSupportBundle(gomock.Any(), HostResponseFixture.Name).Do(func(bundle api.SupportBundleRequest, _ string) {
+				Expect(bundle.Files).Should(HaveLen(2))
+				Expect(bundle.Files).Should(HaveKey(agentLogFile))
+				Expect(string(bundle.Files["journal.log"])).Should(Equal("test journal"))
+			}),
+		)
+		Expect(NewSupportBundleHandler(agentContext).Upload("test reason")).Should(Succeed())
+	})
+	It("should keep the most recent content within the maximum size", func() {
+		agentContext.Plugin = supportPlugin{MockPlugin: plugin, MockSupportBundleCollector: collector}
+		largeFile := append(bytes.Repeat([]byte("a"), api.MaxSupportBundleSize), []byte("last line")...)
+		gomock.InOrder(
+			collector.EXPECT().CollectSupportFiles().Return(map[string][]byte{"large.log": largeFile}, nil),
+			mClient.EXPECT().UploadSupportBundle(gomock.Any(), HostResponseFixture.Name).Do(func(bundle api.SupportBundleRequest, _ string) {
+				size := 0
+				for _, content := range bundle.Files {
+					size += len(content)
+				}
+				Expect(size).Should(BeNumerically("<=", api.MaxSupportBundleSize))
+				Expect(string(bundle.Files["large.log"])).Should(HaveSuffix("last line"))
+			}),
+		)
+		Expect(NewSupportBundleHandler(agentContext).Upload("test reason")).Should(Succeed())
+	})
+	It("should return error if upload fails", func() {
+		mClient.EXPECT().UploadSupportBundle(gomock.Any(), HostResponseFixture.Name).Return(errors.New("test upload error"))
+		Expect(NewSupportBundleHandler(agentContext).Upload("test reason")).ShouldNot(Succeed())
+	})
+})
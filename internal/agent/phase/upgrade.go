@@ -3,9 +3,11 @@ package phase
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -28,7 +30,30 @@ type osVersionHandler struct {
 	agentContext context.AgentContext
 }
 
+// Reconcile records the OS version reconciliation progress in the agent state.
+// Only failures and reboots are persisted, since a successful reconciliation is normally a no-op.
 func (o *osVersionHandler) Reconcile(osVersionManagement map[string]runtime.RawExtension, needsInplaceUpdate bool) (infrastructurev1.PostAction, error) {
+	post, err := o.reconcile(osVersionManagement, needsInplaceUpdate)
+	agentState := loadState(o.agentContext.State)
+	switch {
+	case err != nil:
+		agentState.OSVersion.Attempt(time.Now())
+		agentState.OSVersion.Fail(err)
+	case post.Reboot:
+		agentState.Phase = infrastructurev1.PhaseOSVersionReconcile
+		agentState.OSVersion.Attempt(time.Now())
+		agentState.OSVersion.LastError = ""
+	case agentState.OSVersion.Attempts > 0:
+		// Reconciliation was previously in progress, mark it as completed and reset the counters.
+		agentState.OSVersion.Progress = state.Progress{CompletedAt: time.Now()}
+	default:
+		return post, nil
+	}
+	saveState(o.agentContext.State, agentState)
+	return post, err
+}
+
+func (o *osVersionHandler) reconcile(osVersionManagement map[string]runtime.RawExtension, needsInplaceUpdate bool) (infrastructurev1.PostAction, error) {
 	post := infrastructurev1.PostAction{}
 	// Serialize input to JSON
 	bytes, err := json.Marshal(osVersionManagement)
@@ -8,8 +8,11 @@ import (
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/client"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/context"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin"
+	"github.com/twpayne/go-vfs/v4"
+	"github.com/twpayne/go-vfs/v4/vfst"
 	gomock "go.uber.org/mock/gomock"
 	corev1 "k8s.io/api/core/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -21,9 +24,15 @@ var _ = Describe("upgrade handler", Label("cli", "phases", "upgrade"), func() {
 	var plugin *osplugin.MockPlugin
 	var handler OSVersionHandler
 	var agentContext context.AgentContext
+	var fs vfs.FS
+	var fsCleanup func()
+	var err error
 
 	BeforeEach(func() {
 		mockCtrl = gomock.NewController(GinkgoT())
+		fs, fsCleanup, err = vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
 		mClient = client.NewMockClient(mockCtrl)
 		plugin = osplugin.NewMockPlugin(mockCtrl)
 		agentContext = context.AgentContext{
@@ -32,6 +41,7 @@ var _ = Describe("upgrade handler", Label("cli", "phases", "upgrade"), func() {
 			Config:     ConfigFixture,
 			ConfigPath: ConfigPathFixture,
 			Hostname:   HostResponseFixture.Name,
+			State:      state.NewManager(fs, ConfigFixture.Agent.WorkDir),
 		}
 		handler = NewOSVersionHandler(agentContext)
 	})
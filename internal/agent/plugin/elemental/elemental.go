@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"errors"
@@ -32,6 +33,8 @@ const (
 	bootstrapPath         = "/oem/bootstrap-cloud-config.yaml"
 	liveModeFile          = "/run/elemental/live_mode"
 	bootstrapSentinelPath = "/run/cluster-api/bootstrap-success.complete"
+	// journalLines is the number of journal lines included in support bundles.
+	journalLines = 500
 )
 
 var (
@@ -46,6 +49,8 @@ type OSVersionManagement struct {
 }
 
 var _ osplugin.Plugin = (*ElementalPlugin)(nil)
+var _ osplugin.SupportBundleCollector = (*ElementalPlugin)(nil)
+var _ osplugin.InterruptibleInstaller = (*ElementalPlugin)(nil)
 
 type ElementalPlugin struct {
 	fs          vfs.FS
@@ -171,6 +176,11 @@ func (p *ElementalPlugin) formatSetFileName(path string) string {
 }
 
 func (p *ElementalPlugin) Install(input []byte) error {
+	return p.InstallContext(context.Background(), input)
+}
+
+// InstallContext installs Elemental, killing the elemental install if the context is done before completion.
+func (p *ElementalPlugin) InstallContext(ctx context.Context, input []byte) error {
 	log.Debug("Installing Elemental")
 	// Do not install the system twice.
 	// This is the reset scenario where the machine is repurposed instead of reprovisioned from scratch.
@@ -191,7 +201,7 @@ func (p *ElementalPlugin) Install(input []byte) error {
 	install.ConfigURLs = append(install.ConfigURLs, hostnameInitPath, identityInitPath, agentConfigInitPath, cloudConfigInitPath)
 	// Install
 	log.Info("Running elemental install")
-	if err := p.cliRunner.Install(install); err != nil {
+	if err := p.cliRunner.Install(ctx, install); err != nil {
 		return fmt.Errorf("invoking elemental install: %w", err)
 	}
 	return nil
@@ -457,6 +467,25 @@ func (p *ElementalPlugin) Reboot() error {
 	return nil
 }
 
+// CollectSupportFiles returns the last elemental CLI output, the elemental state, and the journal tail.
+// Files that can not be collected contain the collection error instead, so that the others are still included.
+func (p *ElementalPlugin) CollectSupportFiles() (map[string][]byte, error) {
+	return map[string][]byte{
+		"elemental-cli.log":    p.cliRunner.Output(),
+		"elemental-state.yaml": p.commandOutputOrError("elemental state"),
+		"journal.log":          p.commandOutputOrError(fmt.Sprintf("journalctl --no-pager --lines %d", journalLines)),
+	}, nil
+}
+
+func (p *ElementalPlugin) commandOutputOrError(command string) []byte {
+	output, err := p.cmdRunner.CommandOutput(command)
+	if err != nil {
+		log.Errorf(err, "Could not collect '%s' output", command)
+		return []byte(fmt.Sprintf("Could not collect '%s' output: %s\n", command, err.Error()))
+	}
+	return output
+}
+
 func (p *ElementalPlugin) isRunningInLiveMode() (bool, error) {
 	_, err := p.fs.Stat(liveModeFile)
 	if err == nil {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"testing"
@@ -110,7 +111,7 @@ var _ = Describe("Elemental Plugin", Label("agent", "plugin", "elemental"), func
 	})
 	It("should only install when running in live mode", func() {
 		Expect(plugin.Install([]byte("{}"))).Should(Succeed())
-		cliRunner.EXPECT().Install(gomock.Any()).Times(0)
+		cliRunner.EXPECT().Install(gomock.Any(), gomock.Any()).Times(0)
 	})
 	It("should install invoking elemental install", func() {
 		Expect(vfs.MkdirAll(fs, "/run/elemental", os.ModePerm)).Should(Succeed())
@@ -119,7 +120,7 @@ var _ = Describe("Elemental Plugin", Label("agent", "plugin", "elemental"), func
 		installWithSetFiles := install
 		installWithSetFiles.ConfigURLs = append(installWithSetFiles.ConfigURLs, hostnameInitPath, identityInitPath, agentConfigInitPath, cloudConfigInitPath)
 		Expect(err).ToNot(HaveOccurred())
-		cliRunner.EXPECT().Install(installWithSetFiles).Return(nil)
+		cliRunner.EXPECT().Install(gomock.Any(), installWithSetFiles).Return(nil)
 		Expect(plugin.Install(installJSON)).Should(Succeed())
 	})
 	It("should trigger reset by creating reset cloud init-file and scheduling recovery reboot", func() {
@@ -152,6 +153,18 @@ var _ = Describe("Elemental Plugin", Label("agent", "plugin", "elemental"), func
 		hostManager.EXPECT().Reboot().Return(nil)
 		Expect(plugin.Reboot()).Should(Succeed())
 	})
+	It("should collect support files", func() {
+		cliRunner.EXPECT().Output().Return([]byte("test cli output\n"))
+		cmdRunner.EXPECT().CommandOutput("elemental state").Return([]byte("test state\n"), nil)
+		cmdRunner.EXPECT().CommandOutput(fmt.Sprintf("journalctl --no-pager --lines %d", journalLines)).Return(nil, errors.New("test journal error"))
+		collector, ok := plugin.(osplugin.SupportBundleCollector)
+		Expect(ok).To(BeTrue(), "plugin must implement the SupportBundleCollector interface")
+		files, err := collector.CollectSupportFiles()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(files["elemental-cli.log"])).To(Equal("test cli output\n"))
+		Expect(string(files["elemental-state.yaml"])).To(Equal("test state\n"))
+		Expect(string(files["journal.log"])).To(ContainSubstring("test journal error"))
+	})
 	It("should bootstrap cloud-init", func() {
 		capiBootstrap, err := os.ReadFile("_testdata/capi-bootstrap.yaml")
 		Expect(err).ToNot(HaveOccurred())
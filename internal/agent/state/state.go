@@ -0,0 +1,212 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/twpayne/go-vfs/v4"
+	"gopkg.in/yaml.v3"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/utils"
+)
+
+const (
+	// StateFile is the name of the state file within the agent work directory.
+	StateFile = "state.yaml"
+)
+
+// State represents the local agent progress, persisted across restarts.
+type State struct {
+	// Hostname is the name of the registered ElementalHost.
+	Hostname string `yaml:"hostname,omitempty"`
+	// Phase is the last phase the agent started handling.
+	Phase infrastructurev1.HostPhase `yaml:"phase,omitempty"`
+	// UpdatedAt is the last time the state was persisted.
+	UpdatedAt time.Time `yaml:"updatedAt,omitempty"`
+	// Registration progress.
+	Registration RegistrationState `yaml:"registration,omitempty"`
+	// Installation progress.
+	Installation InstallationState `yaml:"installation,omitempty"`
+	// Bootstrap progress.
+	Bootstrap BootstrapState `yaml:"bootstrap,omitempty"`
+	// Reset progress.
+	Reset ResetState `yaml:"reset,omitempty"`
+	// OSVersion reconciliation progress.
+	OSVersion OSVersionState `yaml:"osVersion,omitempty"`
+	// AgentUpdate tracks the elemental-agent binary updates.
+	AgentUpdate AgentUpdateState `yaml:"agentUpdate,omitempty"`
+}
+
+// RegistrationState tracks the 'register' command steps.
+// Registering and finalizing the registration are retried independently, so each step tracks its own progress.
+type RegistrationState struct {
+	// Register is the progress of the ElementalHost registration.
+	Register Progress `yaml:"register,omitempty"`
+	// Finalize is the progress of the registration finalization.
+	Finalize Progress `yaml:"finalize,omitempty"`
+	// Registered is true once the ElementalHost was created and the identity persisted.
+	Registered bool `yaml:"registered,omitempty"`
+	// Finalized is true once hostname, config, and identity were installed.
+	Finalized bool `yaml:"finalized,omitempty"`
+}
+
+// InstallationState tracks the 'install' command steps.
+type InstallationState struct {
+	Progress `yaml:",inline"`
+	// CloudConfigApplied is true once the registration cloud-config was installed.
+	CloudConfigApplied bool `yaml:"cloudConfigApplied,omitempty"`
+	// Installed is true once the OSPlugin installed the system.
+	Installed bool `yaml:"installed,omitempty"`
+}
+
+// BootstrapState tracks the bootstrap steps.
+type BootstrapState struct {
+	Progress `yaml:",inline"`
+	// Applied is true once the bootstrap config was applied and the system is waiting for reboot.
+	Applied bool `yaml:"applied,omitempty"`
+}
+
+// ResetState tracks the 'reset' command steps.
+type ResetState struct {
+	Progress `yaml:",inline"`
+	// Reset is true once the OSPlugin reset the system.
+	Reset bool `yaml:"reset,omitempty"`
+}
+
+// AgentUpdateState tracks the elemental-agent binary updates.
+type AgentUpdateState struct {
+	// Version of the installed elemental-agent update, if any.
+	Version string `yaml:"version,omitempty"`
+	// Pending is true until the updated elemental-agent successfully reaches the Elemental API.
+	// An updated elemental-agent exiting while pending is rolled back.
+	Pending bool `yaml:"pending,omitempty"`
+	// OriginalPath is the elemental-agent binary shipped with the OS, that is restored on rollback.
+	OriginalPath string `yaml:"originalPath,omitempty"`
+	// OriginalVersion is the version of the elemental-agent binary shipped with the OS, when the update was installed.
+	// The update is discarded if the OS ships a different binary, for example after an OS upgrade.
+	OriginalVersion string `yaml:"originalVersion,omitempty"`
+	// FailedVersion is the last version that failed to be updated to. It is not attempted again.
+	FailedVersion string `yaml:"failedVersion,omitempty"`
+}
+
+// OSVersionState tracks the OS version reconciliation.
+type OSVersionState struct {
+	Progress `yaml:",inline"`
+}
+
+// Progress contains the attempt counters and timestamps common to all phases.
+type Progress struct {
+	// Attempts is the number of attempts since the phase started.
+	Attempts int `yaml:"attempts,omitempty"`
+	// StartedAt is the time the phase started.
+	StartedAt time.Time `yaml:"startedAt,omitempty"`
+	// LastAttemptAt is the time of the last attempt.
+	LastAttemptAt time.Time `yaml:"lastAttemptAt,omitempty"`
+	// CompletedAt is the time the phase completed successfully.
+	CompletedAt time.Time `yaml:"completedAt,omitempty"`
+	// LastError is the error message of the last failed attempt, if any.
+	LastError string `yaml:"lastError,omitempty"`
+}
+
+// Completed returns true if the phase completed successfully.
+func (p *Progress) Completed() bool {
+	return !p.CompletedAt.IsZero()
+}
+
+// Attempt records a new attempt.
+func (p *Progress) Attempt(now time.Time) {
+	if p.StartedAt.IsZero() {
+		p.StartedAt = now
+	}
+	p.Attempts++
+	p.LastAttemptAt = now
+}
+
+// Fail records the failure of the last attempt.
+func (p *Progress) Fail(err error) {
+	p.LastError = err.Error()
+}
+
+// Complete records the successful completion of the phase.
+func (p *Progress) Complete(now time.Time) {
+	p.CompletedAt = now
+	p.LastError = ""
+}
+
+// Manager loads and persists the agent State.
+type Manager interface {
+	// Load returns the persisted State, or an empty one if none was persisted yet.
+	Load() (State, error)
+	// Save atomically persists the State.
+	Save(State) error
+}
+
+var _ Manager = (*manager)(nil)
+
+type manager struct {
+	workDir string
+	fs      vfs.FS
+}
+
+func NewManager(fs vfs.FS, workDir string) Manager {
+	return &manager{
+		workDir: workDir,
+		fs:      fs,
+	}
+}
+
+func (m *manager) Load() (State, error) {
+	state := State{}
+	path := filepath.Join(m.workDir, StateFile)
+	log.Debugf("Loading state from file: %s", path)
+	bytes, err := m.fs.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Debug("State file does not exist, starting from empty state")
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("reading '%s': %w", path, err)
+	}
+	if err := yaml.Unmarshal(bytes, &state); err != nil {
+		return state, fmt.Errorf("unmarshalling state: %w", err)
+	}
+	return state, nil
+}
+
+func (m *manager) Save(state State) error {
+	state.UpdatedAt = time.Now().UTC()
+	bytes, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshalling state: %w", err)
+	}
+	if err := utils.CreateDirectory(m.fs, m.workDir); err != nil {
+		return fmt.Errorf("creating work directory: %w", err)
+	}
+	// Write a temporary file first and rename it, so that the state file is never partially written.
+	path := filepath.Join(m.workDir, StateFile)
+	tmpPath := fmt.Sprintf("%s.tmp", path)
+	file, err := m.fs.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("creating file '%s': %w", tmpPath, err)
+	}
+	if _, err := file.Write(bytes); err != nil {
+		file.Close()
+		return fmt.Errorf("writing file '%s': %w", tmpPath, err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("syncing file '%s': %w", tmpPath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("closing file '%s': %w", tmpPath, err)
+	}
+	if err := m.fs.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("renaming '%s' to '%s': %w", tmpPath, path, err)
+	}
+	return nil
+}
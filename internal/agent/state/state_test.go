@@ -0,0 +1,95 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/twpayne/go-vfs/v4"
+	"github.com/twpayne/go-vfs/v4/vfst"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+)
+
+func TestState(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Agent State Suite")
+}
+
+var _ = Describe("state manager", Label("cli", "state"), func() {
+	workDir := "/test/var/lib/elemental/agent"
+	var fs vfs.FS
+	var err error
+	var fsCleanup func()
+	var manager Manager
+
+	BeforeEach(func() {
+		fs, fsCleanup, err = vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		manager = NewManager(fs, workDir)
+	})
+	It("should return empty state if file does not exist", func() {
+		state, err := manager.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state).To(Equal(State{}))
+	})
+	It("should save and load state", func() {
+		now := time.Now().UTC().Truncate(time.Second)
+		wantState := State{
+			Hostname: "test-host",
+			Phase:    infrastructurev1.PhaseInstalling,
+			Installation: InstallationState{
+				Progress: Progress{
+					Attempts:      2,
+					StartedAt:     now,
+					LastAttemptAt: now,
+					LastError:     "test error",
+				},
+				CloudConfigApplied: true,
+			},
+		}
+		Expect(manager.Save(wantState)).To(Succeed())
+		// Expect no temporary file left behind
+		_, err := fs.Stat(fmt.Sprintf("%s/%s.tmp", workDir, StateFile))
+		Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+		info, err := fs.Stat(fmt.Sprintf("%s/%s", workDir, StateFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		state, err := manager.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state.UpdatedAt.IsZero()).To(BeFalse())
+		state.UpdatedAt = time.Time{}
+		Expect(state).To(Equal(wantState))
+	})
+	It("should fail on malformed state file", func() {
+		Expect(vfs.MkdirAll(fs, workDir, os.ModePerm)).To(Succeed())
+		Expect(fs.WriteFile(fmt.Sprintf("%s/%s", workDir, StateFile), []byte("not: [valid"), 0600)).To(Succeed())
+		_, err := manager.Load()
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("progress", Label("cli", "state"), func() {
+	It("should track attempts, failures, and completion", func() {
+		progress := Progress{}
+		first := time.Now()
+		progress.Attempt(first)
+		progress.Fail(errors.New("test error"))
+		second := first.Add(time.Minute)
+		progress.Attempt(second)
+		Expect(progress.Attempts).To(Equal(2))
+		Expect(progress.StartedAt).To(Equal(first))
+		Expect(progress.LastAttemptAt).To(Equal(second))
+		Expect(progress.LastError).To(Equal("test error"))
+		Expect(progress.Completed()).To(BeFalse())
+		progress.Complete(second)
+		Expect(progress.Completed()).To(BeTrue())
+		Expect(progress.LastError).To(BeEmpty())
+	})
+})
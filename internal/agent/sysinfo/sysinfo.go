@@ -0,0 +1,156 @@
+package sysinfo
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/twpayne/go-vfs/v4"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	systemUUIDPath   = "/sys/class/dmi/id/product_uuid"
+	serialNumberPath = "/sys/class/dmi/id/product_serial"
+	productNamePath  = "/sys/class/dmi/id/product_name"
+	memInfoPath      = "/proc/meminfo"
+)
+
+// Errors.
+var (
+	ErrNoSystemUUID   = errors.New("system UUID not available")
+	ErrNoSerialNumber = errors.New("serial number not available")
+	ErrNoProductName  = errors.New("product name not available")
+	ErrNoMemoryTotal  = errors.New("total memory not found")
+)
+
+// SystemUUID returns the SMBIOS system UUID of this host.
+// This is the same value reported by the kubelet as the Node status.nodeInfo.systemUUID.
+func SystemUUID(fs vfs.FS) (string, error) {
+	bytes, err := fs.ReadFile(systemUUIDPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNoSystemUUID
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading file '%s': %w", systemUUIDPath, err)
+	}
+	systemUUID := strings.TrimSpace(string(bytes))
+	if len(systemUUID) == 0 {
+		return "", ErrNoSystemUUID
+	}
+	return systemUUID, nil
+}
+
+// SerialNumber returns the SMBIOS system serial number of this host.
+func SerialNumber(fs vfs.FS) (string, error) {
+	bytes, err := fs.ReadFile(serialNumberPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNoSerialNumber
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading file '%s': %w", serialNumberPath, err)
+	}
+	serialNumber := strings.TrimSpace(string(bytes))
+	if len(serialNumber) == 0 {
+		return "", ErrNoSerialNumber
+	}
+	return serialNumber, nil
+}
+
+// ProductName returns the SMBIOS system product name, or hardware model, of this host.
+func ProductName(fs vfs.FS) (string, error) {
+	bytes, err := fs.ReadFile(productNamePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNoProductName
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading file '%s': %w", productNamePath, err)
+	}
+	productName := strings.TrimSpace(string(bytes))
+	if len(productName) == 0 {
+		return "", ErrNoProductName
+	}
+	return productName, nil
+}
+
+// MACAddresses returns the MAC addresses of the network interfaces of this host.
+func MACAddresses() ([]string, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("listing network interfaces: %w", err)
+	}
+	return filterMACAddresses(interfaces), nil
+}
+
+// filterMACAddresses returns the MAC addresses of the interfaces, excluding loopback interfaces.
+func filterMACAddresses(interfaces []net.Interface) []string {
+	macAddresses := []string{}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) == 0 {
+			continue
+		}
+		macAddresses = append(macAddresses, iface.HardwareAddr.String())
+	}
+	return macAddresses
+}
+
+// Addresses returns the global unicast IP addresses of this host.
+func Addresses() ([]string, error) {
+	interfaceAddresses, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("listing interface addresses: %w", err)
+	}
+	return filterAddresses(interfaceAddresses), nil
+}
+
+// filterAddresses returns the global unicast IP addresses only,
+// excluding loopback and link-local addresses that may be shared by different hosts.
+func filterAddresses(interfaceAddresses []net.Addr) []string {
+	addresses := []string{}
+	for _, interfaceAddress := range interfaceAddresses {
+		ipNet, ok := interfaceAddress.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		addresses = append(addresses, ipNet.IP.String())
+	}
+	return addresses
+}
+
+// Capacity returns the cpu and memory capacity of this host.
+func Capacity(fs vfs.FS) (corev1.ResourceList, error) {
+	memory, err := memoryTotal(fs)
+	if err != nil {
+		return nil, fmt.Errorf("getting total memory: %w", err)
+	}
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewQuantity(int64(runtime.NumCPU()), resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(memory, resource.BinarySI),
+	}, nil
+}
+
+// memoryTotal returns the total memory in bytes, as reported by the 'MemTotal' entry of /proc/meminfo.
+func memoryTotal(fs vfs.FS) (int64, error) {
+	bytes, err := fs.ReadFile(memInfoPath)
+	if err != nil {
+		return 0, fmt.Errorf("reading file '%s': %w", memInfoPath, err)
+	}
+	for _, line := range strings.Split(string(bytes), "\n") {
+		// Expected format: 'MemTotal:       16314400 kB'
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "MemTotal:" {
+			continue
+		}
+		kiloBytes, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parsing total memory '%s': %w", fields[1], err)
+		}
+		return kiloBytes * 1024, nil
+	}
+	return 0, ErrNoMemoryTotal
+}
@@ -0,0 +1,105 @@
+package sysinfo
+
+import (
+	"net"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/twpayne/go-vfs/v4/vfst"
+)
+
+func TestSysinfo(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Agent Sysinfo Suite")
+}
+
+var _ = Describe("system info", Label("cli", "sysinfo"), func() {
+	It("should read the system UUID", func() {
+		fs, fsCleanup, err := vfst.NewTestFS(map[string]interface{}{
+			systemUUIDPath: "4c4c4544-0044-3510-8052-b4c04f4e5931\n",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		Expect(SystemUUID(fs)).To(Equal("4c4c4544-0044-3510-8052-b4c04f4e5931"))
+	})
+	It("should return error if system UUID is not available", func() {
+		fs, fsCleanup, err := vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		_, err = SystemUUID(fs)
+		Expect(err).To(MatchError(ErrNoSystemUUID))
+	})
+	It("should read the serial number", func() {
+		fs, fsCleanup, err := vfst.NewTestFS(map[string]interface{}{
+			serialNumberPath: "CZ1234567X\n",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		Expect(SerialNumber(fs)).To(Equal("CZ1234567X"))
+	})
+	It("should return error if serial number is empty", func() {
+		fs, fsCleanup, err := vfst.NewTestFS(map[string]interface{}{
+			serialNumberPath: " \n",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		_, err = SerialNumber(fs)
+		Expect(err).To(MatchError(ErrNoSerialNumber))
+	})
+	It("should read the product name", func() {
+		fs, fsCleanup, err := vfst.NewTestFS(map[string]interface{}{
+			productNamePath: "PowerEdge R650\n",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		Expect(ProductName(fs)).To(Equal("PowerEdge R650"))
+	})
+	It("should return error if product name is not available", func() {
+		fs, fsCleanup, err := vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		_, err = ProductName(fs)
+		Expect(err).To(MatchError(ErrNoProductName))
+	})
+	It("should return the memory capacity", func() {
+		fs, fsCleanup, err := vfst.NewTestFS(map[string]interface{}{
+			memInfoPath: "MemTotal:       16314400 kB\nMemFree:         1228148 kB\n",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		capacity, err := Capacity(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(capacity.Memory().Value()).To(Equal(int64(16314400 * 1024)))
+		Expect(capacity.Cpu().Value()).To(BeNumerically(">", 0))
+	})
+	It("should return error if total memory is not found", func() {
+		fs, fsCleanup, err := vfst.NewTestFS(map[string]interface{}{
+			memInfoPath: "MemFree:         1228148 kB\n",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		_, err = Capacity(fs)
+		Expect(err).To(MatchError(ErrNoMemoryTotal))
+	})
+	It("should only return global unicast addresses", func() {
+		interfaceAddresses := []net.Addr{
+			&net.IPNet{IP: net.ParseIP("127.0.0.1"), Mask: net.CIDRMask(8, 32)},
+			&net.IPNet{IP: net.ParseIP("::1"), Mask: net.CIDRMask(128, 128)},
+			&net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)},
+			&net.IPNet{IP: net.ParseIP("192.168.122.10"), Mask: net.CIDRMask(24, 32)},
+			&net.IPNet{IP: net.ParseIP("fd00::10"), Mask: net.CIDRMask(64, 128)},
+		}
+		Expect(filterAddresses(interfaceAddresses)).To(Equal([]string{"192.168.122.10", "fd00::10"}))
+	})
+	It("should not return loopback MAC addresses", func() {
+		hardwareAddr, err := net.ParseMAC("52:54:00:12:34:56")
+		Expect(err).ToNot(HaveOccurred())
+		interfaces := []net.Interface{
+			{Name: "lo", Flags: net.FlagLoopback | net.FlagUp},
+			{Name: "eth0", Flags: net.FlagUp, HardwareAddr: hardwareAddr},
+			{Name: "tun0", Flags: net.FlagUp},
+		}
+		Expect(filterMACAddresses(interfaces)).To(Equal([]string{"52:54:00:12:34:56"}))
+	})
+})
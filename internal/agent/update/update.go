@@ -0,0 +1,383 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/twpayne/go-vfs/v4"
+	"k8s.io/utils/clock"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/backoff"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/config"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/log"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/utils"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/version"
+)
+
+const (
+	// BinaryPath is the updated elemental-agent binary, relative to the agent WorkDir.
+	BinaryPath = "bin/elemental-agent"
+	// maxBinarySize limits the elemental-agent binary download.
+	maxBinarySize = 256 * 1024 * 1024
+	// downloadTimeout limits the elemental-agent binary download duration.
+	downloadTimeout = 5 * time.Minute
+)
+
+var (
+	ErrNoPublicKey       = errors.New("no update public key configured")
+	ErrInvalidPublicKey  = errors.New("invalid update public key")
+	ErrChecksumMismatch  = errors.New("checksum mismatch")
+	ErrInvalidSignature  = errors.New("invalid signature")
+	ErrBinaryTooLarge    = errors.New("binary exceeds the maximum size")
+	ErrUpdateDidNotStart = errors.New("updated elemental-agent exited before reaching the Elemental API")
+)
+
+// Error is a failure to update the elemental-agent to a version.
+// The same version is not attempted again.
+type Error struct {
+	Version string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("updating elemental-agent to version '%s': %s", e.Version, e.Err.Error())
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+type Updater interface {
+	// Resume starts the updated elemental-agent binary, if any, when running the binary shipped with the OS.
+	// If the updated binary previously exited before being confirmed, it is rolled back instead.
+	// If the OS ships a different binary than when the update was installed, the update is discarded.
+	Resume() error
+	// Confirm marks the running updated elemental-agent binary, if any, as working.
+	Confirm()
+	// Reconcile updates the elemental-agent to the desired version, or restores the binary shipped with the OS if nil.
+	// When the updated binary is started, the current process is replaced and this method does not return.
+	Reconcile(desired *infrastructurev1.AgentUpdate) error
+}
+
+var _ Updater = (*updater)(nil)
+
+func NewUpdater(fs vfs.FS, conf config.Config, stateManager state.Manager) Updater {
+	return &updater{
+		fs:            fs,
+		workDir:       conf.Agent.WorkDir,
+		publicKey:     conf.Agent.UpdatePublicKey,
+		stateManager:  stateManager,
+		httpClient:    http.Client{Timeout: downloadTimeout},
+		version:       version.Version,
+		executable:    os.Executable,
+		exec:          execBinary,
+		clock:         clock.RealClock{},
+		downloadRetry: backoff.NewBackoff(conf.Agent),
+	}
+}
+
+type updater struct {
+	fs           vfs.FS
+	workDir      string
+	publicKey    string
+	stateManager state.Manager
+	httpClient   http.Client
+	version      string
+	executable   func() (string, error)
+	exec         func(path string) error
+	confirmed    bool
+	clock        clock.Clock
+	// downloadRetry delays the download attempts of the same version after a failure.
+	downloadRetry   backoff.Backoff
+	downloadVersion string
+	nextDownload    time.Time
+}
+
+func (u *updater) Resume() error {
+	agentState, err := u.stateManager.Load()
+	if err != nil {
+		return fmt.Errorf("loading agent state: %w", err)
+	}
+	if len(agentState.AgentUpdate.Version) == 0 {
+		return nil
+	}
+	runningUpdate, err := u.isRunningUpdate()
+	if err != nil {
+		return fmt.Errorf("determining running elemental-agent binary: %w", err)
+	}
+	if runningUpdate {
+		return nil
+	}
+	if originalVersion := agentState.AgentUpdate.OriginalVersion; len(originalVersion) > 0 && originalVersion != u.version {
+		log.Infof("elemental-agent shipped with the OS changed from version '%s' to '%s', discarding update to version '%s'", originalVersion, u.version, agentState.AgentUpdate.Version)
+		if err := u.fs.Remove(u.binaryPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing updated elemental-agent binary: %w", err)
+		}
+		agentState.AgentUpdate = state.AgentUpdateState{}
+		if err := u.stateManager.Save(agentState); err != nil {
+			return fmt.Errorf("persisting agent state: %w", err)
+		}
+		return nil
+	}
+	if agentState.AgentUpdate.Pending {
+		return u.rollback(agentState, ErrUpdateDidNotStart)
+	}
+	log.Infof("Starting updated elemental-agent version '%s'", agentState.AgentUpdate.Version)
+	if err := u.startUpdate(); err != nil {
+		return u.rollback(agentState, err)
+	}
+	return nil
+}
+
+func (u *updater) Confirm() {
+	if u.confirmed {
+		return
+	}
+	agentState, err := u.stateManager.Load()
+	if err != nil {
+		log.Error(err, "Could not load agent state")
+		return
+	}
+	if agentState.AgentUpdate.Pending {
+		runningUpdate, err := u.isRunningUpdate()
+		if err != nil {
+			log.Error(err, "Could not determine running elemental-agent binary")
+			return
+		}
+		if !runningUpdate {
+			return
+		}
+		log.Infof("Confirming updated elemental-agent version '%s'", agentState.AgentUpdate.Version)
+		agentState.AgentUpdate.Pending = false
+		if err := u.stateManager.Save(agentState); err != nil {
+			log.Error(err, "Could not persist agent state")
+			return
+		}
+	}
+	u.confirmed = true
+}
+
+func (u *updater) Reconcile(desired *infrastructurev1.AgentUpdate) error {
+	agentState, err := u.stateManager.Load()
+	if err != nil {
+		return fmt.Errorf("loading agent state: %w", err)
+	}
+	runningUpdate, err := u.isRunningUpdate()
+	if err != nil {
+		return fmt.Errorf("determining running elemental-agent binary: %w", err)
+	}
+	if desired == nil {
+		if runningUpdate {
+			return u.restoreOriginal(agentState)
+		}
+		return nil
+	}
+	if desired.Version == u.version || desired.Version == agentState.AgentUpdate.FailedVersion {
+		return nil
+	}
+
+	if desired.Version != u.downloadVersion {
+		u.downloadVersion = desired.Version
+		u.downloadRetry.Reset()
+		u.nextDownload = time.Time{}
+	}
+	if now := u.clock.Now(); now.Before(u.nextDownload) {
+		log.Debugf("Waiting '%s' before downloading elemental-agent version '%s' again", u.nextDownload.Sub(now), desired.Version)
+		return nil
+	}
+
+	log.Infof("Updating elemental-agent from version '%s' to '%s'", u.version, desired.Version)
+	binary, err := u.download(desired.URL)
+	if err != nil {
+		// Downloads are attempted again on a later reconcile, backing off
+		u.nextDownload = u.clock.Now().Add(u.downloadRetry.Next())
+		return fmt.Errorf("downloading elemental-agent version '%s': %w", desired.Version, err)
+	}
+	u.downloadRetry.Reset()
+	u.nextDownload = time.Time{}
+	if err := u.verify(binary, *desired); err != nil {
+		return u.fail(agentState, desired.Version, fmt.Errorf("verifying binary: %w", err))
+	}
+	if err := u.install(binary); err != nil {
+		return u.fail(agentState, desired.Version, fmt.Errorf("installing binary: %w", err))
+	}
+	if !runningUpdate {
+		originalPath, err := u.executable()
+		if err != nil {
+			return u.fail(agentState, desired.Version, fmt.Errorf("determining running elemental-agent binary: %w", err))
+		}
+		agentState.AgentUpdate.OriginalPath = originalPath
+		agentState.AgentUpdate.OriginalVersion = u.version
+	}
+	agentState.AgentUpdate.Version = desired.Version
+	agentState.AgentUpdate.Pending = true
+	if err := u.stateManager.Save(agentState); err != nil {
+		return u.fail(agentState, desired.Version, fmt.Errorf("persisting agent state: %w", err))
+	}
+	log.Infof("Starting updated elemental-agent version '%s'", desired.Version)
+	if err := u.startUpdate(); err != nil {
+		return u.rollback(agentState, err)
+	}
+	return nil
+}
+
+// rollback removes the updated binary, so that the binary shipped with the OS is used from now on.
+func (u *updater) rollback(agentState state.State, cause error) error {
+	failedVersion := agentState.AgentUpdate.Version
+	log.Errorf(cause, "Rolling back elemental-agent version '%s'", failedVersion)
+	if err := u.fs.Remove(u.binaryPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Error(err, "Could not remove updated elemental-agent binary")
+	}
+	agentState.AgentUpdate = state.AgentUpdateState{FailedVersion: failedVersion}
+	if err := u.stateManager.Save(agentState); err != nil {
+		log.Error(err, "Could not persist agent state")
+	}
+	return &Error{Version: failedVersion, Err: cause}
+}
+
+// fail records the failed version, so that it is not attempted again.
+func (u *updater) fail(agentState state.State, failedVersion string, cause error) error {
+	agentState.AgentUpdate.FailedVersion = failedVersion
+	if err := u.stateManager.Save(agentState); err != nil {
+		log.Error(err, "Could not persist agent state")
+	}
+	return &Error{Version: failedVersion, Err: cause}
+}
+
+// restoreOriginal removes the updated binary and starts the binary shipped with the OS.
+func (u *updater) restoreOriginal(agentState state.State) error {
+	originalPath := agentState.AgentUpdate.OriginalPath
+	if len(originalPath) == 0 {
+		return errors.New("unknown elemental-agent binary shipped with the OS")
+	}
+	if err := u.fs.Remove(u.binaryPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing updated elemental-agent binary: %w", err)
+	}
+	agentState.AgentUpdate = state.AgentUpdateState{}
+	if err := u.stateManager.Save(agentState); err != nil {
+		return fmt.Errorf("persisting agent state: %w", err)
+	}
+	log.Infof("Starting elemental-agent shipped with the OS: %s", originalPath)
+	if err := u.exec(originalPath); err != nil {
+		return fmt.Errorf("starting elemental-agent shipped with the OS: %w", err)
+	}
+	return nil
+}
+
+func (u *updater) download(url string) ([]byte, error) {
+	response, err := u.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("getting '%s': %w", url, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("getting '%s' returned code '%d'", url, response.StatusCode)
+	}
+	binary, err := io.ReadAll(io.LimitReader(response.Body, maxBinarySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	if len(binary) > maxBinarySize {
+		return nil, ErrBinaryTooLarge
+	}
+	return binary, nil
+}
+
+// verify checks the binary checksum and signature, with the configured update public key.
+func (u *updater) verify(binary []byte, desired infrastructurev1.AgentUpdate) error {
+	checksum := sha256.Sum256(binary)
+	if hex.EncodeToString(checksum[:]) != desired.SHA256 {
+		return ErrChecksumMismatch
+	}
+	if len(u.publicKey) == 0 {
+		return ErrNoPublicKey
+	}
+	block, _ := pem.Decode([]byte(u.publicKey))
+	if block == nil {
+		return fmt.Errorf("%w: no PEM data found", ErrInvalidPublicKey)
+	}
+	parsedKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+	}
+	publicKey, ok := parsedKey.(ed25519.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: not an ed25519 key", ErrInvalidPublicKey)
+	}
+	signature, err := base64.StdEncoding.DecodeString(desired.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	if !ed25519.Verify(publicKey, binary, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// install atomically replaces the updated binary.
+func (u *updater) install(binary []byte) error {
+	path := u.binaryPath()
+	tmpPath := fmt.Sprintf("%s.tmp", path)
+	if err := utils.WriteFile(u.fs, tmpPath, binary); err != nil {
+		return fmt.Errorf("writing '%s': %w", tmpPath, err)
+	}
+	if err := u.fs.Chmod(tmpPath, 0700); err != nil {
+		return fmt.Errorf("setting '%s' permissions: %w", tmpPath, err)
+	}
+	if err := u.fs.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("renaming '%s' to '%s': %w", tmpPath, path, err)
+	}
+	return nil
+}
+
+func (u *updater) binaryPath() string {
+	return filepath.Join(u.workDir, BinaryPath)
+}
+
+// isRunningUpdate returns true if the running process is the updated binary.
+func (u *updater) isRunningUpdate() (bool, error) {
+	executable, err := u.executable()
+	if err != nil {
+		return false, fmt.Errorf("getting executable path: %w", err)
+	}
+	binaryPath, err := u.fs.RawPath(u.binaryPath())
+	if err != nil {
+		return false, fmt.Errorf("getting raw path of '%s': %w", u.binaryPath(), err)
+	}
+	if resolvedPath, err := filepath.EvalSymlinks(binaryPath); err == nil {
+		binaryPath = resolvedPath
+	}
+	return executable == binaryPath, nil
+}
+
+// startUpdate replaces the current process with the updated binary.
+func (u *updater) startUpdate() error {
+	binaryPath, err := u.fs.RawPath(u.binaryPath())
+	if err != nil {
+		return fmt.Errorf("getting raw path of '%s': %w", u.binaryPath(), err)
+	}
+	return u.exec(binaryPath)
+}
+
+// execBinary replaces the current process with the binary, keeping the same arguments and environment.
+func execBinary(path string) error {
+	args := append([]string{path}, os.Args[1:]...)
+	if err := syscall.Exec(path, args, os.Environ()); err != nil {
+		return fmt.Errorf("executing '%s': %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/twpayne/go-vfs/v4"
+	"github.com/twpayne/go-vfs/v4/vfst"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/backoff"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/state"
+)
+
+func TestUpdate(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Agent Update Suite")
+}
+
+var _ = Describe("elemental-agent updater", Label("cli", "update"), func() {
+	workDir := "/test/var/lib/elemental/agent"
+	originalPath := "/usr/sbin/elemental-agent"
+	binary := []byte("test elemental-agent binary")
+	var fs vfs.FS
+	var err error
+	var fsCleanup func()
+	var stateManager state.Manager
+	var server *httptest.Server
+	var desired infrastructurev1.AgentUpdate
+	var publicKey string
+	var executed []string
+	var execErr error
+	var runningPath string
+	var fakeClock *clocktesting.FakeClock
+	var u *updater
+	BeforeEach(func() {
+		fs, fsCleanup, err = vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		stateManager = state.NewManager(fs, workDir)
+
+		server = httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, _ *http.Request) {
+			_, _ = response.Write(binary)
+		}))
+		DeferCleanup(server.Close)
+
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		x509key, err := x509.MarshalPKIXPublicKey(pubKey)
+		Expect(err).ToNot(HaveOccurred())
+		publicKey = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: x509key}))
+		checksum := sha256.Sum256(binary)
+		desired = infrastructurev1.AgentUpdate{
+			Version:   "v0.0.2",
+			URL:       server.URL,
+			SHA256:    hex.EncodeToString(checksum[:]),
+			Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(privKey, binary)),
+		}
+
+		executed = []string{}
+		execErr = nil
+		runningPath = originalPath
+		fakeClock = clocktesting.NewFakeClock(time.Now())
+		u = &updater{
+			fs:           fs,
+			workDir:      workDir,
+			publicKey:    publicKey,
+			stateManager: stateManager,
+			httpClient:   http.Client{},
+			version:      "v0.0.1",
+			executable:   func() (string, error) { return runningPath, nil },
+			exec: func(path string) error {
+				executed = append(executed, path)
+				return execErr
+			},
+			clock: fakeClock,
+			downloadRetry: backoff.NewBackoffWithClock(infrastructurev1.Agent{
+				Reconciliation: time.Minute,
+				Backoff:        infrastructurev1.Backoff{Jitter: ptr.To(0)},
+			}, clock.RealClock{}, func() float64 { return 0 }),
+		}
+	})
+	rawBinaryPath := func() string {
+		path, err := fs.RawPath(filepath.Join(workDir, BinaryPath))
+		Expect(err).ToNot(HaveOccurred())
+		return path
+	}
+	It("should do nothing if the desired version is running", func() {
+		desired.Version = u.version
+		Expect(u.Reconcile(&desired)).Should(Succeed())
+		Expect(u.Reconcile(nil)).Should(Succeed())
+		Expect(executed).Should(BeEmpty())
+	})
+	It("should install and start the desired version", func() {
+		Expect(u.Reconcile(&desired)).Should(Succeed())
+		Expect(executed).Should(Equal([]string{rawBinaryPath()}))
+		installed, err := fs.ReadFile(filepath.Join(workDir, BinaryPath))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(installed).Should(Equal(binary))
+		info, err := fs.Stat(filepath.Join(workDir, BinaryPath))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).Should(Equal(os.FileMode(0700)))
+		agentState, err := stateManager.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(agentState.AgentUpdate).Should(Equal(state.AgentUpdateState{
+			Version:         desired.Version,
+			Pending:         true,
+			OriginalPath:    originalPath,
+			OriginalVersion: u.version,
+		}))
+	})
+	It("should confirm the running update", func() {
+		Expect(u.Reconcile(&desired)).Should(Succeed())
+		// Confirming from the binary shipped with the OS has no effect
+		u.Confirm()
+		agentState, err := stateManager.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(agentState.AgentUpdate.Pending).Should(BeTrue())
+		// Confirming from the updated binary
+		runningPath = rawBinaryPath()
+		u.Confirm()
+		agentState, err = stateManager.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(agentState.AgentUpdate.Pending).Should(BeFalse())
+		Expect(agentState.AgentUpdate.Version).Should(Equal(desired.Version))
+	})
+	It("should refuse updates without public key", func() {
+		u.publicKey = ""
+		err := u.Reconcile(&desired)
+		var updateErr *Error
+		Expect(errors.As(err, &updateErr)).Should(BeTrue())
+		Expect(updateErr.Version).Should(Equal(desired.Version))
+		Expect(errors.Is(err, ErrNoPublicKey)).Should(BeTrue())
+		Expect(executed).Should(BeEmpty())
+		// The failed version is not attempted again
+		Expect(u.Reconcile(&desired)).Should(Succeed())
+	})
+	It("should refuse binaries with wrong checksum", func() {
+		desired.SHA256 = hex.EncodeToString(make([]byte, sha256.Size))
+		Expect(errors.Is(u.Reconcile(&desired), ErrChecksumMismatch)).Should(BeTrue())
+		Expect(executed).Should(BeEmpty())
+		_, err := fs.Stat(filepath.Join(workDir, BinaryPath))
+		Expect(err).To(HaveOccurred(), "Unverified binary must not be installed")
+	})
+	It("should refuse binaries with wrong signature", func() {
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		desired.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(otherKey, binary))
+		Expect(errors.Is(u.Reconcile(&desired), ErrInvalidSignature)).Should(BeTrue())
+		Expect(executed).Should(BeEmpty())
+	})
+	It("should attempt failed downloads again, backing off", func() {
+		downloads := 0
+		server.Config.Handler = http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			downloads++
+			http.NotFound(response, request)
+		})
+		err := u.Reconcile(&desired)
+		Expect(err).To(HaveOccurred())
+		var updateErr *Error
+		Expect(errors.As(err, &updateErr)).Should(BeFalse(), "Download errors should not be reported as update failures")
+		agentState, err := stateManager.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(agentState.AgentUpdate.FailedVersion).Should(BeEmpty())
+		// The download is not attempted again before the backoff delay
+		Expect(u.Reconcile(&desired)).Should(Succeed())
+		Expect(downloads).Should(Equal(1))
+		fakeClock.Step(time.Minute)
+		Expect(u.Reconcile(&desired)).ShouldNot(Succeed())
+		Expect(downloads).Should(Equal(2))
+		// The delay increases on each failure
+		fakeClock.Step(time.Minute)
+		Expect(u.Reconcile(&desired)).Should(Succeed())
+		Expect(downloads).Should(Equal(2))
+		fakeClock.Step(time.Minute)
+		Expect(u.Reconcile(&desired)).ShouldNot(Succeed())
+		Expect(downloads).Should(Equal(3))
+	})
+	It("should roll back if the updated binary can not be started", func() {
+		execErr = errors.New("test exec error")
+		err := u.Reconcile(&desired)
+		var updateErr *Error
+		Expect(errors.As(err, &updateErr)).Should(BeTrue())
+		_, err = fs.Stat(filepath.Join(workDir, BinaryPath))
+		Expect(err).To(HaveOccurred(), "Updated binary must be removed")
+		agentState, err := stateManager.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(agentState.AgentUpdate).Should(Equal(state.AgentUpdateState{FailedVersion: desired.Version}))
+	})
+	It("should start the confirmed update on restart", func() {
+		Expect(u.Reconcile(&desired)).Should(Succeed())
+		runningPath = rawBinaryPath()
+		u.Confirm()
+		// The binary shipped with the OS is started again, for example after a reboot
+		runningPath = originalPath
+		executed = []string{}
+		Expect(u.Resume()).Should(Succeed())
+		Expect(executed).Should(Equal([]string{rawBinaryPath()}))
+	})
+	It("should roll back the pending update on restart", func() {
+		Expect(u.Reconcile(&desired)).Should(Succeed())
+		// The updated binary exited before being confirmed, and the binary shipped with the OS is started again
+		executed = []string{}
+		err := u.Resume()
+		Expect(errors.Is(err, ErrUpdateDidNotStart)).Should(BeTrue())
+		Expect(executed).Should(BeEmpty())
+		_, err = fs.Stat(filepath.Join(workDir, BinaryPath))
+		Expect(err).To(HaveOccurred(), "Updated binary must be removed")
+		agentState, err := stateManager.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(agentState.AgentUpdate).Should(Equal(state.AgentUpdateState{FailedVersion: desired.Version}))
+		// The failed version is not attempted again
+		Expect(u.Reconcile(&desired)).Should(Succeed())
+		Expect(executed).Should(BeEmpty())
+	})
+	It("should discard the update when the OS ships a different binary", func() {
+		Expect(u.Reconcile(&desired)).Should(Succeed())
+		runningPath = rawBinaryPath()
+		u.Confirm()
+		// An OS upgrade ships a newer elemental-agent, started after the reboot
+		runningPath = originalPath
+		u.version = "v0.0.3"
+		executed = []string{}
+		Expect(u.Resume()).Should(Succeed())
+		Expect(executed).Should(BeEmpty(), "The previously updated binary must not be started")
+		_, err = fs.Stat(filepath.Join(workDir, BinaryPath))
+		Expect(err).To(HaveOccurred(), "Updated binary must be removed")
+		agentState, err := stateManager.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(agentState.AgentUpdate).Should(Equal(state.AgentUpdateState{}))
+	})
+	It("should restore the binary shipped with the OS when no update is desired", func() {
+		Expect(u.Reconcile(&desired)).Should(Succeed())
+		runningPath = rawBinaryPath()
+		u.version = desired.Version
+		u.Confirm()
+		executed = []string{}
+		Expect(u.Reconcile(nil)).Should(Succeed())
+		Expect(executed).Should(Equal([]string{originalPath}))
+		_, err = fs.Stat(filepath.Join(workDir, BinaryPath))
+		Expect(err).To(HaveOccurred(), "Updated binary must be removed")
+		agentState, err := stateManager.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(agentState.AgentUpdate).Should(Equal(state.AgentUpdateState{}))
+	})
+})
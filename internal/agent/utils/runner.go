@@ -11,6 +11,7 @@ import (
 
 type CommandRunner interface {
 	RunCommand(command string) error
+	CommandOutput(command string) ([]byte, error)
 }
 
 func NewCommandRunner() CommandRunner {
@@ -33,3 +34,17 @@ func (r *commandRunner) RunCommand(command string) error {
 	}
 	return nil
 }
+
+// CommandOutput runs the input command through bash and returns its standard output.
+// If the command fails, the output collected so far is returned together with the error.
+// This implies `/bin/bash` is installed on the host.
+func (r *commandRunner) CommandOutput(command string) ([]byte, error) {
+	log.Debugf("Running command: %s", command)
+	cmd := exec.CommandContext(context.Background(), "/bin/bash", "-c", command)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return output, fmt.Errorf("running command: %w", err)
+	}
+	return output, nil
+}
@@ -54,6 +54,21 @@ func (m *MockCommandRunner) EXPECT() *MockCommandRunnerMockRecorder {
 	return m.recorder
 }
 
+// CommandOutput mocks base method.
+func (m *MockCommandRunner) CommandOutput(arg0 string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommandOutput", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CommandOutput indicates an expected call of CommandOutput.
+func (mr *MockCommandRunnerMockRecorder) CommandOutput(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommandOutput", reflect.TypeOf((*MockCommandRunner)(nil).CommandOutput), arg0)
+}
+
 // RunCommand mocks base method.
 func (m *MockCommandRunner) RunCommand(arg0 string) error {
 	m.ctrl.T.Helper()
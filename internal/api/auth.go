@@ -11,6 +11,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	k8sapierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -25,7 +26,9 @@ var (
 
 type Authenticator interface {
 	ValidateHostRequest(*http.Request, http.ResponseWriter, *v1beta1.ElementalHost, *v1beta1.ElementalRegistration) error
-	ValidateRegistrationRequest(*http.Request, http.ResponseWriter, *v1beta1.ElementalRegistration) error
+	// ValidateRegistrationRequest validates the registration token.
+	// If the token is a one-time token bound to an ElementalHostReservation, the reservation is returned.
+	ValidateRegistrationRequest(*http.Request, http.ResponseWriter, *v1beta1.ElementalRegistration) (*v1beta1.ElementalHostReservation, error)
 }
 
 func NewAuthenticator(k8sClient client.Client, logger logr.Logger) Authenticator {
@@ -82,19 +85,19 @@ func (a *authenticator) ValidateHostRequest(request *http.Request, response http
 	return nil
 }
 
-func (a *authenticator) ValidateRegistrationRequest(request *http.Request, response http.ResponseWriter, registration *v1beta1.ElementalRegistration) error {
+func (a *authenticator) ValidateRegistrationRequest(request *http.Request, response http.ResponseWriter, registration *v1beta1.ElementalRegistration) (*v1beta1.ElementalHostReservation, error) {
 	// Verify token was passed correctly
 	authValue := request.Header.Get("Registration-Authorization")
 	if len(authValue) == 0 {
 		err := fmt.Errorf("missing 'Registration-Authorization' header: %w", ErrUnauthorized)
 		a.writeResponse(response, err)
-		return err
+		return nil, err
 	}
 	token, found := strings.CutPrefix(authValue, "Bearer ")
 	if !found {
 		err := fmt.Errorf("not a 'Bearer' token: %w", ErrUnauthorized)
 		a.writeResponse(response, err)
-		return err
+		return nil, err
 	}
 
 	// Fetch registration secret and read the private key
@@ -105,24 +108,24 @@ func (a *authenticator) ValidateRegistrationRequest(request *http.Request, respo
 	}, registrationSecret); err != nil {
 		err := fmt.Errorf("getting registration secret: %w", ErrMissingRegistrationSecret)
 		a.writeResponse(response, err)
-		return err
+		return nil, err
 	}
 	privKeyPem, found := registrationSecret.Data["privKey"]
 	if !found {
 		a.writeResponse(response, ErrNoSigningKey)
-		return ErrNoSigningKey
+		return nil, ErrNoSigningKey
 	}
 	parsedKey, err := jwt.ParseEdPrivateKeyFromPEM(privKeyPem)
 	if err != nil {
 		err := fmt.Errorf("parsing ed25519 key: %w", err)
 		a.writeResponse(response, err)
-		return err
+		return nil, err
 	}
 	var privKey ed25519.PrivateKey
 	var ok bool
 	if privKey, ok = parsedKey.(ed25519.PrivateKey); !ok {
 		a.writeResponse(response, jwt.ErrNotEdPrivateKey)
-		return jwt.ErrNotEdPrivateKey
+		return nil, jwt.ErrNotEdPrivateKey
 	}
 	// Validate and Verify JWT
 	expectedClaims := &jwt.RegisteredClaims{
@@ -141,9 +144,32 @@ func (a *authenticator) ValidateRegistrationRequest(request *http.Request, respo
 	if err != nil {
 		err := fmt.Errorf("validating JWT token: %w: %w", err, ErrForbidden)
 		a.writeResponse(response, err)
-		return err
+		return nil, err
 	}
-	return nil
+	// Tokens with an ID are bound to an ElementalHostReservation
+	if len(expectedClaims.ID) == 0 {
+		return nil, nil
+	}
+	reservation := &v1beta1.ElementalHostReservation{}
+	if err := a.k8sClient.Get(request.Context(), types.NamespacedName{
+		Name:      expectedClaims.ID,
+		Namespace: registration.Namespace,
+	}, reservation); err != nil {
+		if k8sapierrors.IsNotFound(err) {
+			err = fmt.Errorf("ElementalHostReservation '%s' not found: %w", expectedClaims.ID, ErrForbidden)
+		} else {
+			err = fmt.Errorf("getting ElementalHostReservation '%s': %w", expectedClaims.ID, err)
+		}
+		a.writeResponse(response, err)
+		return nil, err
+	}
+	// Reject tokens issued to a deleted reservation with the same name
+	if reservation.Spec.RegistrationRef.Name != registration.Name || reservation.Status.Token != token {
+		err := fmt.Errorf("token does not match ElementalHostReservation '%s': %w", reservation.Name, ErrForbidden)
+		a.writeResponse(response, err)
+		return nil, err
+	}
+	return reservation, nil
 }
 
 func (a *authenticator) writeResponse(response http.ResponseWriter, err error) {
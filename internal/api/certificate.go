@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// certificateResyncInterval is used to reload the certificate also when file events are missed.
+const certificateResyncInterval = time.Minute
+
+var (
+	ErrCertificateExpired = errors.New("certificate is expired")
+	ErrNoCertificateFound = errors.New("no certificate loaded")
+)
+
+// CertificateLoader serves the Elemental API TLS certificate, reloading it whenever the files on disk change.
+type CertificateLoader struct {
+	logger   logr.Logger
+	recorder record.EventRecorder
+	// eventTarget is the object the reload events are recorded on, for example the manager Pod.
+	// No events are recorded if nil.
+	eventTarget    *corev1.ObjectReference
+	certificate    string
+	privKey        string
+	resyncInterval time.Duration
+
+	mutex   sync.RWMutex
+	current *tls.Certificate
+}
+
+// NewCertificateLoader loads the TLS certificate and private key, failing if they can not be loaded.
+func NewCertificateLoader(logger logr.Logger, recorder record.EventRecorder, eventTarget *corev1.ObjectReference, certificate string, privKey string) (*CertificateLoader, error) {
+	loader := &CertificateLoader{
+		logger:         logger.WithName("certificate-loader"),
+		recorder:       recorder,
+		eventTarget:    eventTarget,
+		certificate:    certificate,
+		privKey:        privKey,
+		resyncInterval: certificateResyncInterval,
+	}
+	if err := loader.Reload(); err != nil {
+		return nil, fmt.Errorf("loading Elemental API TLS certificate: %w", err)
+	}
+	return loader, nil
+}
+
+// GetCertificate returns the currently loaded certificate.
+// It can be used as tls.Config.GetCertificate.
+func (l *CertificateLoader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if l.current == nil {
+		return nil, ErrNoCertificateFound
+	}
+	return l.current, nil
+}
+
+// Reload reads the certificate and private key from disk.
+// The new pair is only served if the private key matches the certificate, and the certificate is not expired.
+// Otherwise the previously loaded certificate is kept.
+func (l *CertificateLoader) Reload() error {
+	certificate, err := loadCertificate(l.certificate, l.privKey)
+	if err != nil {
+		certificateReloads.WithLabelValues(certificateReloadFailure).Inc()
+		l.event(corev1.EventTypeWarning, "CertificateReloadFailed", "Elemental API TLS certificate reload failed: %s", err.Error())
+		return err
+	}
+
+	l.mutex.Lock()
+	previous := l.current
+	if previous != nil && bytes.Equal(previous.Certificate[0], certificate.Certificate[0]) {
+		l.mutex.Unlock()
+		return nil
+	}
+	l.current = certificate
+	l.mutex.Unlock()
+
+	certificateReloads.WithLabelValues(certificateReloadSuccess).Inc()
+	certificateExpiration.Set(float64(certificate.Leaf.NotAfter.Unix()))
+	serial := certificate.Leaf.SerialNumber.Text(16)
+	l.logger.Info("Loaded Elemental API TLS certificate", "serial", serial, "notAfter", certificate.Leaf.NotAfter)
+	if previous != nil {
+		l.event(corev1.EventTypeNormal, "CertificateReloaded", "Elemental API TLS certificate reloaded, serving serial '%s'", serial)
+	}
+	return nil
+}
+
+// Start watches the certificate and private key directories, reloading the certificate on change.
+// Directories are watched rather than files, since mounted Secrets are updated by swapping symlinks.
+func (l *CertificateLoader) Start(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating file watcher: %w", err)
+	}
+	defer watcher.Close()
+	for _, dir := range []string{filepath.Dir(l.certificate), filepath.Dir(l.privKey)} {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("watching directory '%s': %w", dir, err)
+		}
+	}
+
+	l.logger.Info("Watching Elemental API TLS certificate", "certificate", l.certificate, "privateKey", l.privKey)
+	resync := time.NewTicker(l.resyncInterval)
+	defer resync.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			l.logger.V(1).Info("Certificate directory event", "event", event.String())
+			l.reloadOrLog()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			l.logger.Error(err, "watching Elemental API TLS certificate")
+		case <-resync.C:
+			l.reloadOrLog()
+		}
+	}
+}
+
+func (l *CertificateLoader) reloadOrLog() {
+	if err := l.Reload(); err != nil {
+		l.logger.Error(err, "reloading Elemental API TLS certificate")
+	}
+}
+
+func (l *CertificateLoader) event(eventtype, reason, messageFmt string, args ...interface{}) {
+	if l.eventTarget == nil {
+		return
+	}
+	l.recorder.Eventf(l.eventTarget, eventtype, reason, messageFmt, args...)
+}
+
+// loadCertificate loads and validates the certificate and private key pair.
+func loadCertificate(certificate string, privKey string) (*tls.Certificate, error) {
+	pair, err := tls.LoadX509KeyPair(certificate, privKey)
+	if err != nil {
+		return nil, fmt.Errorf("loading key pair: %w", err)
+	}
+	if pair.Leaf == nil {
+		if pair.Leaf, err = x509.ParseCertificate(pair.Certificate[0]); err != nil {
+			return nil, fmt.Errorf("parsing certificate: %w", err)
+		}
+	}
+	if time.Now().After(pair.Leaf.NotAfter) {
+		return nil, fmt.Errorf("%w: not after %s", ErrCertificateExpired, pair.Leaf.NotAfter)
+	}
+	return &pair, nil
+}
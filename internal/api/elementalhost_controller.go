@@ -1,20 +1,28 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"html"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/gorilla/mux"
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/controller/utils"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/log"
 	"github.com/swaggest/openapi-go"
 	corev1 "k8s.io/api/core/v1"
 	k8sapierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -25,16 +33,20 @@ var _ OpenAPIDecoratedHandler = (*PatchElementalHostHandler)(nil)
 var _ http.Handler = (*PatchElementalHostHandler)(nil)
 
 type PatchElementalHostHandler struct {
-	logger    logr.Logger
-	k8sClient client.Client
-	auth      Authenticator
+	logger               logr.Logger
+	k8sClient            client.Client
+	auth                 Authenticator
+	recorder             record.EventRecorder
+	heartbeatGracePeriod time.Duration
 }
 
-func NewPatchElementalHostHandler(logger logr.Logger, k8sClient client.Client) *PatchElementalHostHandler {
+func NewPatchElementalHostHandler(logger logr.Logger, k8sClient client.Client, recorder record.EventRecorder, heartbeatGracePeriod time.Duration) *PatchElementalHostHandler {
 	return &PatchElementalHostHandler{
-		logger:    logger,
-		k8sClient: k8sClient,
-		auth:      NewAuthenticator(k8sClient, logger),
+		logger:               logger,
+		k8sClient:            k8sClient,
+		auth:                 NewAuthenticator(k8sClient, logger),
+		recorder:             recorder,
+		heartbeatGracePeriod: heartbeatGracePeriod,
 	}
 }
 
@@ -47,6 +59,7 @@ func (h *PatchElementalHostHandler) SetupOpenAPIOperation(oc openapi.OperationCo
 	oc.AddRespStructure(HostResponse{}, WithDecoration("Returns the patched ElementalHost", "application/json", http.StatusOK))
 	oc.AddRespStructure(nil, WithDecoration("If the ElementalRegistration or the ElementalHost are not found", "text/html", http.StatusNotFound))
 	oc.AddRespStructure(nil, WithDecoration("If the ElementalHostPatch request is badly formatted", "text/html", http.StatusBadRequest))
+	oc.AddRespStructure(nil, WithDecoration("If the ElementalHostPatch request changes the state of a paused ElementalHost", "text/html", http.StatusConflict))
 	oc.AddRespStructure(nil, WithDecoration("If the 'Authorization' header does not contain a Bearer token", "text/html", http.StatusUnauthorized))
 	oc.AddRespStructure(nil, WithDecoration("If the 'Authorization' token is not valid", "text/html", http.StatusForbidden))
 	oc.AddRespStructure(nil, WithDecoration("", "text/html", http.StatusInternalServerError))
@@ -97,6 +110,7 @@ func (h *PatchElementalHostHandler) ServeHTTP(response http.ResponseWriter, requ
 	if err := h.auth.ValidateHostRequest(request, response, host, registration); err != nil {
 		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
 			logger.Info("Host request denied", "reason", err.Error())
+			h.recorder.Eventf(host, corev1.EventTypeWarning, "AuthenticationFailed", "Host request denied: %s", err.Error())
 			return
 		}
 		logger.Error(err, "Could not authenticate request")
@@ -120,6 +134,21 @@ func (h *PatchElementalHostHandler) ServeHTTP(response http.ResponseWriter, requ
 		}
 	}
 
+	// Reject state changes while paused
+	paused, err := utils.IsElementalHostPaused(request.Context(), h.k8sClient, host)
+	if err != nil {
+		logger.Error(err, "Could not determine if ElementalHost is paused")
+		response.WriteHeader(http.StatusInternalServerError)
+		WriteResponse(logger, response, fmt.Sprintf("Could not determine if ElementalHost '%s' is paused", hostName))
+		return
+	}
+	if paused && hostPatchRequest.changesState(*host) {
+		logger.Info("ElementalHost is paused, rejecting state change")
+		response.WriteHeader(http.StatusConflict)
+		WriteResponse(logger, response, fmt.Sprintf("ElementalHost '%s' is paused", hostName))
+		return
+	}
+
 	// Patch the object
 	patchHelper, err := patch.NewHelper(host, h.k8sClient)
 	if err != nil {
@@ -129,7 +158,14 @@ func (h *PatchElementalHostHandler) ServeHTTP(response http.ResponseWriter, requ
 		return
 	}
 
+	previousPhase := host.Status.Phase
+	previousAgentVersion := host.Status.AgentVersion
 	hostPatchRequest.applyToElementalHost(host)
+	reconcileAgentVersion(host, *registration, agentVersion(request.UserAgent()))
+	// Record the agent heartbeat
+	if now := time.Now(); lastSeenExpired(*host, h.heartbeatGracePeriod, now) {
+		host.Status.LastSeen = &metav1.Time{Time: now}
+	}
 	if err := patchHelper.Patch(request.Context(), host); err != nil {
 		logger.Error(err, "Could not patch ElementalHost")
 		response.WriteHeader(http.StatusInternalServerError)
@@ -137,6 +173,17 @@ func (h *PatchElementalHostHandler) ServeHTTP(response http.ResponseWriter, requ
 		return
 	}
 
+	if host.Status.Phase != previousPhase {
+		h.recorder.Eventf(host, corev1.EventTypeNormal, "PhaseChanged", "Phase changed from '%s' to '%s'", previousPhase, host.Status.Phase)
+		observePhaseDuration(*host)
+	}
+	if len(previousAgentVersion) > 0 && host.Status.AgentVersion != previousAgentVersion {
+		h.recorder.Eventf(host, corev1.EventTypeNormal, "AgentVersionChanged", "elemental-agent version changed from '%s' to '%s'", previousAgentVersion, host.Status.AgentVersion)
+	}
+	if hostPatchRequest.AgentUpdateFailure != nil {
+		h.recorder.Eventf(host, corev1.EventTypeWarning, "AgentUpdateFailed", "elemental-agent update to version '%s' failed: %s", hostPatchRequest.AgentUpdateFailure.Version, hostPatchRequest.AgentUpdateFailure.Message)
+	}
+
 	// Fetch the updated host
 	host = &infrastructurev1.ElementalHost{}
 	if err := h.k8sClient.Get(request.Context(), k8sclient.ObjectKey{Namespace: namespace, Name: hostName}, host); err != nil {
@@ -154,6 +201,17 @@ func (h *PatchElementalHostHandler) ServeHTTP(response http.ResponseWriter, requ
 	// Serialize response to JSON
 	hostResponse := HostResponse{}
 	hostResponse.fromElementalHost(*host)
+	hostResponse.AgentUpdate = registration.Spec.AgentUpdate
+
+	// Deliver pending diagnostics, if any.
+	// A failure to list them should not prevent the agent from progressing.
+	diagnostics := &infrastructurev1.ElementalHostDiagnosticList{}
+	if err := h.k8sClient.List(request.Context(), diagnostics, k8sclient.InNamespace(namespace)); err != nil {
+		logger.Error(err, "Could not list ElementalHostDiagnostics")
+	} else {
+		hostResponse.Diagnostics = pendingDiagnostics(*host, diagnostics.Items)
+	}
+
 	responseBytes, err := json.Marshal(hostResponse)
 	if err != nil {
 		h.logger.Error(err, "Could not encode response body", "host", fmt.Sprintf("%+v", hostResponse))
@@ -175,13 +233,17 @@ type PostElementalHostHandler struct {
 	logger    logr.Logger
 	k8sClient client.Client
 	auth      Authenticator
+	recorder  record.EventRecorder
+	limiter   *registrationRateLimiter
 }
 
-func NewPostElementalHostHandler(logger logr.Logger, k8sClient client.Client) *PostElementalHostHandler {
+func NewPostElementalHostHandler(logger logr.Logger, k8sClient client.Client, recorder record.EventRecorder) *PostElementalHostHandler {
 	return &PostElementalHostHandler{
 		logger:    logger,
 		k8sClient: k8sClient,
 		auth:      NewAuthenticator(k8sClient, logger),
+		recorder:  recorder,
+		limiter:   newRegistrationRateLimiter(),
 	}
 }
 
@@ -192,11 +254,12 @@ func (h *PostElementalHostHandler) SetupOpenAPIOperation(oc openapi.OperationCon
 	oc.AddReqStructure(HostCreateRequest{})
 
 	oc.AddRespStructure(nil, WithDecoration("ElementalHost correctly created. Location Header contains its URI", "", http.StatusCreated))
-	oc.AddRespStructure(nil, WithDecoration("ElementalHost with same name within this ElementalRegistration already exists", "text/html", http.StatusConflict))
+	oc.AddRespStructure(nil, WithDecoration("ElementalHost with same name within this ElementalRegistration already exists, or the ElementalRegistration is paused", "text/html", http.StatusConflict))
 	oc.AddRespStructure(nil, WithDecoration("ElementalRegistration not found", "text/html", http.StatusNotFound))
 	oc.AddRespStructure(nil, WithDecoration("ElementalHost request is badly formatted", "text/html", http.StatusBadRequest))
 	oc.AddRespStructure(nil, WithDecoration("If the 'Authorization' or 'Registration-Authorization' headers do not contain Bearer tokens", "text/html", http.StatusUnauthorized))
-	oc.AddRespStructure(nil, WithDecoration("If the 'Authorization' or 'Registration-Authorization' tokens are not valid", "text/html", http.StatusForbidden))
+	oc.AddRespStructure(nil, WithDecoration("If the 'Authorization' or 'Registration-Authorization' tokens are not valid, or the ElementalRegistration maximum number of hosts was reached", "text/html", http.StatusForbidden))
+	oc.AddRespStructure(nil, WithDecoration("ElementalRegistration registrations rate exceeded. Retry-After header contains the delay in seconds", "text/html", http.StatusTooManyRequests))
 	oc.AddRespStructure(nil, WithDecoration("", "text/html", http.StatusInternalServerError))
 
 	return nil
@@ -266,21 +329,108 @@ func (h *PostElementalHostHandler) ServeHTTP(response http.ResponseWriter, reque
 	if err := h.auth.ValidateHostRequest(request, response, &newHost, registration); err != nil {
 		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
 			logger.Info("Host request denied", "reason", err.Error())
+			h.recorder.Eventf(registration, corev1.EventTypeWarning, "AuthenticationFailed", "Host '%s' request denied: %s", newHostName, err.Error())
 			return
 		}
 		logger.Error(err, "Could not authenticate host request")
 		return
 	}
 	// Authenticate Registration token
-	if err := h.auth.ValidateRegistrationRequest(request, response, registration); err != nil {
+	reservation, err := h.auth.ValidateRegistrationRequest(request, response, registration)
+	if err != nil {
 		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
 			logger.Info("Registration request denied", "reason", err.Error())
+			h.recorder.Eventf(registration, corev1.EventTypeWarning, "AuthenticationFailed", "Host '%s' registration request denied: %s", newHostName, err.Error())
 			return
 		}
 		logger.Error(err, "Could not authenticate registration request")
 		return
 	}
 
+	// Reject new registrations while paused
+	paused, err := utils.IsPaused(request.Context(), h.k8sClient, registration)
+	if err != nil {
+		logger.Error(err, "Could not determine if ElementalRegistration is paused")
+		response.WriteHeader(http.StatusInternalServerError)
+		WriteResponse(logger, response, fmt.Sprintf("Could not determine if ElementalRegistration '%s' is paused", registrationName))
+		return
+	}
+	if paused {
+		logger.Info("ElementalRegistration is paused, rejecting new host")
+		response.WriteHeader(http.StatusConflict)
+		WriteResponse(logger, response, fmt.Sprintf("ElementalRegistration '%s' is paused", registrationName))
+		return
+	}
+
+	// Enforce the maximum number of hosts
+	if registration.Spec.MaxHosts != nil {
+		registeredHosts, err := h.countRegisteredHosts(request.Context(), registration)
+		if err != nil {
+			logger.Error(err, "Could not count registered ElementalHosts")
+			response.WriteHeader(http.StatusInternalServerError)
+			WriteResponse(logger, response, fmt.Sprintf("Could not count ElementalHosts registered through '%s'", registrationName))
+			return
+		}
+		if registeredHosts >= int(*registration.Spec.MaxHosts) {
+			logger.Info("ElementalRegistration maximum number of hosts reached", "maxHosts", *registration.Spec.MaxHosts)
+			h.recorder.Eventf(registration, corev1.EventTypeWarning, "RegistrationRejected", "Host '%s' rejected: maximum number of hosts (%d) reached", newHostName, *registration.Spec.MaxHosts)
+			response.WriteHeader(http.StatusForbidden)
+			WriteResponse(logger, response, fmt.Sprintf("ElementalRegistration '%s' maximum number of hosts (%d) reached", registrationName, *registration.Spec.MaxHosts))
+			return
+		}
+	}
+
+	// One-time tokens can only register the reserved host, once
+	if reservation != nil {
+		if reservation.Status.HostRef != nil {
+			logger.Info("ElementalHostReservation token was already consumed", log.KeyElementalHostReservation, reservation.Name)
+			response.WriteHeader(http.StatusForbidden)
+			WriteResponse(logger, response, fmt.Sprintf("ElementalHostReservation '%s' token was already consumed", reservation.Name))
+			return
+		}
+		if !hostCreateRequest.isReservedHost(*reservation) {
+			logger.Info("ElementalHost does not match the reservation", log.KeyElementalHostReservation, reservation.Name)
+			response.WriteHeader(http.StatusForbidden)
+			WriteResponse(logger, response, fmt.Sprintf("Host '%s' does not match ElementalHostReservation '%s'", newHostName, reservation.Name))
+			return
+		}
+		newHost.Labels = mergeMaps(newHost.Labels, reservation.Spec.HostLabels)
+		newHost.Annotations = mergeMaps(newHost.Annotations, reservation.Spec.HostAnnotations)
+		delete(newHost.Annotations, infrastructurev1.AnnotationElementalHostApproved)
+	}
+
+	// Reject already existing hosts before consuming a registration slot
+	if err := h.k8sClient.Get(request.Context(), k8sclient.ObjectKeyFromObject(&newHost), &infrastructurev1.ElementalHost{}); err == nil {
+		logger.Info("ElementalHost already exists")
+		response.WriteHeader(http.StatusConflict)
+		WriteResponse(logger, response, fmt.Sprintf("Host '%s' in namespace '%s' already exists", namespace, newHostName))
+		return
+	} else if !k8sapierrors.IsNotFound(err) {
+		logger.Error(err, "Could not get ElementalHost")
+		response.WriteHeader(http.StatusInternalServerError)
+		WriteResponse(logger, response, fmt.Sprintf("Could not get Elemental Host '%s'", newHostName))
+		return
+	}
+
+	// Enforce the registrations rate
+	if registration.Spec.MaxRegistrationsPerMinute > 0 {
+		if allowed, delay := h.limiter.Allow(k8sclient.ObjectKeyFromObject(registration), registration.Spec.MaxRegistrationsPerMinute); !allowed {
+			logger.Info("ElementalRegistration registrations rate exceeded", "retryAfter", delay)
+			h.recorder.Eventf(registration, corev1.EventTypeWarning, "RegistrationRejected", "Host '%s' rejected: registrations rate exceeded", newHostName)
+			response.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			response.WriteHeader(http.StatusTooManyRequests)
+			WriteResponse(logger, response, fmt.Sprintf("ElementalRegistration '%s' registrations rate exceeded", registrationName))
+			return
+		}
+	}
+
+	// Hold new hosts for approval, unless they are expected
+	if registration.Spec.RequireApproval {
+		approved := reservation != nil || hostCreateRequest.isAutoApproved(registration.Spec.AutoApprove)
+		logger.Info("ElementalRegistration requires approval", "autoApproved", approved)
+		newHost.Annotations[infrastructurev1.AnnotationElementalHostApproved] = strconv.FormatBool(approved)
+	}
+
 	// Create new Host
 	if err := h.k8sClient.Create(request.Context(), &newHost); err != nil {
 		if k8sapierrors.IsAlreadyExists(err) {
@@ -296,11 +446,40 @@ func (h *PostElementalHostHandler) ServeHTTP(response http.ResponseWriter, reque
 	}
 
 	logger.Info("ElementalHost created successfully", log.KeyElementalHost, newHostName)
+	h.recorder.Eventf(&newHost, corev1.EventTypeNormal, "Registered", "Host '%s' registered through ElementalRegistration '%s'", newHost.Name, registration.Name)
+
+	// Consume the one-time token
+	if reservation != nil {
+		original := reservation.DeepCopy()
+		reservation.Status.HostRef = &corev1.ObjectReference{
+			APIVersion: infrastructurev1.GroupVersion.String(),
+			Kind:       "ElementalHost",
+			Name:       newHost.Name,
+			Namespace:  newHost.Namespace,
+			UID:        newHost.UID,
+		}
+		// Since the host name is reserved, the token can not register another host even if this fails.
+		if err := h.k8sClient.Status().Patch(request.Context(), reservation, client.MergeFrom(original)); err != nil {
+			logger.Error(err, "Could not mark ElementalHostReservation token as consumed", log.KeyElementalHostReservation, reservation.Name)
+		}
+	}
 
 	response.Header().Set("Location", fmt.Sprintf("%s%s/namespaces/%s/registrations/%s/hosts/%s", Prefix, PrefixV1, namespace, registrationName, newHostName))
 	response.WriteHeader(http.StatusCreated)
 }
 
+// countRegisteredHosts returns the number of ElementalHosts controlled by the ElementalRegistration.
+// The count relies on the ElementalRegistration field index, thus it can lag behind the hosts just registered.
+func (h *PostElementalHostHandler) countRegisteredHosts(ctx context.Context, registration *infrastructurev1.ElementalRegistration) (int, error) {
+	hosts := &infrastructurev1.ElementalHostList{}
+	if err := h.k8sClient.List(ctx, hosts,
+		client.InNamespace(registration.Namespace),
+		client.MatchingFields{utils.ElementalHostRegistrationIndexKey: registration.Name}); err != nil {
+		return 0, fmt.Errorf("listing ElementalHosts: %w", err)
+	}
+	return len(hosts.Items), nil
+}
+
 var _ OpenAPIDecoratedHandler = (*DeleteElementalHostHandler)(nil)
 var _ http.Handler = (*DeleteElementalHostHandler)(nil)
 
@@ -308,13 +487,15 @@ type DeleteElementalHostHandler struct {
 	logger    logr.Logger
 	k8sClient client.Client
 	auth      Authenticator
+	recorder  record.EventRecorder
 }
 
-func NewDeleteElementalHostHandler(logger logr.Logger, k8sClient client.Client) *DeleteElementalHostHandler {
+func NewDeleteElementalHostHandler(logger logr.Logger, k8sClient client.Client, recorder record.EventRecorder) *DeleteElementalHostHandler {
 	return &DeleteElementalHostHandler{
 		logger:    logger,
 		k8sClient: k8sClient,
 		auth:      NewAuthenticator(k8sClient, logger),
+		recorder:  recorder,
 	}
 }
 
@@ -326,6 +507,7 @@ func (h *DeleteElementalHostHandler) SetupOpenAPIOperation(oc openapi.OperationC
 
 	oc.AddRespStructure(nil, WithDecoration("ElementalHost correctly deleted.", "", http.StatusAccepted))
 	oc.AddRespStructure(nil, WithDecoration("ElementalHost not found", "text/html", http.StatusNotFound))
+	oc.AddRespStructure(nil, WithDecoration("ElementalHost is paused", "text/html", http.StatusConflict))
 	oc.AddRespStructure(nil, WithDecoration("If the 'Authorization' header does not contain a Bearer token", "text/html", http.StatusUnauthorized))
 	oc.AddRespStructure(nil, WithDecoration("If the 'Authorization' token is not valid", "text/html", http.StatusForbidden))
 	oc.AddRespStructure(nil, WithDecoration("", "text/html", http.StatusInternalServerError))
@@ -378,6 +560,7 @@ func (h *DeleteElementalHostHandler) ServeHTTP(response http.ResponseWriter, req
 	if err := h.auth.ValidateHostRequest(request, response, host, registration); err != nil {
 		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
 			logger.Info("Host request denied", "reason", err.Error())
+			h.recorder.Eventf(host, corev1.EventTypeWarning, "AuthenticationFailed", "Host request denied: %s", err.Error())
 			return
 		}
 		logger.Error(err, "Could not authenticate host request")
@@ -390,6 +573,20 @@ func (h *DeleteElementalHostHandler) ServeHTTP(response http.ResponseWriter, req
 		return
 	}
 
+	paused, err := utils.IsElementalHostPaused(request.Context(), h.k8sClient, host)
+	if err != nil {
+		logger.Error(err, "Could not determine if ElementalHost is paused")
+		response.WriteHeader(http.StatusInternalServerError)
+		WriteResponse(logger, response, fmt.Sprintf("Could not determine if ElementalHost '%s' is paused", hostName))
+		return
+	}
+	if paused {
+		logger.Info("ElementalHost is paused, rejecting deletion")
+		response.WriteHeader(http.StatusConflict)
+		WriteResponse(logger, response, fmt.Sprintf("ElementalHost '%s' is paused", hostName))
+		return
+	}
+
 	if err := h.k8sClient.Delete(request.Context(), host); err != nil {
 		logger.Error(err, "Deleting ElementalHost")
 		response.WriteHeader(http.StatusInternalServerError)
@@ -408,13 +605,15 @@ type GetElementalHostBootstrapHandler struct {
 	logger    logr.Logger
 	k8sClient client.Client
 	auth      Authenticator
+	recorder  record.EventRecorder
 }
 
-func NewGetElementalHostBootstrapHandler(logger logr.Logger, k8sClient client.Client) *GetElementalHostBootstrapHandler {
+func NewGetElementalHostBootstrapHandler(logger logr.Logger, k8sClient client.Client, recorder record.EventRecorder) *GetElementalHostBootstrapHandler {
 	return &GetElementalHostBootstrapHandler{
 		logger:    logger,
 		k8sClient: k8sClient,
 		auth:      NewAuthenticator(k8sClient, logger),
+		recorder:  recorder,
 	}
 }
 
@@ -476,6 +675,7 @@ func (h *GetElementalHostBootstrapHandler) ServeHTTP(response http.ResponseWrite
 	if err := h.auth.ValidateHostRequest(request, response, host, registration); err != nil {
 		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
 			logger.Info("Host request denied", "reason", err.Error())
+			h.recorder.Eventf(host, corev1.EventTypeWarning, "AuthenticationFailed", "Host request denied: %s", err.Error())
 			return
 		}
 		logger.Error(err, "Could not authenticate host request")
@@ -524,3 +724,428 @@ func (h *GetElementalHostBootstrapHandler) ServeHTTP(response http.ResponseWrite
 	response.WriteHeader(http.StatusOK)
 	WriteResponseBytes(logger, response, responseBytes)
 }
+
+var _ OpenAPIDecoratedHandler = (*GetElementalHostRegistrationHandler)(nil)
+var _ http.Handler = (*GetElementalHostRegistrationHandler)(nil)
+
+type GetElementalHostRegistrationHandler struct {
+	logger    logr.Logger
+	k8sClient client.Client
+	auth      Authenticator
+	recorder  record.EventRecorder
+}
+
+func NewGetElementalHostRegistrationHandler(logger logr.Logger, k8sClient client.Client, recorder record.EventRecorder) *GetElementalHostRegistrationHandler {
+	return &GetElementalHostRegistrationHandler{
+		logger:    logger,
+		k8sClient: k8sClient,
+		auth:      NewAuthenticator(k8sClient, logger),
+		recorder:  recorder,
+	}
+}
+
+func (h *GetElementalHostRegistrationHandler) SetupOpenAPIOperation(oc openapi.OperationContext) error {
+	oc.SetSummary("Get ElementalRegistration for a registered ElementalHost")
+	oc.SetDescription("This endpoint returns the ElementalRegistration of an already registered ElementalHost, without the registration token. It is used by the elemental-agent to keep its config in sync.")
+
+	oc.AddReqStructure(HostRegistrationGetRequest{})
+
+	oc.AddRespStructure(RegistrationResponse{}, WithDecoration("Returns the ElementalRegistration", "application/json", http.StatusOK))
+	oc.AddRespStructure(nil, WithDecoration("If the ElementalRegistration or ElementalHost are not found", "text/html", http.StatusNotFound))
+	oc.AddRespStructure(nil, WithDecoration("If the 'Authorization' header does not contain a Bearer token", "text/html", http.StatusUnauthorized))
+	oc.AddRespStructure(nil, WithDecoration("If the 'Authorization' token is not valid", "text/html", http.StatusForbidden))
+	oc.AddRespStructure(nil, WithDecoration("", "text/html", http.StatusInternalServerError))
+
+	return nil
+}
+
+func (h *GetElementalHostRegistrationHandler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	pathVars := mux.Vars(request)
+	namespace := html.EscapeString(pathVars["namespace"])
+	registrationName := html.EscapeString(pathVars["registrationName"])
+	hostName := html.EscapeString(pathVars["hostName"])
+
+	logger := h.logger.WithValues(log.KeyNamespace, namespace).
+		WithValues(log.KeyElementalRegistration, registrationName).
+		WithValues(log.KeyElementalHost, hostName)
+	logger.V(log.DebugLevel).Info("Getting ElementalRegistration for ElementalHost")
+
+	// Fetch registration
+	registration := &infrastructurev1.ElementalRegistration{}
+	if err := h.k8sClient.Get(request.Context(), k8sclient.ObjectKey{Namespace: namespace, Name: registrationName}, registration); err != nil {
+		if k8sapierrors.IsNotFound(err) {
+			response.WriteHeader(http.StatusNotFound)
+			WriteResponse(logger, response, fmt.Sprintf("ElementalRegistration '%s' not found", registrationName))
+		} else {
+			logger.Error(err, "Could not fetch ElementalRegistration")
+			response.WriteHeader(http.StatusInternalServerError)
+			WriteResponse(logger, response, fmt.Sprintf("Could not fetch ElementalRegistration '%s'", registrationName))
+		}
+		return
+	}
+
+	// Fetch host
+	host := &infrastructurev1.ElementalHost{}
+	if err := h.k8sClient.Get(request.Context(), k8sclient.ObjectKey{Namespace: namespace, Name: hostName}, host); err != nil {
+		if k8sapierrors.IsNotFound(err) {
+			response.WriteHeader(http.StatusNotFound)
+			WriteResponse(logger, response, fmt.Sprintf("ElementalHost '%s' not found", hostName))
+		} else {
+			logger.Error(err, "Could not fetch ElementalHost")
+			response.WriteHeader(http.StatusInternalServerError)
+			WriteResponse(logger, response, fmt.Sprintf("Could not fetch ElementalHost '%s'", hostName))
+		}
+		return
+	}
+
+	// Authenticate Request
+	if err := h.auth.ValidateHostRequest(request, response, host, registration); err != nil {
+		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
+			logger.Info("Host request denied", "reason", err.Error())
+			h.recorder.Eventf(host, corev1.EventTypeWarning, "AuthenticationFailed", "Host request denied: %s", err.Error())
+			return
+		}
+		logger.Error(err, "Could not authenticate host request")
+		return
+	}
+
+	registrationResponse := RegistrationResponse{}
+	registrationResponse.fromElementalRegistrationForHost(*registration)
+
+	// Serialize to JSON
+	responseBytes, err := json.Marshal(registrationResponse)
+	if err != nil {
+		logger.Error(err, "Could not encode response body")
+		response.WriteHeader(http.StatusInternalServerError)
+		WriteResponse(logger, response, fmt.Errorf("Could not encode response body: %w", err).Error())
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	WriteResponseBytes(logger, response, responseBytes)
+}
+
+var _ OpenAPIDecoratedHandler = (*PostElementalHostSupportBundleHandler)(nil)
+var _ http.Handler = (*PostElementalHostSupportBundleHandler)(nil)
+
+type PostElementalHostSupportBundleHandler struct {
+	logger    logr.Logger
+	k8sClient client.Client
+	auth      Authenticator
+	recorder  record.EventRecorder
+}
+
+func NewPostElementalHostSupportBundleHandler(logger logr.Logger, k8sClient client.Client, recorder record.EventRecorder) *PostElementalHostSupportBundleHandler {
+	return &PostElementalHostSupportBundleHandler{
+		logger:    logger,
+		k8sClient: k8sClient,
+		auth:      NewAuthenticator(k8sClient, logger),
+		recorder:  recorder,
+	}
+}
+
+func (h *PostElementalHostSupportBundleHandler) SetupOpenAPIOperation(oc openapi.OperationContext) error {
+	oc.SetSummary("Upload ElementalHost support bundle")
+	oc.SetDescription("This endpoint stores the ElementalHost support bundle, replacing any previous one.")
+
+	oc.AddReqStructure(SupportBundleRequest{})
+
+	oc.AddRespStructure(nil, WithDecoration("If the support bundle was stored", "text/html", http.StatusCreated))
+	oc.AddRespStructure(nil, WithDecoration("If the ElementalRegistration or ElementalHost are not found", "text/html", http.StatusNotFound))
+	oc.AddRespStructure(nil, WithDecoration("If the SupportBundle request is badly formatted", "text/html", http.StatusBadRequest))
+	oc.AddRespStructure(nil, WithDecoration("If the support bundle files exceed the maximum size", "text/html", http.StatusRequestEntityTooLarge))
+	oc.AddRespStructure(nil, WithDecoration("If a Secret with the support bundle name exists and does not belong to the ElementalHost", "text/html", http.StatusConflict))
+	oc.AddRespStructure(nil, WithDecoration("If the 'Authorization' header does not contain a Bearer token", "text/html", http.StatusUnauthorized))
+	oc.AddRespStructure(nil, WithDecoration("If the 'Authorization' token is not valid", "text/html", http.StatusForbidden))
+	oc.AddRespStructure(nil, WithDecoration("", "text/html", http.StatusInternalServerError))
+
+	return nil
+}
+
+func (h *PostElementalHostSupportBundleHandler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	pathVars := mux.Vars(request)
+	namespace := html.EscapeString(pathVars["namespace"])
+	registrationName := html.EscapeString(pathVars["registrationName"])
+	hostName := html.EscapeString(pathVars["hostName"])
+
+	logger := h.logger.WithValues(log.KeyNamespace, namespace).
+		WithValues(log.KeyElementalRegistration, registrationName).
+		WithValues(log.KeyElementalHost, hostName)
+	logger.Info("Uploading ElementalHost support bundle")
+
+	// Fetch registration
+	registration := &infrastructurev1.ElementalRegistration{}
+	if err := h.k8sClient.Get(request.Context(), k8sclient.ObjectKey{Namespace: namespace, Name: registrationName}, registration); err != nil {
+		if k8sapierrors.IsNotFound(err) {
+			response.WriteHeader(http.StatusNotFound)
+			WriteResponse(logger, response, fmt.Sprintf("ElementalRegistration '%s' not found", registrationName))
+		} else {
+			logger.Error(err, "Could not fetch ElementalRegistration")
+			response.WriteHeader(http.StatusInternalServerError)
+			WriteResponse(logger, response, fmt.Sprintf("Could not fetch ElementalRegistration '%s'", registrationName))
+		}
+		return
+	}
+
+	// Fetch host
+	host := &infrastructurev1.ElementalHost{}
+	if err := h.k8sClient.Get(request.Context(), k8sclient.ObjectKey{Namespace: namespace, Name: hostName}, host); err != nil {
+		if k8sapierrors.IsNotFound(err) {
+			response.WriteHeader(http.StatusNotFound)
+			WriteResponse(logger, response, fmt.Sprintf("ElementalHost '%s' not found", hostName))
+		} else {
+			logger.Error(err, "Could not fetch ElementalHost")
+			response.WriteHeader(http.StatusInternalServerError)
+			WriteResponse(logger, response, fmt.Sprintf("Could not fetch ElementalHost '%s'", hostName))
+		}
+		return
+	}
+
+	// Authenticate Request
+	if err := h.auth.ValidateHostRequest(request, response, host, registration); err != nil {
+		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
+			logger.Info("Host request denied", "reason", err.Error())
+			h.recorder.Eventf(host, corev1.EventTypeWarning, "AuthenticationFailed", "Host request denied: %s", err.Error())
+			return
+		}
+		logger.Error(err, "Could not authenticate host request")
+		return
+	}
+
+	// Unmarshal POST request body.
+	// Files are base64 encoded, the request can be larger than the support bundle itself.
+	request.Body = http.MaxBytesReader(response, request.Body, 2*MaxSupportBundleSize)
+	supportBundleRequest := &SupportBundleRequest{}
+	if err := json.NewDecoder(request.Body).Decode(supportBundleRequest); err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			response.WriteHeader(http.StatusRequestEntityTooLarge)
+			WriteResponse(logger, response, fmt.Sprintf("Support bundle request exceeds %d bytes", maxBytesError.Limit))
+			return
+		}
+		response.WriteHeader(http.StatusBadRequest)
+		WriteResponse(logger, response, fmt.Errorf("Could not decode request: %w", err).Error())
+		return
+	}
+
+	// Validate POST request
+	if size := supportBundleRequest.size(); size > MaxSupportBundleSize {
+		response.WriteHeader(http.StatusRequestEntityTooLarge)
+		WriteResponse(logger, response, fmt.Sprintf("Support bundle size %d exceeds %d bytes", size, MaxSupportBundleSize))
+		return
+	}
+	for fileName := range supportBundleRequest.Files {
+		if errs := validation.IsConfigMapKey(fileName); len(errs) > 0 {
+			response.WriteHeader(http.StatusBadRequest)
+			WriteResponse(logger, response, fmt.Sprintf("Invalid support bundle file name '%s': %s", fileName, strings.Join(errs, ", ")))
+			return
+		}
+	}
+
+	// Store the support bundle, replacing the previous one
+	secret := supportBundleRequest.toSecret(host)
+	if err := h.k8sClient.Create(request.Context(), secret); err != nil {
+		if !k8sapierrors.IsAlreadyExists(err) {
+			logger.Error(err, "Could not create support bundle Secret")
+			response.WriteHeader(http.StatusInternalServerError)
+			WriteResponse(logger, response, "Could not store support bundle")
+			return
+		}
+		existingSecret := &corev1.Secret{}
+		if err := h.k8sClient.Get(request.Context(), k8sclient.ObjectKeyFromObject(secret), existingSecret); err != nil {
+			logger.Error(err, "Could not fetch support bundle Secret")
+			response.WriteHeader(http.StatusInternalServerError)
+			WriteResponse(logger, response, "Could not store support bundle")
+			return
+		}
+		if !isSupportBundleSecret(existingSecret, host) {
+			logger.Info("Secret does not belong to the ElementalHost, not replacing it", log.KeySupportBundleSecret, secret.Name)
+			response.WriteHeader(http.StatusConflict)
+			WriteResponse(logger, response, fmt.Sprintf("Secret '%s' already exists and does not store the ElementalHost '%s' support bundle", secret.Name, hostName))
+			return
+		}
+		existingSecret.Labels = secret.Labels
+		existingSecret.OwnerReferences = secret.OwnerReferences
+		existingSecret.Data = secret.Data
+		if err := h.k8sClient.Update(request.Context(), existingSecret); err != nil {
+			logger.Error(err, "Could not update support bundle Secret")
+			response.WriteHeader(http.StatusInternalServerError)
+			WriteResponse(logger, response, "Could not store support bundle")
+			return
+		}
+	}
+
+	// Reference the support bundle from the ElementalHost status
+	patchHelper, err := patch.NewHelper(host, h.k8sClient)
+	if err != nil {
+		logger.Error(err, "Initializing ElementalHost patch helper")
+		response.WriteHeader(http.StatusInternalServerError)
+		WriteResponse(logger, response, "Could not initialize ElementalHost patch helper")
+		return
+	}
+	host.Status.SupportBundle = &infrastructurev1.SupportBundleStatus{
+		SecretName: secret.Name,
+		Reason:     supportBundleRequest.Reason,
+		UploadedAt: metav1.Now(),
+	}
+	if err := patchHelper.Patch(request.Context(), host); err != nil {
+		logger.Error(err, "Could not patch ElementalHost")
+		response.WriteHeader(http.StatusInternalServerError)
+		WriteResponse(logger, response, fmt.Sprintf("Could not patch ElementalHost '%s'", hostName))
+		return
+	}
+
+	logger.Info("ElementalHost support bundle stored", log.KeySupportBundleSecret, secret.Name)
+	h.recorder.Eventf(host, corev1.EventTypeNormal, "SupportBundleUploaded", "Support bundle stored in Secret '%s': %s", secret.Name, supportBundleRequest.Reason)
+	response.WriteHeader(http.StatusCreated)
+}
+
+var _ OpenAPIDecoratedHandler = (*PostElementalHostDiagnosticHandler)(nil)
+var _ http.Handler = (*PostElementalHostDiagnosticHandler)(nil)
+
+type PostElementalHostDiagnosticHandler struct {
+	logger    logr.Logger
+	k8sClient client.Client
+	auth      Authenticator
+	recorder  record.EventRecorder
+}
+
+func NewPostElementalHostDiagnosticHandler(logger logr.Logger, k8sClient client.Client, recorder record.EventRecorder) *PostElementalHostDiagnosticHandler {
+	return &PostElementalHostDiagnosticHandler{
+		logger:    logger,
+		k8sClient: k8sClient,
+		auth:      NewAuthenticator(k8sClient, logger),
+		recorder:  recorder,
+	}
+}
+
+func (h *PostElementalHostDiagnosticHandler) SetupOpenAPIOperation(oc openapi.OperationContext) error {
+	oc.SetSummary("Post ElementalHostDiagnostic results")
+	oc.SetDescription("This endpoint stores the results of the ElementalHostDiagnostic probes executed by the ElementalHost.")
+
+	oc.AddReqStructure(DiagnosticResultsRequest{})
+
+	oc.AddRespStructure(nil, WithDecoration("If the probe results were stored", "text/html", http.StatusCreated))
+	oc.AddRespStructure(nil, WithDecoration("If the ElementalRegistration, ElementalHost, or ElementalHostDiagnostic are not found", "text/html", http.StatusNotFound))
+	oc.AddRespStructure(nil, WithDecoration("If the DiagnosticResults request is badly formatted, or contains probes that were not requested", "text/html", http.StatusBadRequest))
+	oc.AddRespStructure(nil, WithDecoration("If the ElementalHostDiagnostic results were already posted", "text/html", http.StatusConflict))
+	oc.AddRespStructure(nil, WithDecoration("If the 'Authorization' header does not contain a Bearer token", "text/html", http.StatusUnauthorized))
+	oc.AddRespStructure(nil, WithDecoration("If the 'Authorization' token is not valid", "text/html", http.StatusForbidden))
+	oc.AddRespStructure(nil, WithDecoration("", "text/html", http.StatusInternalServerError))
+
+	return nil
+}
+
+func (h *PostElementalHostDiagnosticHandler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	pathVars := mux.Vars(request)
+	namespace := html.EscapeString(pathVars["namespace"])
+	registrationName := html.EscapeString(pathVars["registrationName"])
+	hostName := html.EscapeString(pathVars["hostName"])
+	diagnosticName := html.EscapeString(pathVars["diagnosticName"])
+
+	logger := h.logger.WithValues(log.KeyNamespace, namespace).
+		WithValues(log.KeyElementalRegistration, registrationName).
+		WithValues(log.KeyElementalHost, hostName).
+		WithValues(log.KeyElementalHostDiagnostic, diagnosticName)
+	logger.Info("Posting ElementalHostDiagnostic results")
+
+	// Fetch registration
+	registration := &infrastructurev1.ElementalRegistration{}
+	if err := h.k8sClient.Get(request.Context(), k8sclient.ObjectKey{Namespace: namespace, Name: registrationName}, registration); err != nil {
+		if k8sapierrors.IsNotFound(err) {
+			response.WriteHeader(http.StatusNotFound)
+			WriteResponse(logger, response, fmt.Sprintf("ElementalRegistration '%s' not found", registrationName))
+		} else {
+			logger.Error(err, "Could not fetch ElementalRegistration")
+			response.WriteHeader(http.StatusInternalServerError)
+			WriteResponse(logger, response, fmt.Sprintf("Could not fetch ElementalRegistration '%s'", registrationName))
+		}
+		return
+	}
+
+	// Fetch host
+	host := &infrastructurev1.ElementalHost{}
+	if err := h.k8sClient.Get(request.Context(), k8sclient.ObjectKey{Namespace: namespace, Name: hostName}, host); err != nil {
+		if k8sapierrors.IsNotFound(err) {
+			response.WriteHeader(http.StatusNotFound)
+			WriteResponse(logger, response, fmt.Sprintf("ElementalHost '%s' not found", hostName))
+		} else {
+			logger.Error(err, "Could not fetch ElementalHost")
+			response.WriteHeader(http.StatusInternalServerError)
+			WriteResponse(logger, response, fmt.Sprintf("Could not fetch ElementalHost '%s'", hostName))
+		}
+		return
+	}
+
+	// Authenticate Request
+	if err := h.auth.ValidateHostRequest(request, response, host, registration); err != nil {
+		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
+			logger.Info("Host request denied", "reason", err.Error())
+			h.recorder.Eventf(host, corev1.EventTypeWarning, "AuthenticationFailed", "Host request denied: %s", err.Error())
+			return
+		}
+		logger.Error(err, "Could not authenticate host request")
+		return
+	}
+
+	// Fetch diagnostic. Hosts can only post results of their own diagnostics.
+	diagnostic := &infrastructurev1.ElementalHostDiagnostic{}
+	if err := h.k8sClient.Get(request.Context(), k8sclient.ObjectKey{Namespace: namespace, Name: diagnosticName}, diagnostic); err != nil {
+		if k8sapierrors.IsNotFound(err) {
+			response.WriteHeader(http.StatusNotFound)
+			WriteResponse(logger, response, fmt.Sprintf("ElementalHostDiagnostic '%s' not found", diagnosticName))
+		} else {
+			logger.Error(err, "Could not fetch ElementalHostDiagnostic")
+			response.WriteHeader(http.StatusInternalServerError)
+			WriteResponse(logger, response, fmt.Sprintf("Could not fetch ElementalHostDiagnostic '%s'", diagnosticName))
+		}
+		return
+	}
+	if diagnostic.Spec.HostRef.Name != host.Name {
+		response.WriteHeader(http.StatusNotFound)
+		WriteResponse(logger, response, fmt.Sprintf("ElementalHostDiagnostic '%s' not found", diagnosticName))
+		return
+	}
+	if diagnostic.IsCompleted() {
+		response.WriteHeader(http.StatusConflict)
+		WriteResponse(logger, response, fmt.Sprintf("ElementalHostDiagnostic '%s' results were already posted", diagnosticName))
+		return
+	}
+
+	// Unmarshal POST request body
+	diagnosticResultsRequest := &DiagnosticResultsRequest{}
+	if err := json.NewDecoder(request.Body).Decode(diagnosticResultsRequest); err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		WriteResponse(logger, response, fmt.Errorf("Could not decode request: %w", err).Error())
+		return
+	}
+
+	// Validate POST request
+	if err := diagnosticResultsRequest.validate(*diagnostic); err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		WriteResponse(logger, response, fmt.Sprintf("Invalid diagnostic results: %s", err.Error()))
+		return
+	}
+
+	// Store the results
+	patchHelper, err := patch.NewHelper(diagnostic, h.k8sClient)
+	if err != nil {
+		logger.Error(err, "Initializing ElementalHostDiagnostic patch helper")
+		response.WriteHeader(http.StatusInternalServerError)
+		WriteResponse(logger, response, "Could not initialize ElementalHostDiagnostic patch helper")
+		return
+	}
+	diagnostic.Status.Results = diagnosticResultsRequest.Results
+	diagnostic.Status.CompletedAt = &metav1.Time{Time: time.Now()}
+	if err := patchHelper.Patch(request.Context(), diagnostic); err != nil {
+		logger.Error(err, "Could not patch ElementalHostDiagnostic")
+		response.WriteHeader(http.StatusInternalServerError)
+		WriteResponse(logger, response, fmt.Sprintf("Could not patch ElementalHostDiagnostic '%s'", diagnosticName))
+		return
+	}
+
+	logger.Info("ElementalHostDiagnostic results stored")
+	h.recorder.Eventf(diagnostic, corev1.EventTypeNormal, "DiagnosticCompleted", "Probes executed by ElementalHost '%s'", host.Name)
+	response.WriteHeader(http.StatusCreated)
+}
@@ -12,7 +12,9 @@ import (
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/log"
 	"github.com/swaggest/openapi-go"
+	corev1 "k8s.io/api/core/v1"
 	k8sapierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -24,13 +26,15 @@ type GetElementalRegistrationHandler struct {
 	logger    logr.Logger
 	k8sClient client.Client
 	auth      Authenticator
+	recorder  record.EventRecorder
 }
 
-func NewGetElementalRegistrationHandler(logger logr.Logger, k8sClient client.Client) *GetElementalRegistrationHandler {
+func NewGetElementalRegistrationHandler(logger logr.Logger, k8sClient client.Client, recorder record.EventRecorder) *GetElementalRegistrationHandler {
 	return &GetElementalRegistrationHandler{
 		logger:    logger,
 		k8sClient: k8sClient,
 		auth:      NewAuthenticator(k8sClient, logger),
+		recorder:  recorder,
 	}
 }
 
@@ -73,9 +77,11 @@ func (h *GetElementalRegistrationHandler) ServeHTTP(response http.ResponseWriter
 	}
 
 	// Authenticate Registration token
-	if err := h.auth.ValidateRegistrationRequest(request, response, registration); err != nil {
+	reservation, err := h.auth.ValidateRegistrationRequest(request, response, registration)
+	if err != nil {
 		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
 			logger.Info("Registration request denied", "reason", err.Error())
+			h.recorder.Eventf(registration, corev1.EventTypeWarning, "AuthenticationFailed", "Registration request denied: %s", err.Error())
 			return
 		}
 		logger.Error(err, "Could not authenticate registration request")
@@ -84,6 +90,9 @@ func (h *GetElementalRegistrationHandler) ServeHTTP(response http.ResponseWriter
 
 	registrationResponse := RegistrationResponse{}
 	registrationResponse.fromElementalRegistration(*registration)
+	if reservation != nil {
+		registrationResponse.applyReservation(*reservation)
+	}
 
 	// Serialize to JSON
 	responseBytes, err := json.Marshal(registrationResponse)
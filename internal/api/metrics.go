@@ -0,0 +1,63 @@
+package api
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+)
+
+// unknownSystemModel is used when the elemental-agent did not report the host hardware model.
+const unknownSystemModel = "unknown"
+
+// hostPhaseDuration is exposed on the manager metrics endpoint.
+var hostPhaseDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "elemental_host_phase_duration_seconds",
+		Help:    "Duration of the ElementalHost phases reported by the elemental-agent.",
+		Buckets: []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200},
+	},
+	[]string{"phase", "outcome", "model"},
+)
+
+// Elemental API TLS certificate reload outcomes.
+const (
+	certificateReloadSuccess = "success"
+	certificateReloadFailure = "failure"
+)
+
+// certificateReloads counts the Elemental API TLS certificate reloads by outcome.
+var certificateReloads = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "elemental_api_certificate_reloads_total",
+		Help: "Total number of Elemental API TLS certificate reloads.",
+	},
+	[]string{"outcome"},
+)
+
+// certificateExpiration exposes the expiration of the served Elemental API TLS certificate.
+var certificateExpiration = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "elemental_api_certificate_expiration_timestamp_seconds",
+		Help: "Expiration time of the served Elemental API TLS certificate, in seconds since the Unix epoch.",
+	},
+)
+
+func init() {
+	metrics.Registry.MustRegister(hostPhaseDuration, certificateReloads, certificateExpiration)
+}
+
+// observePhaseDuration records the duration of the last completed phase of the ElementalHost.
+func observePhaseDuration(elementalHost infrastructurev1.ElementalHost) {
+	record := lastCompletedPhase(elementalHost)
+	if record == nil {
+		return
+	}
+	model := elementalHost.Status.SystemModel
+	if len(model) == 0 {
+		model = unknownSystemModel
+	}
+	hostPhaseDuration.
+		WithLabelValues(string(record.Phase), string(record.Outcome), model).
+		Observe(record.EndTime.Sub(record.StartTime.Time).Seconds())
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// registrationRateLimiter limits the rate of new ElementalHost registrations for each ElementalRegistration.
+type registrationRateLimiter struct {
+	mutex    sync.Mutex
+	limiters map[types.NamespacedName]*rate.Limiter
+}
+
+func newRegistrationRateLimiter() *registrationRateLimiter {
+	return &registrationRateLimiter{
+		limiters: map[types.NamespacedName]*rate.Limiter{},
+	}
+}
+
+// Allow returns true if a new registration is allowed within the registrations per minute limit.
+// If not allowed, it returns the delay after which a new registration will be allowed.
+func (l *registrationRateLimiter) Allow(registration types.NamespacedName, perMinute int32) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	limit := rate.Every(time.Minute / time.Duration(perMinute))
+	limiter, found := l.limiters[registration]
+	if !found {
+		limiter = rate.NewLimiter(limit, int(perMinute))
+		l.limiters[registration] = limiter
+	} else if limiter.Burst() != int(perMinute) {
+		// The limit was updated on the ElementalRegistration
+		limiter.SetLimit(limit)
+		limiter.SetBurst(int(perMinute))
+	}
+
+	reservation := limiter.Reserve()
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return false, delay
+	}
+	return true, 0
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/gorilla/mux"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -20,25 +22,27 @@ const (
 )
 
 type Server struct {
-	context     context.Context
-	port        uint
-	k8sClient   client.Client
-	httpServer  *http.Server
-	logger      logr.Logger
-	useTLS      bool
-	privKey     string
-	certificate string
+	context    context.Context
+	port       uint
+	k8sClient  client.Client
+	recorder   record.EventRecorder
+	httpServer *http.Server
+	logger     logr.Logger
+	// heartbeatGracePeriod is the time after which an ElementalHost not seen is flagged as unreachable.
+	heartbeatGracePeriod time.Duration
+	// certificateLoader serves the TLS certificate. The server listens for plain HTTP connections if nil.
+	certificateLoader *CertificateLoader
 }
 
-func NewServer(ctx context.Context, k8sClient client.Client, port uint, useTLS bool, privKey string, certificate string) *Server {
+func NewServer(ctx context.Context, k8sClient client.Client, recorder record.EventRecorder, port uint, heartbeatGracePeriod time.Duration, certificateLoader *CertificateLoader) *Server {
 	return &Server{
-		context:     ctx,
-		port:        port,
-		k8sClient:   k8sClient,
-		logger:      log.FromContext(ctx),
-		useTLS:      useTLS,
-		privKey:     privKey,
-		certificate: certificate,
+		context:              ctx,
+		port:                 port,
+		k8sClient:            k8sClient,
+		recorder:             recorder,
+		logger:               log.FromContext(ctx),
+		heartbeatGracePeriod: heartbeatGracePeriod,
+		certificateLoader:    certificateLoader,
 	}
 }
 
@@ -47,25 +51,37 @@ func (s *Server) NewRouter() *mux.Router {
 	elementalV1 := router.PathPrefix(fmt.Sprintf("%s%s", Prefix, PrefixV1)).Subrouter()
 
 	elementalV1.Handle("/namespaces/{namespace}/registrations/{registrationName}",
-		NewGetElementalRegistrationHandler(s.logger, s.k8sClient)).
+		NewGetElementalRegistrationHandler(s.logger, s.k8sClient, s.recorder)).
 		Methods(http.MethodGet)
 
 	elementalV1.Handle("/namespaces/{namespace}/registrations/{registrationName}/hosts",
-		NewPostElementalHostHandler(s.logger, s.k8sClient)).
+		NewPostElementalHostHandler(s.logger, s.k8sClient, s.recorder)).
 		Methods(http.MethodPost)
 
 	elementalV1.Handle("/namespaces/{namespace}/registrations/{registrationName}/hosts/{hostName}",
-		NewDeleteElementalHostHandler(s.logger, s.k8sClient)).
+		NewDeleteElementalHostHandler(s.logger, s.k8sClient, s.recorder)).
 		Methods(http.MethodDelete)
 
 	elementalV1.Handle("/namespaces/{namespace}/registrations/{registrationName}/hosts/{hostName}",
-		NewPatchElementalHostHandler(s.logger, s.k8sClient)).
+		NewPatchElementalHostHandler(s.logger, s.k8sClient, s.recorder, s.heartbeatGracePeriod)).
 		Methods(http.MethodPatch)
 
 	elementalV1.Handle("/namespaces/{namespace}/registrations/{registrationName}/hosts/{hostName}/bootstrap",
-		NewGetElementalHostBootstrapHandler(s.logger, s.k8sClient)).
+		NewGetElementalHostBootstrapHandler(s.logger, s.k8sClient, s.recorder)).
 		Methods(http.MethodGet)
 
+	elementalV1.Handle("/namespaces/{namespace}/registrations/{registrationName}/hosts/{hostName}/registration",
+		NewGetElementalHostRegistrationHandler(s.logger, s.k8sClient, s.recorder)).
+		Methods(http.MethodGet)
+
+	elementalV1.Handle("/namespaces/{namespace}/registrations/{registrationName}/hosts/{hostName}/support-bundle",
+		NewPostElementalHostSupportBundleHandler(s.logger, s.k8sClient, s.recorder)).
+		Methods(http.MethodPost)
+
+	elementalV1.Handle("/namespaces/{namespace}/registrations/{registrationName}/hosts/{hostName}/diagnostics/{diagnosticName}",
+		NewPostElementalHostDiagnosticHandler(s.logger, s.k8sClient, s.recorder)).
+		Methods(http.MethodPost)
+
 	return router
 }
 
@@ -79,10 +95,23 @@ func (s *Server) Start(ctx context.Context) error {
 		ReadTimeout:  30 * time.Second,
 	}
 
+	if s.certificateLoader != nil {
+		s.httpServer.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: s.certificateLoader.GetCertificate,
+		}
+		go func() {
+			if err := s.certificateLoader.Start(ctx); err != nil {
+				s.logger.Error(err, "watching Elemental API TLS certificate, certificate renewals require a restart")
+			}
+		}()
+	}
+
 	go func() {
 		var err error
-		if s.useTLS {
-			err = s.httpServer.ListenAndServeTLS(s.certificate, s.privKey)
+		if s.certificateLoader != nil {
+			// The certificate is served by the loader
+			err = s.httpServer.ListenAndServeTLS("", "")
 		} else {
 			err = s.httpServer.ListenAndServe()
 		}
@@ -1,13 +1,22 @@
 package api
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
 
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 	"golang.org/x/exp/maps"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -15,6 +24,16 @@ import (
 
 var ErrBootstrapSecretNoConfig = errors.New("CAPI bootstrap secret does not contain any config")
 
+const (
+	// phaseHistoryLimit is the maximum number of phases recorded in the ElementalHost status.
+	phaseHistoryLimit = 20
+	// MaxSupportBundleSize is the maximum total size of the support bundle files, in bytes.
+	// Support bundles are stored in a Secret, that is limited to 1MiB.
+	MaxSupportBundleSize = 512 * 1024
+	// MaxDiagnosticOutputSize is the maximum size of each diagnostic probe output, in bytes.
+	MaxDiagnosticOutputSize = 16 * 1024
+)
+
 type HostCreateRequest struct {
 	Auth    string `header:"Authorization"`
 	RegAuth string `header:"Registration-Authorization"`
@@ -26,15 +45,22 @@ type HostCreateRequest struct {
 	Annotations map[string]string `json:"annotations,omitempty"`
 	Labels      map[string]string `json:"labels,omitempty"`
 	PubKey      string            `json:"pubKey,omitempty"`
+
+	SerialNumber string   `json:"serialNumber,omitempty"`
+	MACAddresses []string `json:"macAddresses,omitempty"`
 }
 
 func (h *HostCreateRequest) toElementalHost(namespace string) infrastructurev1.ElementalHost {
+	annotations := map[string]string{}
+	maps.Copy(annotations, h.Annotations)
+	// The approval can only be granted by the ElementalRegistration or by the end user
+	delete(annotations, infrastructurev1.AnnotationElementalHostApproved)
 	return infrastructurev1.ElementalHost{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        h.Name,
 			Namespace:   namespace,
 			Labels:      h.Labels,
-			Annotations: h.Annotations,
+			Annotations: annotations,
 		},
 		Spec: infrastructurev1.ElementalHostSpec{
 			PubKey: h.PubKey,
@@ -42,6 +68,32 @@ func (h *HostCreateRequest) toElementalHost(namespace string) infrastructurev1.E
 	}
 }
 
+// isAutoApproved returns true if the host matches any of the expected serial numbers or MAC addresses.
+func (h *HostCreateRequest) isAutoApproved(policy *infrastructurev1.AutoApprovePolicy) bool {
+	if policy == nil {
+		return false
+	}
+	if len(h.SerialNumber) > 0 && slices.Contains(policy.SerialNumbers, h.SerialNumber) {
+		return true
+	}
+	for _, macAddress := range h.MACAddresses {
+		if slices.ContainsFunc(policy.MACAddresses, func(expected string) bool { return isSameMACAddress(macAddress, expected) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSameMACAddress compares two MAC addresses, regardless of their format.
+func isSameMACAddress(a string, b string) bool {
+	hardwareAddrA, errA := net.ParseMAC(a)
+	hardwareAddrB, errB := net.ParseMAC(b)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(a, b)
+	}
+	return bytes.Equal(hardwareAddrA, hardwareAddrB)
+}
+
 type HostDeleteRequest struct {
 	Auth string `header:"Authorization"`
 
@@ -57,12 +109,21 @@ type HostPatchRequest struct {
 	RegistrationName string `path:"registrationName"`
 	HostName         string `path:"hostName"`
 
-	Annotations   map[string]string `json:"annotations,omitempty"`
-	Labels        map[string]string `json:"labels,omitempty"`
-	Bootstrapped  *bool             `json:"bootstrapped,omitempty"`
-	Installed     *bool             `json:"installed,omitempty"`
-	Reset         *bool             `json:"reset,omitempty"`
-	InPlaceUpdate *string           `json:"inPlaceUpdate,omitempty"`
+	Annotations   map[string]string   `json:"annotations,omitempty"`
+	Labels        map[string]string   `json:"labels,omitempty"`
+	Bootstrapped  *bool               `json:"bootstrapped,omitempty"`
+	Installed     *bool               `json:"installed,omitempty"`
+	Reset         *bool               `json:"reset,omitempty"`
+	OperationID   *string             `json:"operationID,omitempty"`
+	InPlaceUpdate *string             `json:"inPlaceUpdate,omitempty"`
+	SystemUUID    *string             `json:"systemUUID,omitempty"`
+	SystemModel   *string             `json:"systemModel,omitempty"`
+	Addresses     []string            `json:"addresses,omitempty"`
+	Capacity      corev1.ResourceList `json:"capacity,omitempty"`
+
+	AgentUpdateFailure *infrastructurev1.AgentUpdateFailure `json:"agentUpdateFailure,omitempty"`
+	AgentConfigHash    *string                              `json:"agentConfigHash,omitempty"`
+	TrustedCAs         []string                             `json:"trustedCAs,omitempty"`
 
 	Condition *clusterv1.Condition        `json:"condition,omitempty"`
 	Phase     *infrastructurev1.HostPhase `json:"phase,omitempty"`
@@ -85,8 +146,14 @@ func (h *HostPatchRequest) applyToElementalHost(elementalHost *infrastructurev1.
 	if elementalHost.Labels == nil {
 		elementalHost.Labels = map[string]string{}
 	}
+	approved, approvalFound := elementalHost.Annotations[infrastructurev1.AnnotationElementalHostApproved]
 	maps.Copy(elementalHost.Annotations, h.Annotations)
 	maps.Copy(elementalHost.Labels, h.Labels)
+	// The approval can not be changed by the elemental-agent
+	delete(elementalHost.Annotations, infrastructurev1.AnnotationElementalHostApproved)
+	if approvalFound {
+		elementalHost.Annotations[infrastructurev1.AnnotationElementalHostApproved] = approved
+	}
 	// Map request values to ElementalHost labels
 	if h.Installed != nil {
 		elementalHost.Labels[infrastructurev1.LabelElementalHostInstalled] = "true"
@@ -97,9 +164,36 @@ func (h *HostPatchRequest) applyToElementalHost(elementalHost *infrastructurev1.
 	if h.Reset != nil {
 		elementalHost.Labels[infrastructurev1.LabelElementalHostReset] = "true"
 	}
+	// Acknowledge the pending operation
+	if h.OperationID != nil && elementalHost.Status.Operation != nil && elementalHost.Status.Operation.ID == *h.OperationID {
+		elementalHost.Status.Operation.AcknowledgedAt = &metav1.Time{Time: time.Now()}
+	}
 	if h.InPlaceUpdate != nil {
 		elementalHost.Labels[infrastructurev1.LabelElementalHostInPlaceUpdate] = *h.InPlaceUpdate
 	}
+	// Map the reported host identity and inventory to the ElementalHost status
+	if h.SystemUUID != nil {
+		elementalHost.Status.SystemUUID = *h.SystemUUID
+	}
+	if h.SystemModel != nil {
+		elementalHost.Status.SystemModel = *h.SystemModel
+	}
+	if h.Addresses != nil {
+		elementalHost.Status.Addresses = h.Addresses
+	}
+	if h.Capacity != nil {
+		elementalHost.Status.Capacity = h.Capacity
+	}
+	if h.AgentUpdateFailure != nil {
+		elementalHost.Status.AgentUpdateFailure = h.AgentUpdateFailure.DeepCopy()
+		elementalHost.Status.AgentUpdateFailure.FailedAt = metav1.Now()
+	}
+	if h.AgentConfigHash != nil {
+		elementalHost.Status.AgentConfigHash = *h.AgentConfigHash
+	}
+	if h.TrustedCAs != nil {
+		elementalHost.Status.TrustedCAs = h.TrustedCAs
+	}
 	if elementalHost.Status.Conditions == nil {
 		elementalHost.Status.Conditions = clusterv1.Conditions{}
 	}
@@ -108,11 +202,54 @@ func (h *HostPatchRequest) applyToElementalHost(elementalHost *infrastructurev1.
 	// Always update the Summary after conditions change
 	conditions.SetSummary(elementalHost)
 
+	if h.Phase != nil && *h.Phase != elementalHost.Status.Phase {
+		recordPhase(elementalHost, *h.Phase, metav1.Now())
+	}
 	if h.Phase != nil {
 		elementalHost.Status.Phase = *h.Phase
 	}
 }
 
+// recordPhase ends the current phase in the ElementalHost phase history, and starts the new one.
+// The outcome of the ended phase depends on the ElementalHost Ready condition, that summarizes the errors reported so far.
+func recordPhase(elementalHost *infrastructurev1.ElementalHost, phase infrastructurev1.HostPhase, now metav1.Time) {
+	history := elementalHost.Status.PhaseHistory
+	if len(history) > 0 && history[len(history)-1].EndTime == nil {
+		current := &history[len(history)-1]
+		current.EndTime = &now
+		current.Outcome = infrastructurev1.PhaseSucceeded
+		if ready := conditions.Get(elementalHost, clusterv1.ReadyCondition); ready != nil &&
+			ready.Status == corev1.ConditionFalse && ready.Severity == clusterv1.ConditionSeverityError {
+			current.Outcome = infrastructurev1.PhaseFailed
+			current.Reason = ready.Reason
+		}
+	}
+	history = append(history, infrastructurev1.HostPhaseRecord{Phase: phase, StartTime: now})
+	if len(history) > phaseHistoryLimit {
+		history = history[len(history)-phaseHistoryLimit:]
+	}
+	elementalHost.Status.PhaseHistory = history
+}
+
+// lastCompletedPhase returns the most recent phase that has ended, if any.
+func lastCompletedPhase(elementalHost infrastructurev1.ElementalHost) *infrastructurev1.HostPhaseRecord {
+	for i := len(elementalHost.Status.PhaseHistory) - 1; i >= 0; i-- {
+		if elementalHost.Status.PhaseHistory[i].EndTime != nil {
+			return &elementalHost.Status.PhaseHistory[i]
+		}
+	}
+	return nil
+}
+
+// changesState returns true if the request would move the ElementalHost to a different phase or state.
+// Labels, annotations, and conditions updates are not considered state changes.
+func (h *HostPatchRequest) changesState(elementalHost infrastructurev1.ElementalHost) bool {
+	if h.Phase != nil && *h.Phase != elementalHost.Status.Phase {
+		return true
+	}
+	return h.Installed != nil || h.Bootstrapped != nil || h.Reset != nil || h.InPlaceUpdate != nil || h.OperationID != nil
+}
+
 type HostResponse struct {
 	Name                string                          `json:"name,omitempty"`
 	Annotations         map[string]string               `json:"annotations,omitempty"`
@@ -121,8 +258,52 @@ type HostResponse struct {
 	Bootstrapped        bool                            `json:"bootstrapped,omitempty"`
 	Installed           bool                            `json:"installed,omitempty"`
 	NeedsReset          bool                            `json:"needsReset,omitempty"`
+	PendingApproval     bool                            `json:"pendingApproval,omitempty"`
+	Operation           *infrastructurev1.HostOperation `json:"operation,omitempty"`
 	InPlaceUpgrade      string                          `json:"inPlaceUpgrade,omitempty"`
 	OSVersionManagement map[string]runtime.RawExtension `json:"osVersionManagement,omitempty" yaml:"osVersionManagement,omitempty"`
+	Diagnostics         []HostDiagnostic                `json:"diagnostics,omitempty"`
+	AgentUpdate         *infrastructurev1.AgentUpdate   `json:"agentUpdate,omitempty"`
+}
+
+// agentUserAgentPrefix prefixes the elemental-agent version in the User-Agent header of its requests.
+const agentUserAgentPrefix = "elemental-agent/"
+
+// agentVersion returns the elemental-agent version from the User-Agent header, if any.
+func agentVersion(userAgent string) string {
+	version, found := strings.CutPrefix(userAgent, agentUserAgentPrefix)
+	if !found {
+		return ""
+	}
+	return version
+}
+
+// lastSeenExpired returns true if the ElementalHost heartbeat must be refreshed.
+// The heartbeat is refreshed at most once every quarter of the grace period, so that periodic
+// agent patches do not update the ElementalHost, and trigger its reconciliation, every time.
+// A zero grace period always refreshes the heartbeat.
+func lastSeenExpired(elementalHost infrastructurev1.ElementalHost, gracePeriod time.Duration, now time.Time) bool {
+	if elementalHost.Status.LastSeen == nil {
+		return true
+	}
+	return now.Sub(elementalHost.Status.LastSeen.Time) >= gracePeriod/4
+}
+
+// reconcileAgentVersion records the running elemental-agent version,
+// and clears the last update failure once the desired version, if any, is running.
+func reconcileAgentVersion(elementalHost *infrastructurev1.ElementalHost, registration infrastructurev1.ElementalRegistration, version string) {
+	if len(version) > 0 {
+		elementalHost.Status.AgentVersion = version
+	}
+	if registration.Spec.AgentUpdate == nil || registration.Spec.AgentUpdate.Version == elementalHost.Status.AgentVersion {
+		elementalHost.Status.AgentUpdateFailure = nil
+	}
+}
+
+// HostDiagnostic defines the probes of a pending ElementalHostDiagnostic to be executed by the elemental-agent.
+type HostDiagnostic struct {
+	Name   string                             `json:"name"`
+	Probes []infrastructurev1.DiagnosticProbe `json:"probes"`
 }
 
 func (h *HostResponse) fromElementalHost(elementalHost infrastructurev1.ElementalHost) {
@@ -130,6 +311,8 @@ func (h *HostResponse) fromElementalHost(elementalHost infrastructurev1.Elementa
 	h.Annotations = elementalHost.Annotations
 	h.Labels = elementalHost.Labels
 	h.BootstrapReady = elementalHost.Spec.BootstrapSecret != nil
+	h.Operation = pendingOperation(elementalHost)
+	h.PendingApproval = elementalHost.IsPendingApproval()
 	if elementalHost.Labels == nil {
 		return
 	}
@@ -149,6 +332,20 @@ func (h *HostResponse) fromElementalHost(elementalHost infrastructurev1.Elementa
 	h.OSVersionManagement = elementalHost.Spec.OSVersionManagement
 }
 
+// pendingOperation returns the operation to be executed by the elemental-agent, if any.
+// An operation is pending until acknowledged, and it is only delivered after draining the Node, if requested.
+func pendingOperation(elementalHost infrastructurev1.ElementalHost) *infrastructurev1.HostOperation {
+	operation := elementalHost.Spec.Operation
+	status := elementalHost.Status.Operation
+	if operation == nil || status == nil || status.ID != operation.ID || status.AcknowledgedAt != nil {
+		return nil
+	}
+	if operation.Drain && !status.Drained {
+		return nil
+	}
+	return operation
+}
+
 type RegistrationGetRequest struct {
 	RegAuth string `header:"Registration-Authorization"`
 
@@ -156,7 +353,18 @@ type RegistrationGetRequest struct {
 	RegistrationName string `path:"registrationName"`
 }
 
+type HostRegistrationGetRequest struct {
+	Auth string `header:"Authorization"`
+
+	Namespace        string `path:"namespace"`
+	RegistrationName string `path:"registrationName"`
+	HostName         string `path:"hostName"`
+}
+
 type RegistrationResponse struct {
+	// HostName is the name reserved for the ElementalHost, if the request was authorized by an ElementalHostReservation.
+	// +optional
+	HostName string `json:"hostName,omitempty"`
 	// HostLabels are labels propagated to each ElementalHost object linked to this registration.
 	// +optional
 	HostLabels map[string]string `json:"hostLabels,omitempty"`
@@ -174,6 +382,51 @@ func (r *RegistrationResponse) fromElementalRegistration(elementalRegistration i
 	r.Config = elementalRegistration.Spec.Config
 }
 
+// applyReservation overrides the registration values with the ElementalHostReservation ones.
+// The registration token is replaced by the one-time token, so that it is never disclosed to reserved hosts.
+func (r *RegistrationResponse) applyReservation(reservation infrastructurev1.ElementalHostReservation) {
+	r.HostName = reservation.Name
+	r.HostLabels = mergeMaps(r.HostLabels, reservation.Spec.HostLabels)
+	r.HostAnnotations = mergeMaps(r.HostAnnotations, reservation.Spec.HostAnnotations)
+	r.Config.Elemental.Registration.Token = reservation.Status.Token
+	r.Config.Elemental.Install = mergeMaps(r.Config.Elemental.Install, reservation.Spec.Install)
+}
+
+// fromElementalRegistrationForHost maps the ElementalRegistration for an already registered host.
+// The registration token is never disclosed, since registered hosts authenticate with their own identity.
+func (r *RegistrationResponse) fromElementalRegistrationForHost(elementalRegistration infrastructurev1.ElementalRegistration) {
+	r.fromElementalRegistration(elementalRegistration)
+	r.Config.Elemental.Registration.Token = ""
+}
+
+// isReservedHost returns true if the host matches the ElementalHostReservation.
+// The host name must match the reservation name, and the host must report the expected serial number or MAC addresses, if any.
+func (h *HostCreateRequest) isReservedHost(reservation infrastructurev1.ElementalHostReservation) bool {
+	if h.Name != reservation.Name {
+		return false
+	}
+	if len(reservation.Spec.SerialNumber) > 0 && h.SerialNumber != reservation.Spec.SerialNumber {
+		return false
+	}
+	if len(reservation.Spec.MACAddresses) == 0 {
+		return true
+	}
+	for _, macAddress := range h.MACAddresses {
+		if slices.ContainsFunc(reservation.Spec.MACAddresses, func(expected string) bool { return isSameMACAddress(macAddress, expected) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeMaps returns a new map containing the base values, overridden by the overrides values.
+func mergeMaps[V any](base map[string]V, overrides map[string]V) map[string]V {
+	merged := make(map[string]V, len(base)+len(overrides))
+	maps.Copy(merged, base)
+	maps.Copy(merged, overrides)
+	return merged
+}
+
 type BootstrapGetRequest struct {
 	Auth string `header:"Authorization"`
 
@@ -198,3 +451,127 @@ func (b *BootstrapResponse) fromSecret(secret *corev1.Secret) error {
 	}
 	return ErrBootstrapSecretNoConfig
 }
+
+type SupportBundleRequest struct {
+	Auth string `header:"Authorization"`
+
+	Namespace        string `path:"namespace"`
+	RegistrationName string `path:"registrationName"`
+	HostName         string `path:"hostName"`
+
+	Reason string            `json:"reason,omitempty"`
+	Files  map[string][]byte `json:"files,omitempty"`
+}
+
+// size returns the total size of the support bundle files.
+func (s *SupportBundleRequest) size() int {
+	size := 0
+	for _, content := range s.Files {
+		size += len(content)
+	}
+	return size
+}
+
+// toSecret returns the Secret storing the support bundle files of the ElementalHost.
+func (s *SupportBundleRequest) toSecret(elementalHost *infrastructurev1.ElementalHost) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      supportBundleSecretName(elementalHost.Name),
+			Namespace: elementalHost.Namespace,
+			Labels: map[string]string{
+				infrastructurev1.LabelElementalHostSupportBundle: supportBundleLabelValue(elementalHost.Name),
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: infrastructurev1.GroupVersion.String(),
+					Kind:       "ElementalHost",
+					Name:       elementalHost.Name,
+					UID:        elementalHost.UID,
+				},
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: s.Files,
+	}
+}
+
+// isSupportBundleSecret returns true if the Secret stores the support bundle of the ElementalHost,
+// so that any other Secret with the same name is never overwritten.
+func isSupportBundleSecret(secret *corev1.Secret, elementalHost *infrastructurev1.ElementalHost) bool {
+	if secret.Labels[infrastructurev1.LabelElementalHostSupportBundle] != supportBundleLabelValue(elementalHost.Name) {
+		return false
+	}
+	for _, owner := range secret.OwnerReferences {
+		if owner.UID == elementalHost.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// supportBundleSecretName returns the '<host name>-support-bundle' Secret name.
+// Host names too long for a Secret name are truncated.
+func supportBundleSecretName(hostName string) string {
+	const suffix = "-support-bundle"
+	return truncateWithHash(hostName, validation.DNS1123SubdomainMaxLength-len(suffix)) + suffix
+}
+
+// supportBundleLabelValue returns the host name, truncated if too long for a label value.
+func supportBundleLabelValue(hostName string) string {
+	return truncateWithHash(hostName, validation.LabelValueMaxLength)
+}
+
+// truncateWithHash truncates the value to the maximum length, if longer.
+// Truncated values end with a short hash of the full value, to keep them unique.
+func truncateWithHash(value string, maxLength int) string {
+	if len(value) <= maxLength {
+		return value
+	}
+	sum := sha256.Sum256([]byte(value))
+	hash := hex.EncodeToString(sum[:])[:8]
+	return strings.TrimRight(value[:maxLength-len(hash)-1], "-.") + "-" + hash
+}
+
+type DiagnosticResultsRequest struct {
+	Auth string `header:"Authorization"`
+
+	Namespace        string `path:"namespace"`
+	RegistrationName string `path:"registrationName"`
+	HostName         string `path:"hostName"`
+	DiagnosticName   string `path:"diagnosticName"`
+
+	Results []infrastructurev1.DiagnosticProbeResult `json:"results,omitempty"`
+}
+
+// validate checks that the results only contain the probes requested by the ElementalHostDiagnostic, at most once each.
+func (d *DiagnosticResultsRequest) validate(diagnostic infrastructurev1.ElementalHostDiagnostic) error {
+	reported := map[infrastructurev1.DiagnosticProbe]bool{}
+	for _, result := range d.Results {
+		if !slices.Contains(diagnostic.Spec.Probes, result.Probe) {
+			return fmt.Errorf("probe '%s' was not requested", result.Probe)
+		}
+		if reported[result.Probe] {
+			return fmt.Errorf("probe '%s' is reported more than once", result.Probe)
+		}
+		reported[result.Probe] = true
+		if len(result.Output) > MaxDiagnosticOutputSize {
+			return fmt.Errorf("probe '%s' output exceeds %d bytes", result.Probe, MaxDiagnosticOutputSize)
+		}
+	}
+	return nil
+}
+
+// pendingDiagnostics returns the ElementalHostDiagnostics of the ElementalHost still waiting for the elemental-agent.
+func pendingDiagnostics(elementalHost infrastructurev1.ElementalHost, diagnostics []infrastructurev1.ElementalHostDiagnostic) []HostDiagnostic {
+	pending := []HostDiagnostic{}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Spec.HostRef.Name != elementalHost.Name || diagnostic.IsCompleted() || !diagnostic.DeletionTimestamp.IsZero() {
+			continue
+		}
+		pending = append(pending, HostDiagnostic{
+			Name:   diagnostic.Name,
+			Probes: diagnostic.Spec.Probes,
+		})
+	}
+	return pending
+}
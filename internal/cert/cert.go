@@ -0,0 +1,62 @@
+package cert
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrNoCertificate = errors.New("no PEM encoded certificate found")
+)
+
+// Fingerprints returns the hex encoded sha256 fingerprints of the PEM encoded certificates.
+// Multiple certificates can be concatenated, for example in a CA bundle.
+func Fingerprints(pemBytes []byte) ([]string, error) {
+	fingerprints := []string{}
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return nil, fmt.Errorf("parsing certificate: %w", err)
+		}
+		fingerprint := sha256.Sum256(block.Bytes)
+		fingerprints = append(fingerprints, hex.EncodeToString(fingerprint[:]))
+	}
+	if len(fingerprints) == 0 {
+		return nil, ErrNoCertificate
+	}
+	return fingerprints, nil
+}
+
+// ContainsAll returns true if all the wanted fingerprints are found.
+func ContainsAll(fingerprints []string, wanted []string) bool {
+	for _, fingerprint := range wanted {
+		if !slices.Contains(fingerprints, fingerprint) {
+			return false
+		}
+	}
+	return true
+}
+
+// Bundle concatenates the PEM encoded certificates.
+func Bundle(pems ...string) string {
+	trimmed := []string{}
+	for _, pem := range pems {
+		if pem = strings.TrimSpace(pem); len(pem) > 0 {
+			trimmed = append(trimmed, pem)
+		}
+	}
+	return strings.Join(trimmed, "\n") + "\n"
+}
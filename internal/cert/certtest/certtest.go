@@ -0,0 +1,100 @@
+package certtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+)
+
+// Certificate is a generated certificate and its private key.
+type Certificate struct {
+	Cert *x509.Certificate
+	Key  *ecdsa.PrivateKey
+}
+
+// NewCA returns a self-signed certificate authority, that can sign server certificates.
+func NewCA(name string) (*Certificate, error) {
+	return newCertificate(&x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}, nil)
+}
+
+// NewServer returns a server certificate valid for the given hosts, either IP addresses or DNS names.
+// The certificate is signed by the parent certificate authority, or self-signed if the parent is nil.
+func NewServer(serial int64, hosts []string, parent *Certificate) (*Certificate, error) {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if len(hosts) > 0 {
+		template.Subject = pkix.Name{CommonName: hosts[0]}
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+			continue
+		}
+		template.DNSNames = append(template.DNSNames, host)
+	}
+	return newCertificate(template, parent)
+}
+
+// CertPEM returns the PEM encoded certificate.
+func (c *Certificate) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw})
+}
+
+// KeyPEM returns the PEM encoded private key.
+func (c *Certificate) KeyPEM() ([]byte, error) {
+	keyDER, err := x509.MarshalECPrivateKey(c.Key)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// TLSCertificate returns the certificate and its private key, to be served by a TLS server.
+func (c *Certificate) TLSCertificate() *tls.Certificate {
+	return &tls.Certificate{
+		Certificate: [][]byte{c.Cert.Raw},
+		PrivateKey:  c.Key,
+		Leaf:        c.Cert,
+	}
+}
+
+// newCertificate signs the template with the parent, or self-signs it if the parent is nil.
+// The certificate is valid from one hour ago to one hour from now.
+func newCertificate(template *x509.Certificate, parent *Certificate) (*Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating private key: %w", err)
+	}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.Cert, parent.Key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, fmt.Errorf("creating certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("parsing certificate: %w", err)
+	}
+	return &Certificate{Cert: cert, Key: key}, nil
+}
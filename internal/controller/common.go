@@ -3,10 +3,18 @@ package controller
 import (
 	"errors"
 	"time"
+
+	capierrors "sigs.k8s.io/cluster-api/errors"
 )
 
 const (
 	DefaultRequeuePeriod = 10 * time.Second
+	// DefaultHeartbeatGracePeriod is the default time after which an ElementalHost is considered unreachable.
+	DefaultHeartbeatGracePeriod = 5 * time.Minute
+	// NodeNotFoundGracePeriod is the time after which a downstream cluster node not found is considered a terminal failure.
+	NodeNotFoundGracePeriod = 10 * time.Minute
+	// DefaultNodeMaxPods is the default maximum number of pods per node, as configured by the kubelet.
+	DefaultNodeMaxPods = 110
 )
 
 // Common Errors.
@@ -14,3 +22,18 @@ var (
 	// ErrEnqueueing is returned whenever there is an error enqueueing additional resources.
 	ErrEnqueueing = errors.New("enqueueing error")
 )
+
+// TerminalError is a reconciliation error that can not be recovered by retrying.
+// It is surfaced through the status.failureReason and status.failureMessage fields.
+type TerminalError struct {
+	Reason capierrors.MachineStatusError
+	Err    error
+}
+
+func (e *TerminalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TerminalError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"crypto/tls"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/cert/certtest"
+)
+
+var _ = Describe("Elemental API TLS certificate", Label("api", "elemental-api", "tls"), func() {
+	const tlsAPIPort = 9192
+	managerPod := &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Name:       "elemental-controller-manager",
+		Namespace:  "elemental-system",
+	}
+	var certDir string
+	var certificate string
+	var privKey string
+	BeforeEach(func() {
+		certDir = GinkgoT().TempDir()
+		certificate = filepath.Join(certDir, "tls.crt")
+		privKey = filepath.Join(certDir, "tls.key")
+		certPEM, keyPEM := newTestServerCertificate(1)
+		writeTestCertificate(certDir, certificate, certPEM)
+		writeTestCertificate(certDir, privKey, keyPEM)
+	})
+	It("should fail to load a private key not matching the certificate", func() {
+		_, otherKeyPEM := newTestServerCertificate(2)
+		writeTestCertificate(certDir, privKey, otherKeyPEM)
+		_, err := api.NewCertificateLoader(logf.Log, eventRecorder, managerPod, certificate, privKey)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should serve the reloaded certificate after rotation on disk", func() {
+		loader, err := api.NewCertificateLoader(logf.Log, eventRecorder, managerPod, certificate, privKey)
+		Expect(err).ToNot(HaveOccurred())
+		tlsServer := api.NewServer(ctx, k8sClient, eventRecorder, tlsAPIPort, DefaultHeartbeatGracePeriod, loader)
+		go func() {
+			defer GinkgoRecover()
+			Expect(tlsServer.Start(ctx)).Should(Succeed())
+		}()
+		Eventually(func() (*big.Int, error) {
+			return servedSerial(tlsAPIPort)
+		}).WithTimeout(time.Minute).Should(Equal(big.NewInt(1)))
+
+		// Rotate the certificate, the same way a mounted Secret is updated
+		certPEM, keyPEM := newTestServerCertificate(2)
+		writeTestCertificate(certDir, privKey, keyPEM)
+		writeTestCertificate(certDir, certificate, certPEM)
+		Eventually(func() (*big.Int, error) {
+			return servedSerial(tlsAPIPort)
+		}).WithTimeout(time.Minute).Should(Equal(big.NewInt(2)))
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement("Normal CertificateReloaded Elemental API TLS certificate reloaded, serving serial '2'"))
+
+		// A mismatching pair is never served
+		_, otherKeyPEM := newTestServerCertificate(3)
+		writeTestCertificate(certDir, privKey, otherKeyPEM)
+		Expect(loader.Reload()).ShouldNot(Succeed())
+		Expect(servedSerial(tlsAPIPort)).Should(Equal(big.NewInt(2)))
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement(HavePrefix("Warning CertificateReloadFailed Elemental API TLS certificate reload failed")))
+	})
+})
+
+// servedSerial returns the serial number of the certificate served on the local port.
+func servedSerial(port int) (*big.Int, error) {
+	conn, err := tls.Dial("tcp", fmt.Sprintf("localhost:%d", port), &tls.Config{
+		InsecureSkipVerify: true, //nolint:gosec // Only the served certificate is inspected.
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber, nil
+}
+
+// newTestServerCertificate returns a PEM encoded self-signed certificate and private key.
+func newTestServerCertificate(serial int64) ([]byte, []byte) {
+	serverCert, err := certtest.NewServer(serial, []string{"localhost"}, nil)
+	Expect(err).ToNot(HaveOccurred())
+	keyPEM, err := serverCert.KeyPEM()
+	Expect(err).ToNot(HaveOccurred())
+	return serverCert.CertPEM(), keyPEM
+}
+
+// writeTestCertificate atomically replaces the file, so that it is never read partially written.
+func writeTestCertificate(dir string, path string, data []byte) {
+	tmp, err := os.CreateTemp(dir, ".tmp-")
+	Expect(err).ToNot(HaveOccurred())
+	_, err = tmp.Write(data)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(tmp.Close()).Should(Succeed())
+	Expect(os.Rename(tmp.Name(), path)).Should(Succeed())
+}
@@ -35,6 +35,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/controller/utils"
 	ilog "github.com/rancher-sandbox/cluster-api-provider-elemental/internal/log"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -97,6 +98,16 @@ func (r *ElementalClusterReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, fmt.Errorf("fetching ElementalCluster: %w", err)
 	}
 
+	// Return early if the object or its Cluster is paused
+	paused, err := utils.IsPaused(ctx, r.Client, elementalCluster)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("checking if ElementalCluster is paused: %w", err)
+	}
+	if paused {
+		logger.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
 	// Create the patch helper.
 	patchHelper, err := patch.NewHelper(elementalCluster, r.Client)
 	if err != nil {
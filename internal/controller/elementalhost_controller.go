@@ -21,11 +21,18 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -35,6 +42,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/controller/utils"
 	ilog "github.com/rancher-sandbox/cluster-api-provider-elemental/internal/log"
 )
 
@@ -42,6 +50,12 @@ import (
 type ElementalHostReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// HeartbeatGracePeriod is the time after which an ElementalHost not seen is flagged as unreachable.
+	HeartbeatGracePeriod time.Duration
+	// Tracker is used to drain the downstream cluster Node before executing host operations.
+	Tracker utils.RemoteTracker
+	// Recorder is used to emit events on ElementalHost lifecycle transitions.
+	Recorder record.EventRecorder
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -86,6 +100,7 @@ func (r *ElementalHostReconciler) ElementalMachineToElementalHost(ctx context.Co
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhosts,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhosts/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhosts/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -107,6 +122,16 @@ func (r *ElementalHostReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, fmt.Errorf("fetching ElementalHost: %w", err)
 	}
 
+	// Return early if the object or its Cluster is paused
+	paused, err := utils.IsElementalHostPaused(ctx, r.Client, host)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("checking if ElementalHost is paused: %w", err)
+	}
+	if paused {
+		logger.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
 	// Create the patch helper.
 	patchHelper, err := patch.NewHelper(host, r.Client)
 	if err != nil {
@@ -133,6 +158,12 @@ func (r *ElementalHostReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			controllerutil.AddFinalizer(host, infrastructurev1.FinalizerElementalMachine)
 		}
 
+		// Hosts with a terminal failure are not reconciled until they are reset.
+		if host.Status.FailureReason != nil || host.Status.FailureMessage != nil {
+			logger.Info("ElementalHost has a terminal failure, skipping reconciliation")
+			return ctrl.Result{}, nil
+		}
+
 		// Reconcile ElementalHost
 		result, err := r.reconcileNormal(ctx, host)
 		if err != nil {
@@ -188,9 +219,270 @@ func (r *ElementalHostReconciler) reconcileNormal(ctx context.Context, host *inf
 		})
 	}
 
+	// Reconcile Approved Condition
+	if _, found := host.Annotations[infrastructurev1.AnnotationElementalHostApproved]; found {
+		if host.IsPendingApproval() {
+			conditions.Set(host, &v1beta1.Condition{
+				Type:     infrastructurev1.Approved,
+				Status:   v1.ConditionFalse,
+				Severity: infrastructurev1.WaitingForApprovalReasonSeverity,
+				Reason:   infrastructurev1.WaitingForApprovalReason,
+				Message:  "Host is registered successfully. Waiting for approval before installation.",
+			})
+		} else {
+			conditions.Set(host, &v1beta1.Condition{
+				Type:     infrastructurev1.Approved,
+				Status:   v1.ConditionTrue,
+				Severity: v1beta1.ConditionSeverityInfo,
+			})
+		}
+	}
+
+	registration, err := r.registrationOf(ctx, host)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("fetching ElementalRegistration: %w", err)
+	}
+
+	// Garbage collect abandoned hosts
+	abandonedResult, deleted, err := r.reconcileAbandoned(ctx, host, registration)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("reconciling abandoned ElementalHost: %w", err)
+	}
+	if deleted {
+		return ctrl.Result{}, nil
+	}
+
+	// Reconcile installation deadline
+	installResult, err := r.reconcileInstallTimeout(ctx, host, registration)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("reconciling installation timeout: %w", err)
+	}
+	installResult = util.LowestNonZeroResult(installResult, abandonedResult)
+
+	// Reconcile one-shot operations
+	result, err := r.reconcileOperation(ctx, host)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("reconciling operation: %w", err)
+	}
+	result = util.LowestNonZeroResult(result, installResult)
+
+	// Reconcile AgentReachable Condition
+	return util.LowestNonZeroResult(result, r.reconcileHeartbeat(ctx, host)), nil
+}
+
+// registrationOf returns the ElementalRegistration the ElementalHost was registered through.
+// It returns nil if the ElementalHost is not controlled by any ElementalRegistration, or if it does not exist anymore.
+func (r *ElementalHostReconciler) registrationOf(ctx context.Context, host *infrastructurev1.ElementalHost) (*infrastructurev1.ElementalRegistration, error) {
+	owner := metav1.GetControllerOf(host)
+	if owner == nil || owner.Kind != "ElementalRegistration" {
+		return nil, nil
+	}
+	registration := &infrastructurev1.ElementalRegistration{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: host.Namespace, Name: owner.Name}, registration); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fetching ElementalRegistration '%s': %w", owner.Name, err)
+	}
+	return registration, nil
+}
+
+// reconcileAbandoned deletes the ElementalHost without reset, if it was abandoned before being installed.
+// An ElementalHost is abandoned when its elemental-agent was not seen for longer than the ElementalRegistration TTL.
+// It returns true if the ElementalHost was deleted.
+func (r *ElementalHostReconciler) reconcileAbandoned(ctx context.Context, host *infrastructurev1.ElementalHost, registration *infrastructurev1.ElementalRegistration) (ctrl.Result, bool, error) {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, host.Namespace).
+		WithValues(ilog.KeyElementalHost, host.Name)
+
+	if registration == nil || registration.Spec.AbandonedHostTTL == nil {
+		return ctrl.Result{}, false, nil
+	}
+	if value, found := host.Labels[infrastructurev1.LabelElementalHostInstalled]; found && value == "true" {
+		return ctrl.Result{}, false, nil
+	}
+	lastSeen := host.CreationTimestamp.Time
+	if host.Status.LastSeen != nil {
+		lastSeen = host.Status.LastSeen.Time
+	}
+	ttl := registration.Spec.AbandonedHostTTL.Duration
+	if remaining := time.Until(lastSeen.Add(ttl)); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, false, nil
+	}
+
+	logger.Info("Deleting abandoned ElementalHost", "lastSeen", lastSeen)
+	r.Recorder.Eventf(host, v1.EventTypeWarning, "Abandoned", "ElementalHost was not installed and not seen for %s, deleting it without reset", ttl)
+	// The annotation must be persisted before deleting, otherwise the deletion may be reconciled without it.
+	original := host.DeepCopy()
+	if host.Annotations == nil {
+		host.Annotations = map[string]string{}
+	}
+	host.Annotations[infrastructurev1.AnnotationElementalHostForceDelete] = "true"
+	if err := r.Client.Patch(ctx, host, client.MergeFrom(original)); err != nil {
+		return ctrl.Result{}, false, fmt.Errorf("patching ElementalHost force delete annotation: %w", err)
+	}
+	if err := r.Client.Delete(ctx, host); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, false, fmt.Errorf("deleting ElementalHost: %w", err)
+	}
+	return ctrl.Result{}, true, nil
+}
+
+// reconcileInstallTimeout enforces the ElementalRegistration installation deadline, if any.
+// The deadline starts when the ElementalHost is registered, or when it is approved if approval was required.
+func (r *ElementalHostReconciler) reconcileInstallTimeout(ctx context.Context, host *infrastructurev1.ElementalHost, registration *infrastructurev1.ElementalRegistration) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, host.Namespace).
+		WithValues(ilog.KeyElementalHost, host.Name)
+
+	if value, found := host.Labels[infrastructurev1.LabelElementalHostInstalled]; found && value == "true" {
+		return ctrl.Result{}, nil
+	}
+	if host.IsPendingApproval() {
+		return ctrl.Result{}, nil
+	}
+	if value, found := host.Labels[infrastructurev1.LabelElementalHostNeedsReset]; found && value == "true" {
+		return ctrl.Result{}, nil
+	}
+	if registration == nil {
+		return ctrl.Result{}, nil
+	}
+	timeout := registration.Spec.InstallTimeout
+	if timeout == nil {
+		return ctrl.Result{}, nil
+	}
+	start := host.CreationTimestamp.Time
+	if approved := conditions.Get(host, infrastructurev1.Approved); approved != nil && approved.Status == v1.ConditionTrue {
+		start = approved.LastTransitionTime.Time
+	}
+	deadline := start.Add(timeout.Duration.Duration)
+	if remaining := time.Until(deadline); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	message := fmt.Sprintf("ElementalHost was not installed within %s", timeout.Duration.Duration)
+	logger.Info("ElementalHost installation deadline passed", "policy", timeout.Policy)
+	r.Recorder.Eventf(host, v1.EventTypeWarning, "InstallationTimeout", "%s, applying the %s policy", message, timeout.Policy)
+	conditions.Set(host, &v1beta1.Condition{
+		Type:     infrastructurev1.InstallationReady,
+		Status:   v1.ConditionFalse,
+		Severity: v1beta1.ConditionSeverityError,
+		Reason:   infrastructurev1.InstallationTimeoutReason,
+		Message:  message,
+	})
+	if timeout.Policy == infrastructurev1.TimeoutPolicyFail {
+		host.Status.FailureReason = ptr.To(capierrors.CreateMachineError)
+		host.Status.FailureMessage = ptr.To(message)
+		return ctrl.Result{}, nil
+	}
+	host.Labels[infrastructurev1.LabelElementalHostNeedsReset] = "true"
 	return ctrl.Result{}, nil
 }
 
+// reconcileOperation prepares the requested one-shot operation to be delivered to the elemental-agent.
+// If requested, the downstream cluster Node is drained first.
+func (r *ElementalHostReconciler) reconcileOperation(ctx context.Context, host *infrastructurev1.ElementalHost) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, host.Namespace).
+		WithValues(ilog.KeyElementalHost, host.Name)
+
+	operation := host.Spec.Operation
+	if operation == nil {
+		return ctrl.Result{}, nil
+	}
+	if host.Status.Operation == nil || host.Status.Operation.ID != operation.ID {
+		logger.Info("New operation requested", "operationID", operation.ID, "operationType", operation.Type)
+		host.Status.Operation = &infrastructurev1.HostOperationStatus{ID: operation.ID}
+	}
+	if !operation.Drain || host.Status.Operation.Drained || host.Status.Operation.AcknowledgedAt != nil {
+		return ctrl.Result{}, nil
+	}
+
+	// Only bootstrapped hosts are part of a downstream cluster
+	clusterName, found := host.Labels[v1beta1.ClusterNameLabel]
+	if value := host.Labels[infrastructurev1.LabelElementalHostBootstrapped]; !found || value != "true" {
+		logger.Info("ElementalHost is not part of any cluster, nothing to drain")
+		host.Status.Operation.Drained = true
+		return ctrl.Result{}, nil
+	}
+	nodeName, err := r.downstreamNodeName(ctx, *host)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("determining downstream cluster node name: %w", err)
+	}
+	drained, err := r.Tracker.DrainNode(ctx, types.NamespacedName{Namespace: utils.ElementalHostClusterNamespace(host), Name: clusterName}, nodeName)
+	if errors.Is(err, utils.ErrRemoteNodeNotFound) {
+		// The node was confirmed gone by reading the downstream cluster directly, not from a cache.
+		logger.Info("Downstream cluster node not found, nothing to drain", "node", nodeName)
+		r.Recorder.Eventf(host, v1.EventTypeWarning, "DrainSkipped", "Downstream cluster node '%s' not found, skipping drain", nodeName)
+		drained = true
+	} else if err != nil {
+		return ctrl.Result{}, fmt.Errorf("draining downstream cluster node: %w", err)
+	}
+	if !drained {
+		logger.Info("Waiting for downstream cluster node to be drained")
+		return ctrl.Result{RequeueAfter: DefaultRequeuePeriod}, nil
+	}
+	host.Status.Operation.Drained = true
+	return ctrl.Result{}, nil
+}
+
+// downstreamNodeName returns the name of the downstream cluster node matched by the associated ElementalMachine.
+// The node is assumed to be named after the host, if no node was matched yet.
+func (r *ElementalHostReconciler) downstreamNodeName(ctx context.Context, host infrastructurev1.ElementalHost) (string, error) {
+	if host.Spec.MachineRef == nil {
+		return host.Name, nil
+	}
+	elementalMachine := &infrastructurev1.ElementalMachine{}
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: host.Spec.MachineRef.Namespace, Name: host.Spec.MachineRef.Name}, elementalMachine)
+	if apierrors.IsNotFound(err) {
+		return host.Name, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("getting ElementalMachine '%s': %w", host.Spec.MachineRef.Name, err)
+	}
+	if len(elementalMachine.Status.NodeName) == 0 {
+		return host.Name, nil
+	}
+	return elementalMachine.Status.NodeName, nil
+}
+
+// reconcileHeartbeat flags the ElementalHost as unreachable if the elemental-agent was not seen within the grace period.
+// When the host is reachable, a new reconciliation is scheduled at the end of the grace period.
+func (r *ElementalHostReconciler) reconcileHeartbeat(ctx context.Context, host *infrastructurev1.ElementalHost) ctrl.Result {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, host.Namespace).
+		WithValues(ilog.KeyElementalHost, host.Name)
+
+	// The agent never authenticated any request yet.
+	if host.Status.LastSeen == nil {
+		return ctrl.Result{}
+	}
+
+	gracePeriod := r.HeartbeatGracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultHeartbeatGracePeriod
+	}
+	elapsed := time.Since(host.Status.LastSeen.Time)
+	if elapsed > gracePeriod {
+		logger.Info("ElementalHost agent is unreachable", "lastSeen", host.Status.LastSeen.Time)
+		conditions.Set(host, &v1beta1.Condition{
+			Type:     infrastructurev1.AgentReachable,
+			Status:   v1.ConditionFalse,
+			Severity: infrastructurev1.AgentUnreachableReasonSeverity,
+			Reason:   infrastructurev1.AgentUnreachableReason,
+			Message:  fmt.Sprintf("Agent was last seen %s ago", elapsed.Round(time.Second)),
+		})
+		// The agent patching the host will trigger a new reconciliation.
+		return ctrl.Result{}
+	}
+
+	conditions.Set(host, &v1beta1.Condition{
+		Type:     infrastructurev1.AgentReachable,
+		Status:   v1.ConditionTrue,
+		Severity: v1beta1.ConditionSeverityInfo,
+	})
+	return ctrl.Result{RequeueAfter: gracePeriod - elapsed}
+}
+
 func (r *ElementalHostReconciler) reconcileOSVersionManagement(ctx context.Context, host *infrastructurev1.ElementalHost) error {
 	logger := log.FromContext(ctx).
 		WithValues(ilog.KeyNamespace, host.Namespace).
@@ -213,6 +505,7 @@ func (r *ElementalHostReconciler) reconcileOSVersionManagement(ctx context.Conte
 	// If we have a different OS Version to reconcile, then set the `OSVersionReady` false.
 	if !reflect.DeepEqual(host.Spec.OSVersionManagement, machine.Spec.OSVersionManagement) {
 		logger.Info("OSVersionManagement mutated on associated ElementalMachine")
+		r.Recorder.Eventf(host, v1.EventTypeNormal, "OSVersionChanged", "OSVersionManagement updated from ElementalMachine '%s'", machine.Name)
 		// Propagate the OSVersionManagement data
 		host.Spec.OSVersionManagement = machine.Spec.OSVersionManagement
 
@@ -258,11 +551,20 @@ func (r *ElementalHostReconciler) reconcileDelete(ctx context.Context, host *inf
 			Status:   v1.ConditionTrue,
 			Severity: v1beta1.ConditionSeverityInfo,
 		})
+		r.Recorder.Event(host, v1.EventTypeNormal, "Deleted", "ElementalHost was reset and deleted")
+		return ctrl.Result{}, nil
+	}
+
+	if value, found := host.Annotations[infrastructurev1.AnnotationElementalHostForceDelete]; found && value == "true" {
+		logger.Info("Force deleting ElementalHost without reset")
+		controllerutil.RemoveFinalizer(host, infrastructurev1.FinalizerElementalMachine)
+		r.Recorder.Event(host, v1.EventTypeNormal, "ForceDeleted", "ElementalHost was deleted without reset")
 		return ctrl.Result{}, nil
 	}
 
 	if value, found := host.Labels[infrastructurev1.LabelElementalHostNeedsReset]; !found || value != "true" {
 		logger.Info("Triggering reset for to-be-deleted ElementalHost")
+		r.Recorder.Event(host, v1.EventTypeNormal, "Deleting", "Waiting for the remote host to reset before deletion")
 		host.Labels[infrastructurev1.LabelElementalHostNeedsReset] = "true"
 		conditions.Set(host, &v1beta1.Condition{
 			Type:     infrastructurev1.ResetReady,
@@ -1,8 +1,11 @@
 package controller
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -15,7 +18,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util/conditions"
 
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
@@ -86,6 +91,7 @@ var _ = Describe("ElementalHost controller", Label("controller", "elemental-host
 		Expect(resetCondition.Severity).Should(Equal(v1beta1.WaitingForResetReasonSeverity))
 		Expect(resetCondition.Reason).Should(Equal(v1beta1.WaitingForResetReason))
 		Expect(resetCondition.Message).Should(Equal("Waiting for remote host to reset"))
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement("Normal Deleting Waiting for the remote host to reset before deletion"))
 		// Patch with reset done label
 		updatedHost.Labels[v1beta1.LabelElementalHostReset] = "true"
 		Expect(k8sClient.Update(ctx, updatedHost)).Should(Succeed())
@@ -96,6 +102,7 @@ var _ = Describe("ElementalHost controller", Label("controller", "elemental-host
 				updatedHost)
 			return apierrors.IsNotFound(err)
 		}).WithTimeout(time.Minute).Should(BeTrue(), "ElementalHost should be deleted")
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement("Normal Deleted ElementalHost was reset and deleted"))
 	})
 	It("should set conditions summary", func() {
 		// Create an "already installed" host
@@ -227,6 +234,7 @@ var _ = Describe("ElementalHost controller", Label("controller", "elemental-host
 		Expect(osVersionReadyCondition.Reason).Should(Equal(v1beta1.WaitingOSReconcileReason))
 		Expect(osVersionReadyCondition.Severity).Should(Equal(v1beta1.WaitingOSReconcileReasonSeverity))
 		Expect(osVersionReadyCondition.Message).Should(Equal(fmt.Sprintf("ElementalMachine %s OSVersionManagement mutated", elementalMachine.Name)))
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement(fmt.Sprintf("Normal OSVersionChanged OSVersionManagement updated from ElementalMachine '%s'", elementalMachine.Name)))
 		// Mark the host as bootstrapped now. This will be needed to test a different OSVersionReady false reason
 		associatedHostPatch = associatedHost
 		associatedHostPatch.Labels = map[string]string{v1beta1.LabelElementalHostBootstrapped: "true"}
@@ -252,6 +260,212 @@ var _ = Describe("ElementalHost controller", Label("controller", "elemental-host
 		Expect(osVersionReadyCondition.Severity).Should(Equal(v1beta1.InPlaceUpdateNotPendingReasonSeverity))
 		Expect(osVersionReadyCondition.Message).Should(Equal(fmt.Sprintf("ElementalMachine %s OSVersionManagement mutated, but no in-place-upgrade is pending. Mutation will be ignored.", elementalMachine.Name)))
 	})
+	It("should flag the agent as unreachable after grace period", func() {
+		unreachableHost := host
+		unreachableHost.ObjectMeta.Name = "test-unreachable"
+		Expect(k8sClient.Create(ctx, &unreachableHost)).Should(Succeed())
+		// Mark the host as last seen long ago
+		unreachableHostPatch := unreachableHost
+		unreachableHostPatch.Status.LastSeen = &metav1.Time{Time: time.Now().Add(-DefaultHeartbeatGracePeriod * 2)}
+		patchObject(ctx, k8sClient, &unreachableHost, &unreachableHostPatch)
+		Eventually(func() corev1.ConditionStatus {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      unreachableHost.Name,
+				Namespace: unreachableHost.Namespace},
+				&unreachableHost)).Should(Succeed())
+			condition := conditions.Get(&unreachableHost, v1beta1.AgentReachable)
+			if condition == nil {
+				return corev1.ConditionUnknown
+			}
+			return condition.Status
+		}).WithTimeout(time.Minute).Should(Equal(corev1.ConditionFalse), "AgentReachable condition should be false")
+		agentReachableCondition := conditions.Get(&unreachableHost, v1beta1.AgentReachable)
+		Expect(agentReachableCondition.Reason).Should(Equal(v1beta1.AgentUnreachableReason))
+		Expect(agentReachableCondition.Severity).Should(Equal(v1beta1.AgentUnreachableReasonSeverity))
+		Expect(conditions.IsFalse(&unreachableHost, clusterv1.ReadyCondition)).Should(BeTrue(), "Conditions summary should be false")
+		// Mark the host as just seen
+		unreachableHostPatch = unreachableHost
+		unreachableHostPatch.Status.LastSeen = &metav1.Time{Time: time.Now()}
+		patchObject(ctx, k8sClient, &unreachableHost, &unreachableHostPatch)
+		Eventually(func() bool {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      unreachableHost.Name,
+				Namespace: unreachableHost.Namespace},
+				&unreachableHost)).Should(Succeed())
+			return conditions.IsTrue(&unreachableHost, v1beta1.AgentReachable)
+		}).WithTimeout(time.Minute).Should(BeTrue(), "AgentReachable condition should be true")
+	})
+	It("should not reconcile ElementalHost with terminal failure", func() {
+		failedHost := host
+		failedHost.ObjectMeta.Name = "test-failed"
+		Expect(k8sClient.Create(ctx, &failedHost)).Should(Succeed())
+		Eventually(func() []string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      failedHost.Name,
+				Namespace: failedHost.Namespace},
+				&failedHost)).Should(Succeed())
+			return failedHost.GetFinalizers()
+		}).WithTimeout(time.Minute).Should(ContainElement(v1beta1.FinalizerElementalMachine), "ElementalHost should have finalizer")
+		// Mark the host as failed
+		failedHostPatch := failedHost
+		failedHostPatch.Status.FailureReason = ptr.To(capierrors.UpdateMachineError)
+		failedHostPatch.Status.FailureMessage = ptr.To("test failure")
+		patchObject(ctx, k8sClient, &failedHost, &failedHostPatch)
+		// Request an operation
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      failedHost.Name,
+			Namespace: failedHost.Namespace},
+			&failedHost)).Should(Succeed())
+		failedHostPatch = failedHost
+		failedHostPatch.Spec.Operation = &v1beta1.HostOperation{ID: "test-operation", Type: v1beta1.HostOperationReboot}
+		patchObject(ctx, k8sClient, &failedHost, &failedHostPatch)
+		Consistently(func() *v1beta1.HostOperationStatus {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      failedHost.Name,
+				Namespace: failedHost.Namespace},
+				&failedHost)).Should(Succeed())
+			return failedHost.Status.Operation
+		}).WithTimeout(5*time.Second).Should(BeNil(), "Operation should not be reconciled on failed host")
+	})
+	It("should enforce the installation deadline", func() {
+		registration := v1beta1.ElementalRegistration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-install-timeout",
+				Namespace: namespace.Name,
+			},
+			Spec: v1beta1.ElementalRegistrationSpec{
+				InstallTimeout: &v1beta1.Timeout{
+					Duration: metav1.Duration{Duration: time.Second},
+					Policy:   v1beta1.TimeoutPolicyFail,
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &registration)).Should(Succeed())
+		ownerReferences := []metav1.OwnerReference{{
+			APIVersion: v1beta1.GroupVersion.Identifier(),
+			Kind:       "ElementalRegistration",
+			Name:       registration.Name,
+			UID:        registration.UID,
+			Controller: ptr.To(true),
+		}}
+		// Fail policy
+		failingHost := host
+		failingHost.ObjectMeta.Name = "test-install-timeout-fail"
+		failingHost.ObjectMeta.OwnerReferences = ownerReferences
+		Expect(k8sClient.Create(ctx, &failingHost)).Should(Succeed())
+		Eventually(func() *capierrors.MachineStatusError {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      failingHost.Name,
+				Namespace: failingHost.Namespace},
+				&failingHost)).Should(Succeed())
+			return failingHost.Status.FailureReason
+		}).WithTimeout(time.Minute).Should(Equal(ptr.To(capierrors.CreateMachineError)))
+		installationReadyCondition := conditions.Get(&failingHost, v1beta1.InstallationReady)
+		Expect(installationReadyCondition).ShouldNot(BeNil())
+		Expect(installationReadyCondition.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(installationReadyCondition.Reason).Should(Equal(v1beta1.InstallationTimeoutReason))
+		Expect(failingHost.Labels).ShouldNot(HaveKey(v1beta1.LabelElementalHostNeedsReset))
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement(And(
+			HavePrefix("Warning InstallationTimeout"),
+			HaveSuffix(fmt.Sprintf("applying the %s policy", v1beta1.TimeoutPolicyFail)))))
+		// Reset policy
+		registrationPatch := registration
+		registrationPatch.Spec.InstallTimeout = &v1beta1.Timeout{
+			Duration: metav1.Duration{Duration: time.Second},
+			Policy:   v1beta1.TimeoutPolicyReset,
+		}
+		patchObject(ctx, k8sClient, &registration, &registrationPatch)
+		resetHost := host
+		resetHost.ObjectMeta.Name = "test-install-timeout-reset"
+		resetHost.ObjectMeta.OwnerReferences = ownerReferences
+		Expect(k8sClient.Create(ctx, &resetHost)).Should(Succeed())
+		Eventually(func() string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      resetHost.Name,
+				Namespace: resetHost.Namespace},
+				&resetHost)).Should(Succeed())
+			return resetHost.Labels[v1beta1.LabelElementalHostNeedsReset]
+		}).WithTimeout(time.Minute).Should(Equal("true"), "ElementalHost should be reset")
+		Expect(resetHost.Status.FailureReason).Should(BeNil())
+		// Installed hosts are not subject to the deadline
+		installedHost := host
+		installedHost.ObjectMeta.Name = "test-install-timeout-installed"
+		installedHost.ObjectMeta.OwnerReferences = ownerReferences
+		installedHost.ObjectMeta.Labels = map[string]string{v1beta1.LabelElementalHostInstalled: "true"}
+		Expect(k8sClient.Create(ctx, &installedHost)).Should(Succeed())
+		Consistently(func() map[string]string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      installedHost.Name,
+				Namespace: installedHost.Namespace},
+				&installedHost)).Should(Succeed())
+			return installedHost.Labels
+		}).WithTimeout(5 * time.Second).ShouldNot(HaveKey(v1beta1.LabelElementalHostNeedsReset))
+	})
+	It("should delete without reset if forced", func() {
+		forcedHost := host
+		forcedHost.ObjectMeta.Name = "test-force-delete"
+		forcedHost.ObjectMeta.Annotations = map[string]string{v1beta1.AnnotationElementalHostForceDelete: "true"}
+		Expect(k8sClient.Create(ctx, &forcedHost)).Should(Succeed())
+		Eventually(func() []string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      forcedHost.Name,
+				Namespace: forcedHost.Namespace},
+				&forcedHost)).Should(Succeed())
+			return forcedHost.GetFinalizers()
+		}).WithTimeout(time.Minute).Should(ContainElement(v1beta1.FinalizerElementalMachine), "ElementalHost should have finalizer")
+		Expect(k8sClient.Delete(ctx, &forcedHost)).Should(Succeed())
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      forcedHost.Name,
+				Namespace: forcedHost.Namespace},
+				&forcedHost)
+			return apierrors.IsNotFound(err)
+		}).WithTimeout(time.Minute).Should(BeTrue(), "ElementalHost should be deleted")
+		Expect(forcedHost.Labels).ShouldNot(HaveKey(v1beta1.LabelElementalHostNeedsReset))
+	})
+	It("should delete abandoned hosts", func() {
+		registration := v1beta1.ElementalRegistration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-abandoned",
+				Namespace: namespace.Name,
+			},
+			Spec: v1beta1.ElementalRegistrationSpec{
+				AbandonedHostTTL: &metav1.Duration{Duration: 2 * time.Second},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &registration)).Should(Succeed())
+		ownerReferences := []metav1.OwnerReference{{
+			APIVersion: v1beta1.GroupVersion.Identifier(),
+			Kind:       "ElementalRegistration",
+			Name:       registration.Name,
+			UID:        registration.UID,
+			Controller: ptr.To(true),
+		}}
+		abandonedHost := host
+		abandonedHost.ObjectMeta.Name = "test-abandoned"
+		abandonedHost.ObjectMeta.OwnerReferences = ownerReferences
+		Expect(k8sClient.Create(ctx, &abandonedHost)).Should(Succeed())
+		// Installed hosts are never abandoned
+		installedHost := host
+		installedHost.ObjectMeta.Name = "test-abandoned-installed"
+		installedHost.ObjectMeta.OwnerReferences = ownerReferences
+		installedHost.ObjectMeta.Labels = map[string]string{v1beta1.LabelElementalHostInstalled: "true"}
+		Expect(k8sClient.Create(ctx, &installedHost)).Should(Succeed())
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      abandonedHost.Name,
+				Namespace: abandonedHost.Namespace},
+				&abandonedHost)
+			return apierrors.IsNotFound(err)
+		}).WithTimeout(time.Minute).Should(BeTrue(), "Abandoned ElementalHost should be deleted")
+		Consistently(func() *metav1.Time {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      installedHost.Name,
+				Namespace: installedHost.Namespace},
+				&installedHost)).Should(Succeed())
+			return installedHost.GetDeletionTimestamp()
+		}).WithTimeout(5 * time.Second).Should(BeNil())
+	})
 })
 
 var _ = Describe("Elemental API Host controller", Label("api", "elemental-host"), Ordered, func() {
@@ -356,6 +570,231 @@ runcmd:
 			Labels:      request.Labels,
 		}
 		Expect(*response).To(Equal(wantResponse))
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement(fmt.Sprintf("Normal Registered Host '%s' registered through ElementalRegistration '%s'", request.Name, registration.Name)))
+	})
+	It("should record host phase changes", func() {
+		phaseChanges := func() int {
+			count := 0
+			for _, event := range recordedEvents() {
+				if strings.HasPrefix(event, "Normal PhaseChanged") {
+					count++
+				}
+			}
+			return count
+		}
+		previousChanges := phaseChanges()
+		phase := v1beta1.PhaseInstalling
+		_, err := eClient.PatchHost(api.HostPatchRequest{Phase: &phase}, request.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement(HaveSuffix(fmt.Sprintf("to '%s'", v1beta1.PhaseInstalling))))
+		Expect(phaseChanges()).Should(Equal(previousChanges + 1))
+		// Reporting the same phase again is not a transition
+		_, err = eClient.PatchHost(api.HostPatchRequest{Phase: &phase}, request.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Consistently(phaseChanges).WithTimeout(2 * time.Second).Should(Equal(previousChanges + 1))
+	})
+	It("should record the host phase history", func() {
+		// Fail the current phase
+		patchRequest := api.HostPatchRequest{}
+		patchRequest.SetCondition(v1beta1.InstallationReady,
+			corev1.ConditionFalse,
+			clusterv1.ConditionSeverityError,
+			v1beta1.InstallationFailedReason,
+			"test failure")
+		_, err := eClient.PatchHost(patchRequest, request.Name)
+		Expect(err).ToNot(HaveOccurred())
+		// Move to the next phase
+		phase := v1beta1.PhaseTriggeringReset
+		_, err = eClient.PatchHost(api.HostPatchRequest{Phase: &phase}, request.Name)
+		Expect(err).ToNot(HaveOccurred())
+		updatedHost := &v1beta1.ElementalHost{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      request.Name,
+			Namespace: namespace.Name},
+			updatedHost)).Should(Succeed())
+		history := updatedHost.Status.PhaseHistory
+		Expect(len(history)).Should(BeNumerically(">=", 2))
+		installing := history[len(history)-2]
+		Expect(installing.Phase).Should(Equal(v1beta1.PhaseInstalling))
+		Expect(installing.EndTime).ShouldNot(BeNil())
+		Expect(installing.Outcome).Should(Equal(v1beta1.PhaseFailed))
+		Expect(installing.Reason).Should(Equal(v1beta1.InstallationFailedReason))
+		current := history[len(history)-1]
+		Expect(current.Phase).Should(Equal(v1beta1.PhaseTriggeringReset))
+		Expect(current.EndTime).Should(BeNil())
+		Expect(current.Outcome).Should(BeEmpty())
+		// Recover the host for the next tests
+		patchRequest.SetCondition(v1beta1.InstallationReady,
+			corev1.ConditionTrue,
+			clusterv1.ConditionSeverityNone,
+			"",
+			"")
+		phase = v1beta1.PhaseInstalling
+		patchRequest.Phase = &phase
+		_, err = eClient.PatchHost(patchRequest, request.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      request.Name,
+			Namespace: namespace.Name},
+			updatedHost)).Should(Succeed())
+		history = updatedHost.Status.PhaseHistory
+		Expect(history[len(history)-2].Outcome).Should(Equal(v1beta1.PhaseSucceeded))
+	})
+	It("should record authentication failures", func() {
+		hostURL := fmt.Sprintf("%s%s%s/namespaces/%s/registrations/%s/hosts/%s", serverURL, api.Prefix, api.PrefixV1, namespace.Name, registration.Name, request.Name)
+		unauthenticatedRequest, err := http.NewRequest(http.MethodPatch, hostURL, bytes.NewBufferString("{}"))
+		Expect(err).ToNot(HaveOccurred())
+		response, err := http.DefaultClient.Do(unauthenticatedRequest)
+		Expect(err).ToNot(HaveOccurred())
+		defer response.Body.Close()
+		Expect(response.StatusCode).Should(Equal(http.StatusUnauthorized))
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement(HavePrefix("Warning AuthenticationFailed Host request denied")))
+	})
+	It("should store the host support bundle", func() {
+		bundle := api.SupportBundleRequest{
+			Reason: "test reason",
+			Files:  map[string][]byte{"elemental-agent.log": []byte("test logs")},
+		}
+		Expect(eClient.UploadSupportBundle(bundle, request.Name)).Should(Succeed())
+		updatedHost := &v1beta1.ElementalHost{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      request.Name,
+			Namespace: namespace.Name},
+			updatedHost)).Should(Succeed())
+		Expect(updatedHost.Status.SupportBundle).ShouldNot(BeNil())
+		Expect(updatedHost.Status.SupportBundle.Reason).Should(Equal(bundle.Reason))
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      updatedHost.Status.SupportBundle.SecretName,
+			Namespace: namespace.Name},
+			secret)).Should(Succeed())
+		Expect(secret.Data).Should(Equal(bundle.Files))
+		Expect(secret.Labels[v1beta1.LabelElementalHostSupportBundle]).Should(Equal(request.Name))
+		Expect(secret.OwnerReferences).Should(HaveLen(1))
+		Expect(secret.OwnerReferences[0].UID).Should(Equal(updatedHost.UID))
+		// A new support bundle replaces the previous one
+		bundle.Files = map[string][]byte{"journal.log": []byte("test journal")}
+		Expect(eClient.UploadSupportBundle(bundle, request.Name)).Should(Succeed())
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      updatedHost.Status.SupportBundle.SecretName,
+			Namespace: namespace.Name},
+			secret)).Should(Succeed())
+		Expect(secret.Data).Should(Equal(bundle.Files))
+		// Secrets not storing the host support bundle are never replaced
+		foreignSecret := secret.DeepCopy()
+		delete(foreignSecret.Labels, v1beta1.LabelElementalHostSupportBundle)
+		patchObject(ctx, k8sClient, secret, foreignSecret)
+		Expect(eClient.UploadSupportBundle(bundle, request.Name)).Should(MatchError(ContainSubstring("'409'")))
+		restoredSecret := foreignSecret.DeepCopy()
+		restoredSecret.Labels[v1beta1.LabelElementalHostSupportBundle] = request.Name
+		patchObject(ctx, k8sClient, foreignSecret, restoredSecret)
+		// Support bundles exceeding the maximum size are rejected
+		bundle.Files = map[string][]byte{"journal.log": bytes.Repeat([]byte("a"), api.MaxSupportBundleSize+1)}
+		Expect(eClient.UploadSupportBundle(bundle, request.Name)).ShouldNot(Succeed())
+	})
+	It("should deliver the elemental-agent update and record its version", func() {
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      registration.Name,
+			Namespace: registration.Namespace},
+			&registration)).Should(Succeed())
+		agentUpdate := &v1beta1.AgentUpdate{
+			Version:   "v0.0.1-test",
+			URL:       "https://example.com/elemental-agent",
+			SHA256:    strings.Repeat("a", 64),
+			Signature: "dGVzdA==",
+		}
+		registration.Spec.AgentUpdate = agentUpdate
+		Expect(k8sClient.Update(ctx, &registration)).Should(Succeed())
+		Eventually(func() *v1beta1.AgentUpdate {
+			response, err := eClient.PatchHost(api.HostPatchRequest{}, request.Name)
+			Expect(err).ToNot(HaveOccurred())
+			return response.AgentUpdate
+		}).WithTimeout(time.Minute).Should(Equal(agentUpdate))
+
+		// Report an update failure
+		_, err := eClient.PatchHost(api.HostPatchRequest{
+			AgentUpdateFailure: &v1beta1.AgentUpdateFailure{
+				Version: agentUpdate.Version,
+				Message: "test update failure",
+			},
+		}, request.Name)
+		Expect(err).ToNot(HaveOccurred())
+		updatedHost := &v1beta1.ElementalHost{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      request.Name,
+			Namespace: namespace.Name},
+			updatedHost)).Should(Succeed())
+		Expect(updatedHost.Status.AgentVersion).Should(Equal("v0.0.0-test"), "Version must be parsed from the User-Agent header")
+		Expect(updatedHost.Status.AgentUpdateFailure).ShouldNot(BeNil())
+		Expect(updatedHost.Status.AgentUpdateFailure.Version).Should(Equal(agentUpdate.Version))
+		Expect(updatedHost.Status.AgentUpdateFailure.Message).Should(Equal("test update failure"))
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement("Warning AgentUpdateFailed elemental-agent update to version 'v0.0.1-test' failed: test update failure"))
+
+		// The failure is cleared once no update is desired
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      registration.Name,
+			Namespace: registration.Namespace},
+			&registration)).Should(Succeed())
+		registration.Spec.AgentUpdate = nil
+		Expect(k8sClient.Update(ctx, &registration)).Should(Succeed())
+		Eventually(func() *v1beta1.AgentUpdateFailure {
+			_, err := eClient.PatchHost(api.HostPatchRequest{}, request.Name)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.Name,
+				Namespace: namespace.Name},
+				updatedHost)).Should(Succeed())
+			return updatedHost.Status.AgentUpdateFailure
+		}).WithTimeout(time.Minute).Should(BeNil())
+	})
+	It("should get the host registration without the registration token", func() {
+		response, err := eClient.GetHostRegistration(request.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Config.Elemental.Registration.URI).Should(Equal(registration.Spec.Config.Elemental.Registration.URI))
+		Expect(response.Config.Elemental.Registration.Token).Should(BeEmpty(), "Registration token must not be disclosed to registered hosts")
+		Expect(response.Config.Elemental.Agent.Debug).Should(BeTrue())
+	})
+	It("should report the applied agent config hash", func() {
+		configHash := "test-config-hash"
+		_, err := eClient.PatchHost(api.HostPatchRequest{AgentConfigHash: &configHash}, request.Name)
+		Expect(err).ToNot(HaveOccurred())
+		updatedHost := &v1beta1.ElementalHost{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      request.Name,
+			Namespace: namespace.Name},
+			updatedHost)).Should(Succeed())
+		Expect(updatedHost.Status.AgentConfigHash).Should(Equal(configHash))
+	})
+	It("should only refresh the heartbeat once every quarter of the grace period", func() {
+		setLastSeen := func(lastSeen metav1.Time) {
+			host := &v1beta1.ElementalHost{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.Name,
+				Namespace: namespace.Name},
+				host)).Should(Succeed())
+			hostPatch := host.DeepCopy()
+			hostPatch.Status.LastSeen = &lastSeen
+			patchObject(ctx, k8sClient, host, hostPatch)
+		}
+		getLastSeen := func() time.Time {
+			_, err := eClient.PatchHost(api.HostPatchRequest{}, request.Name)
+			Expect(err).ToNot(HaveOccurred())
+			host := &v1beta1.ElementalHost{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.Name,
+				Namespace: namespace.Name},
+				host)).Should(Succeed())
+			Expect(host.Status.LastSeen).ShouldNot(BeNil(), "LastSeen must be set")
+			return host.Status.LastSeen.Time
+		}
+		// A recent heartbeat is not refreshed
+		recentLastSeen := metav1.NewTime(time.Now().Add(-10 * time.Second).Truncate(time.Second))
+		setLastSeen(recentLastSeen)
+		Expect(getLastSeen()).Should(BeTemporally("==", recentLastSeen.Time))
+		// An older heartbeat is refreshed
+		staleLastSeen := metav1.NewTime(time.Now().Add(-DefaultHeartbeatGracePeriod / 2).Truncate(time.Second))
+		setLastSeen(staleLastSeen)
+		Expect(getLastSeen()).Should(BeTemporally(">", staleLastSeen.Time))
 	})
 	It("should patch host with installed label", func() {
 		// Patch the host as Installed
@@ -371,6 +810,7 @@ runcmd:
 		value, found := updatedHost.Labels[v1beta1.LabelElementalHostInstalled]
 		Expect(found).Should(BeTrue(), "Installed label must be present")
 		Expect(value).Should(Equal("true"), "Installed label must have 'true' value")
+		Expect(updatedHost.Status.LastSeen).ShouldNot(BeNil(), "LastSeen must be set")
 		Eventually(func() bool {
 			Expect(k8sClient.Get(ctx, types.NamespacedName{
 				Name:      request.Name,
@@ -440,6 +880,38 @@ runcmd:
 			return bootstrappedCondition != nil && bootstrappedCondition.Status == corev1.ConditionTrue
 		}).WithTimeout(time.Minute).Should(BeTrue(), "condition must be set")
 	})
+	It("should receive and acknowledge operation", func() {
+		host := &v1beta1.ElementalHost{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      request.Name,
+			Namespace: namespace.Name},
+			host)).Should(Succeed())
+		operation := v1beta1.HostOperation{
+			ID:    "test-operation",
+			Type:  v1beta1.HostOperationReboot,
+			Drain: true,
+		}
+		host.Spec.Operation = &operation
+		Expect(k8sClient.Update(ctx, host)).Should(Succeed())
+		// Wait for the operation to be ready (nothing to drain, since the host is not part of any cluster)
+		Eventually(func() *api.HostResponse {
+			response, err := eClient.PatchHost(api.HostPatchRequest{}, request.Name)
+			Expect(err).ToNot(HaveOccurred())
+			return response
+		}).WithTimeout(time.Minute).Should(HaveField("Operation", Equal(&operation)), "Operation must be delivered")
+		// Acknowledge the operation
+		response, err := eClient.PatchHost(api.HostPatchRequest{OperationID: &operation.ID}, request.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Operation).Should(BeNil(), "Acknowledged operation must not be delivered again")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      request.Name,
+			Namespace: namespace.Name},
+			host)).Should(Succeed())
+		Expect(host.Status.Operation).ShouldNot(BeNil())
+		Expect(host.Status.Operation.ID).Should(Equal(operation.ID))
+		Expect(host.Status.Operation.Drained).Should(BeTrue())
+		Expect(host.Status.Operation.AcknowledgedAt).ShouldNot(BeNil())
+	})
 	It("should receive needs reset flag", func() {
 		host := &v1beta1.ElementalHost{}
 		Expect(k8sClient.Get(ctx, types.NamespacedName{
@@ -488,4 +960,58 @@ runcmd:
 			return apierrors.IsNotFound(err)
 		}).WithTimeout(time.Minute).Should(BeTrue(), "ElementalHost should be deleted")
 	})
+	It("should hold new hosts for approval if required by the registration", func() {
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      registration.Name,
+			Namespace: registration.Namespace},
+			&registration)).Should(Succeed())
+		registration.Spec.RequireApproval = true
+		registration.Spec.AutoApprove = &v1beta1.AutoApprovePolicy{
+			MACAddresses: []string{"52:54:00:12:34:56"},
+		}
+		Expect(k8sClient.Update(ctx, &registration)).Should(Succeed())
+
+		// The host can not approve itself
+		pendingRequest := request
+		pendingRequest.Name = "test-pending-approval"
+		pendingRequest.Annotations = map[string]string{v1beta1.AnnotationElementalHostApproved: "true"}
+		pendingRequest.MACAddresses = []string{"52:54:00:ab:cd:ef"}
+		Expect(eClient.CreateHost(pendingRequest)).Should(Succeed())
+		response, err := eClient.PatchHost(api.HostPatchRequest{
+			Annotations: map[string]string{v1beta1.AnnotationElementalHostApproved: "true"},
+		}, pendingRequest.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.PendingApproval).Should(BeTrue(), "Host must be pending approval")
+		host := &v1beta1.ElementalHost{}
+		Eventually(func() string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      pendingRequest.Name,
+				Namespace: namespace.Name},
+				host)).Should(Succeed())
+			return conditions.GetReason(host, v1beta1.Approved)
+		}).WithTimeout(time.Minute).Should(Equal(v1beta1.WaitingForApprovalReason))
+
+		// Approve the host
+		host.Annotations[v1beta1.AnnotationElementalHostApproved] = "true"
+		Expect(k8sClient.Update(ctx, host)).Should(Succeed())
+		response, err = eClient.PatchHost(api.HostPatchRequest{}, pendingRequest.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.PendingApproval).Should(BeFalse(), "Host must be approved")
+		Eventually(func() bool {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      pendingRequest.Name,
+				Namespace: namespace.Name},
+				host)).Should(Succeed())
+			return conditions.IsTrue(host, v1beta1.Approved)
+		}).WithTimeout(time.Minute).Should(BeTrue(), "Approved condition must be true")
+	})
+	It("should automatically approve expected hosts", func() {
+		approvedRequest := request
+		approvedRequest.Name = "test-auto-approved"
+		approvedRequest.MACAddresses = []string{"52-54-00-12-34-56"}
+		Expect(eClient.CreateHost(approvedRequest)).Should(Succeed())
+		response, err := eClient.PatchHost(api.HostPatchRequest{}, approvedRequest.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.PendingApproval).Should(BeFalse(), "Host must be automatically approved")
+	})
 })
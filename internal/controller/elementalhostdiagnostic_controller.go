@@ -0,0 +1,163 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/controller/utils"
+	ilog "github.com/rancher-sandbox/cluster-api-provider-elemental/internal/log"
+)
+
+// ElementalHostDiagnosticReconciler reconciles a ElementalHostDiagnostic object.
+type ElementalHostDiagnosticReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhostdiagnostics,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhostdiagnostics/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhosts,verbs=get;list;watch
+
+// Reconcile tracks the execution of the ElementalHostDiagnostic probes.
+// The probes are delivered to the elemental-agent, and their results collected, by the Elemental API.
+func (r *ElementalHostDiagnosticReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, rerr error) {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, req.Namespace).
+		WithValues(ilog.KeyElementalHostDiagnostic, req.Name)
+	logger.Info("Reconciling ElementalHostDiagnostic")
+
+	// Fetch the ElementalHostDiagnostic
+	diagnostic := &infrastructurev1.ElementalHostDiagnostic{}
+	if err := r.Client.Get(ctx, req.NamespacedName, diagnostic); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("fetching ElementalHostDiagnostic: %w", err)
+	}
+
+	// Return early if the object is paused
+	paused, err := utils.IsPaused(ctx, r.Client, diagnostic)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("checking if ElementalHostDiagnostic is paused: %w", err)
+	}
+	if paused {
+		logger.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	// Create the patch helper.
+	patchHelper, err := patch.NewHelper(diagnostic, r.Client)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("initializing patch helper: %w", err)
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, diagnostic); err != nil {
+			rerr = errors.Join(rerr, fmt.Errorf("patching ElementalHostDiagnostic: %w", err))
+		}
+	}()
+
+	// The probes are only executed once. Create a new diagnostic to execute them again.
+	if diagnostic.IsCompleted() {
+		conditions.Set(diagnostic, &clusterv1.Condition{
+			Type:   clusterv1.ReadyCondition,
+			Status: corev1.ConditionTrue,
+		})
+		return ctrl.Result{}, nil
+	}
+
+	host := &infrastructurev1.ElementalHost{}
+	err = r.Client.Get(ctx, client.ObjectKey{Namespace: diagnostic.Namespace, Name: diagnostic.Spec.HostRef.Name}, host)
+	if apierrors.IsNotFound(err) {
+		logger.Info("ElementalHost not found", ilog.KeyElementalHost, diagnostic.Spec.HostRef.Name)
+		conditions.Set(diagnostic, &clusterv1.Condition{
+			Type:     clusterv1.ReadyCondition,
+			Status:   corev1.ConditionFalse,
+			Severity: infrastructurev1.MissingHostReasonSeverity,
+			Reason:   infrastructurev1.MissingHostReason,
+			Message:  fmt.Sprintf("ElementalHost '%s' not found", diagnostic.Spec.HostRef.Name),
+		})
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("fetching ElementalHost '%s': %w", diagnostic.Spec.HostRef.Name, err)
+	}
+
+	// Delete the diagnostic together with its ElementalHost
+	if err := controllerutil.SetOwnerReference(host, diagnostic, r.Scheme); err != nil {
+		return ctrl.Result{}, fmt.Errorf("setting owner reference: %w", err)
+	}
+
+	conditions.Set(diagnostic, &clusterv1.Condition{
+		Type:     clusterv1.ReadyCondition,
+		Status:   corev1.ConditionFalse,
+		Severity: infrastructurev1.WaitingForAgentReasonSeverity,
+		Reason:   infrastructurev1.WaitingForAgentReason,
+		Message:  "Waiting for the elemental-agent to execute the probes",
+	})
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ElementalHostDiagnosticReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrastructurev1.ElementalHostDiagnostic{}).
+		Watches(
+			&infrastructurev1.ElementalHost{},
+			handler.EnqueueRequestsFromMapFunc(r.ElementalHostToElementalHostDiagnostics),
+		).
+		Complete(r); err != nil {
+		return fmt.Errorf("initializing ElementalHostDiagnosticReconciler builder: %w", err)
+	}
+	return nil
+}
+
+// ElementalHostToElementalHostDiagnostics enqueues the pending ElementalHostDiagnostics referencing the ElementalHost,
+// so that they are linked to the ElementalHost once it is created.
+func (r *ElementalHostDiagnosticReconciler) ElementalHostToElementalHostDiagnostics(ctx context.Context, obj client.Object) []ctrl.Request {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, obj.GetNamespace()).
+		WithValues(ilog.KeyElementalHost, obj.GetName())
+
+	diagnostics := &infrastructurev1.ElementalHostDiagnosticList{}
+	if err := r.Client.List(ctx, diagnostics, client.InNamespace(obj.GetNamespace())); err != nil {
+		logger.Error(err, "Could not list ElementalHostDiagnostics")
+		return []ctrl.Request{}
+	}
+	requests := []ctrl.Request{}
+	for _, diagnostic := range diagnostics.Items {
+		if diagnostic.Spec.HostRef.Name == obj.GetName() && !diagnostic.IsCompleted() {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&diagnostic)})
+		}
+	}
+	return requests
+}
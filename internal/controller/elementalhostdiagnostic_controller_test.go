@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/twpayne/go-vfs/v4/vfst"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/client"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/config"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/identity"
+)
+
+var _ = Describe("ElementalHostDiagnostic controller", Label("controller", "elemental-host-diagnostic"), Ordered, func() {
+	ctx := context.Background()
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "host-diagnostic-test",
+		},
+	}
+	registration := v1beta1.ElementalRegistration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-registration",
+			Namespace: namespace.Name,
+		},
+		Spec: v1beta1.ElementalRegistrationSpec{
+			Config: v1beta1.Config{
+				Elemental: v1beta1.Elemental{
+					Registration: v1beta1.Registration{
+						URI: fmt.Sprintf("%s%s%s/namespaces/%s/registrations/%s", serverURL, api.Prefix, api.PrefixV1, namespace.Name, "test-registration"),
+					},
+					Agent: v1beta1.Agent{
+						WorkDir:           "/var/lib/elemental/agent",
+						InsecureAllowHTTP: true,
+					},
+				},
+			},
+		},
+	}
+	diagnostic := v1beta1.ElementalHostDiagnostic{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-diagnostic",
+			Namespace: namespace.Name,
+		},
+		Spec: v1beta1.ElementalHostDiagnosticSpec{
+			HostRef: corev1.LocalObjectReference{Name: "test-host"},
+			Probes:  []v1beta1.DiagnosticProbe{v1beta1.DiagnosticProbeDiskList, v1beta1.DiagnosticProbeTimeSync},
+		},
+	}
+	hostRequest := api.HostCreateRequest{
+		Name: "test-host",
+	}
+	var eClient client.Client
+	BeforeAll(func() {
+		fs, fsCleanup, err := vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		Expect(k8sClient.Create(ctx, &namespace)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &registration)).Should(Succeed())
+		updatedRegistration := &v1beta1.ElementalRegistration{}
+		Eventually(func() bool {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      registration.Name,
+				Namespace: registration.Namespace},
+				updatedRegistration)).Should(Succeed())
+			return len(updatedRegistration.Spec.Config.Elemental.Registration.Token) != 0
+		}).WithTimeout(time.Minute).Should(BeTrue(), "missing registration token")
+		eClient = client.NewClient("v0.0.0-test")
+		conf := config.Config{
+			Registration: registration.Spec.Config.Elemental.Registration,
+			Agent:        registration.Spec.Config.Elemental.Agent,
+		}
+		conf.Registration.Token = updatedRegistration.Spec.Config.Elemental.Registration.Token
+		idManager := identity.NewManager(fs, registration.Spec.Config.Elemental.Agent.WorkDir)
+		id, err := idManager.LoadSigningKeyOrCreateNew()
+		Expect(err).ToNot(HaveOccurred())
+		pubKey, err := id.MarshalPublic()
+		Expect(err).ToNot(HaveOccurred())
+		hostRequest.PubKey = string(pubKey)
+		Expect(eClient.Init(fs, id, conf)).Should(Succeed())
+	})
+	AfterAll(func() {
+		Expect(k8sClient.Delete(ctx, &namespace)).Should(Succeed())
+	})
+	It("should wait for the ElementalHost", func() {
+		Expect(k8sClient.Create(ctx, &diagnostic)).Should(Succeed())
+		updatedDiagnostic := &v1beta1.ElementalHostDiagnostic{}
+		Eventually(func() string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      diagnostic.Name,
+				Namespace: diagnostic.Namespace},
+				updatedDiagnostic)).Should(Succeed())
+			return conditions.GetReason(updatedDiagnostic, clusterv1.ReadyCondition)
+		}).WithTimeout(time.Minute).Should(Equal(v1beta1.MissingHostReason))
+	})
+	It("should deliver the probes to the elemental-agent", func() {
+		Expect(eClient.CreateHost(hostRequest)).Should(Succeed())
+		updatedDiagnostic := &v1beta1.ElementalHostDiagnostic{}
+		Eventually(func() string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      diagnostic.Name,
+				Namespace: diagnostic.Namespace},
+				updatedDiagnostic)).Should(Succeed())
+			return conditions.GetReason(updatedDiagnostic, clusterv1.ReadyCondition)
+		}).WithTimeout(time.Minute).Should(Equal(v1beta1.WaitingForAgentReason))
+		Expect(updatedDiagnostic.OwnerReferences).Should(HaveLen(1))
+		Expect(updatedDiagnostic.OwnerReferences[0].Name).Should(Equal(hostRequest.Name))
+		Eventually(func() []api.HostDiagnostic {
+			response, err := eClient.PatchHost(api.HostPatchRequest{}, hostRequest.Name)
+			Expect(err).ToNot(HaveOccurred())
+			return response.Diagnostics
+		}).WithTimeout(time.Minute).Should(Equal([]api.HostDiagnostic{
+			{Name: diagnostic.Name, Probes: diagnostic.Spec.Probes},
+		}))
+	})
+	It("should reject probes that were not requested", func() {
+		Expect(eClient.PostDiagnosticResults(api.DiagnosticResultsRequest{
+			Results: []v1beta1.DiagnosticProbeResult{
+				{Probe: v1beta1.DiagnosticProbeElementalState, Succeeded: true, Output: "test state"},
+			},
+		}, diagnostic.Name, hostRequest.Name)).ShouldNot(Succeed())
+		Expect(eClient.PostDiagnosticResults(api.DiagnosticResultsRequest{
+			Results: []v1beta1.DiagnosticProbeResult{
+				{Probe: v1beta1.DiagnosticProbeDiskList, Succeeded: true, Output: "test disks"},
+				{Probe: v1beta1.DiagnosticProbeDiskList, Succeeded: true, Output: "test disks"},
+			},
+		}, diagnostic.Name, hostRequest.Name)).ShouldNot(Succeed())
+	})
+	It("should store the probe results", func() {
+		results := []v1beta1.DiagnosticProbeResult{
+			{Probe: v1beta1.DiagnosticProbeDiskList, Succeeded: true, Output: "test disks"},
+			{Probe: v1beta1.DiagnosticProbeTimeSync, Succeeded: false, Output: "test timedatectl error"},
+		}
+		Expect(eClient.PostDiagnosticResults(api.DiagnosticResultsRequest{Results: results}, diagnostic.Name, hostRequest.Name)).Should(Succeed())
+		updatedDiagnostic := &v1beta1.ElementalHostDiagnostic{}
+		Eventually(func() bool {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      diagnostic.Name,
+				Namespace: diagnostic.Namespace},
+				updatedDiagnostic)).Should(Succeed())
+			return conditions.IsTrue(updatedDiagnostic, clusterv1.ReadyCondition)
+		}).WithTimeout(time.Minute).Should(BeTrue(), "ElementalHostDiagnostic should be ready")
+		Expect(updatedDiagnostic.Status.Results).Should(Equal(results))
+		Expect(updatedDiagnostic.Status.CompletedAt).ShouldNot(BeNil())
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement(fmt.Sprintf("Normal DiagnosticCompleted Probes executed by ElementalHost '%s'", hostRequest.Name)))
+		// Results are only posted once
+		Expect(eClient.PostDiagnosticResults(api.DiagnosticResultsRequest{Results: results}, diagnostic.Name, hostRequest.Name)).ShouldNot(Succeed())
+		// Completed diagnostics are no longer delivered
+		Eventually(func() []api.HostDiagnostic {
+			response, err := eClient.PatchHost(api.HostPatchRequest{}, hostRequest.Name)
+			Expect(err).ToNot(HaveOccurred())
+			return response.Diagnostics
+		}).WithTimeout(time.Minute).Should(BeEmpty())
+	})
+})
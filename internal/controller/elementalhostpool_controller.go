@@ -0,0 +1,274 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/controller/utils"
+	ilog "github.com/rancher-sandbox/cluster-api-provider-elemental/internal/log"
+)
+
+var (
+	ErrHostPoolNotAllowed = errors.New("namespace is not allowed to claim hosts from ElementalHostPool")
+)
+
+// ElementalHostPoolReconciler reconciles a ElementalHostPool object.
+type ElementalHostPoolReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhostpools,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhostpools/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhosts,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhosts/status,verbs=get;update;patch
+
+// Reconcile computes the ElementalHostPool status from the ElementalHosts in the pool,
+// and keeps the warm spare ElementalHosts on the target OS version.
+func (r *ElementalHostPoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, rerr error) {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, req.Namespace).
+		WithValues(ilog.KeyElementalHostPool, req.Name)
+	logger.Info("Reconciling ElementalHostPool")
+
+	// Fetch the ElementalHostPool
+	pool := &infrastructurev1.ElementalHostPool{}
+	if err := r.Client.Get(ctx, req.NamespacedName, pool); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("fetching ElementalHostPool: %w", err)
+	}
+
+	// Return early if the object is paused
+	paused, err := utils.IsPaused(ctx, r.Client, pool)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("checking if ElementalHostPool is paused: %w", err)
+	}
+	if paused {
+		logger.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	// Create the patch helper.
+	patchHelper, err := patch.NewHelper(pool, r.Client)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("initializing patch helper: %w", err)
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, pool); err != nil {
+			rerr = errors.Join(rerr, fmt.Errorf("patching ElementalHostPool: %w", err))
+		}
+	}()
+
+	hosts, err := listHostPoolHosts(ctx, r.Client, *pool, labels.Everything())
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("listing ElementalHostPool hosts: %w", err)
+	}
+	status := infrastructurev1.ElementalHostPoolStatus{}
+	for _, host := range hosts {
+		switch {
+		case host.Labels[infrastructurev1.LabelElementalHostInstalled] != "true":
+			status.InstallingHosts++
+		case isHostAvailable(host):
+			status.FreeHosts++
+		default:
+			if _, found := host.Labels[infrastructurev1.LabelElementalHostMachineName]; found {
+				status.ClaimedHosts++
+			}
+		}
+	}
+	pool.Status = status
+
+	if pool.Spec.Spares != nil {
+		spareHosts, err := r.reconcileSpares(ctx, *pool, hosts)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("reconciling ElementalHostPool spares: %w", err)
+		}
+		pool.Status.SpareHosts = spareHosts
+	}
+	return ctrl.Result{}, nil
+}
+
+// reconcileSpares assigns the spares target OS version to free ElementalHosts, until the desired spares count is met.
+// The elemental-agent reconciles the OS version of unassociated hosts while idle.
+// Returns the number of free ElementalHosts already on the target OS version.
+func (r *ElementalHostPoolReconciler) reconcileSpares(ctx context.Context, pool infrastructurev1.ElementalHostPool, hosts []infrastructurev1.ElementalHost) (int32, error) {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, pool.Namespace).
+		WithValues(ilog.KeyElementalHostPool, pool.Name)
+
+	target := pool.Spec.Spares.OSVersionManagement
+	var assigned, ready int32
+	candidates := []infrastructurev1.ElementalHost{}
+	for _, host := range hosts {
+		if !isHostAvailable(host) {
+			continue
+		}
+		if !isOSVersionManagementEqual(host.Spec.OSVersionManagement, target) {
+			candidates = append(candidates, host)
+			continue
+		}
+		assigned++
+		if conditions.IsTrue(&host, infrastructurev1.OSVersionReady) {
+			ready++
+		}
+	}
+
+	for i := range candidates {
+		if assigned >= pool.Spec.Spares.Count {
+			break
+		}
+		host := &candidates[i]
+		logger.Info("Assigning spares OS version to ElementalHost", ilog.KeyElementalHost, host.Name)
+		// Use an optimistic lock, so that hosts claimed concurrently by an ElementalMachine are not mutated.
+		original := host.DeepCopy()
+		host.Spec.OSVersionManagement = target
+		if err := r.Client.Patch(ctx, host, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+			return ready, fmt.Errorf("patching ElementalHost '%s' OSVersionManagement: %w", host.Name, err)
+		}
+		patchHelper, err := patch.NewHelper(host, r.Client)
+		if err != nil {
+			return ready, fmt.Errorf("initializing patch helper: %w", err)
+		}
+		conditions.Set(host, &clusterv1.Condition{
+			Type:     infrastructurev1.OSVersionReady,
+			Status:   corev1.ConditionFalse,
+			Severity: infrastructurev1.WaitingOSReconcileReasonSeverity,
+			Reason:   infrastructurev1.WaitingOSReconcileReason,
+			Message:  fmt.Sprintf("ElementalHostPool %s spares OSVersionManagement assigned.", pool.Name),
+		})
+		if err := patchHelper.Patch(ctx, host); err != nil {
+			return ready, fmt.Errorf("patching ElementalHost '%s' OSVersionReady condition: %w", host.Name, err)
+		}
+		assigned++
+	}
+	return ready, nil
+}
+
+// isOSVersionManagementEqual returns true if both OSVersionManagement definitions are equal.
+// Nil and empty definitions are considered equal.
+func isOSVersionManagementEqual(current map[string]runtime.RawExtension, desired map[string]runtime.RawExtension) bool {
+	if len(current) == 0 && len(desired) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(current, desired)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ElementalHostPoolReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrastructurev1.ElementalHostPool{}).
+		Watches(
+			&infrastructurev1.ElementalHost{},
+			handler.EnqueueRequestsFromMapFunc(r.ElementalHostToElementalHostPools),
+		).
+		Complete(r); err != nil {
+		return fmt.Errorf("initializing ElementalHostPoolReconciler builder: %w", err)
+	}
+	return nil
+}
+
+// ElementalHostToElementalHostPools enqueues all the ElementalHostPools in the ElementalHost namespace,
+// since any of them may include the ElementalHost.
+func (r *ElementalHostPoolReconciler) ElementalHostToElementalHostPools(ctx context.Context, obj client.Object) []ctrl.Request {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, obj.GetNamespace()).
+		WithValues(ilog.KeyElementalHost, obj.GetName())
+
+	pools := &infrastructurev1.ElementalHostPoolList{}
+	if err := r.Client.List(ctx, pools, client.InNamespace(obj.GetNamespace())); err != nil {
+		logger.Error(err, "Could not list ElementalHostPools")
+		return []ctrl.Request{}
+	}
+	requests := []ctrl.Request{}
+	for _, pool := range pools.Items {
+		requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&pool)})
+	}
+	return requests
+}
+
+// getHostPool returns the referenced ElementalHostPool.
+// An error wrapping ErrHostPoolNotAllowed is returned if the pool does not exist,
+// or if the namespace is not allowed to claim hosts from it.
+func getHostPool(ctx context.Context, reader client.Reader, namespace string, poolRef corev1.ObjectReference) (*infrastructurev1.ElementalHostPool, error) {
+	poolKey := client.ObjectKey{
+		Namespace: poolRef.Namespace,
+		Name:      poolRef.Name,
+	}
+	if len(poolKey.Namespace) == 0 {
+		poolKey.Namespace = namespace
+	}
+	pool := &infrastructurev1.ElementalHostPool{}
+	err := reader.Get(ctx, poolKey, pool)
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("ElementalHostPool '%s' not found: %w", poolKey.String(), ErrHostPoolNotAllowed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting ElementalHostPool '%s': %w", poolKey.String(), err)
+	}
+	if pool.Namespace != namespace && !slices.Contains(pool.Spec.AllowedNamespaces, namespace) {
+		return nil, fmt.Errorf("namespace '%s', ElementalHostPool '%s': %w", namespace, poolKey.String(), ErrHostPoolNotAllowed)
+	}
+	return pool, nil
+}
+
+// listHostPoolHosts returns the ElementalHosts included in the pool, that also match the selector.
+func listHostPoolHosts(ctx context.Context, reader client.Reader, pool infrastructurev1.ElementalHostPool, selector labels.Selector) ([]infrastructurev1.ElementalHost, error) {
+	if pool.Spec.Selector != nil {
+		poolSelector, err := metav1.LabelSelectorAsSelector(pool.Spec.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("converting ElementalHostPool LabelSelector to Selector: %w", err)
+		}
+		requirements, _ := poolSelector.Requirements()
+		selector = selector.Add(requirements...)
+	}
+	hostList := &infrastructurev1.ElementalHostList{}
+	if err := reader.List(ctx, hostList, client.InNamespace(pool.Namespace), &client.ListOptions{LabelSelector: selector}); err != nil {
+		return nil, fmt.Errorf("listing ElementalHosts: %w", err)
+	}
+	if pool.Spec.RegistrationRef == nil {
+		return hostList.Items, nil
+	}
+	hosts := []infrastructurev1.ElementalHost{}
+	for _, host := range hostList.Items {
+		owner := metav1.GetControllerOf(&host)
+		if owner != nil && owner.Kind == "ElementalRegistration" && owner.Name == pool.Spec.RegistrationRef.Name {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, nil
+}
@@ -0,0 +1,317 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+)
+
+// The ElementalHosts are registered in a shared namespace and grouped in an ElementalHostPool.
+// An ElementalMachine in a tenant namespace claims an ElementalHost from the pool.
+//
+// ElementalHostPool (this test coverage) --> ElementalHost <--> ElementalMachine (tenant namespace)
+var _ = Describe("ElementalHostPool controller", Label("controller", "elemental-host-pool"), Ordered, func() {
+	ctx := context.Background()
+	poolNamespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "elementalhostpool-test",
+		},
+	}
+	tenantNamespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "elementalhostpool-test-tenant",
+		},
+	}
+	registration := v1beta1.ElementalRegistration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-registration",
+			Namespace: poolNamespace.Name,
+		},
+	}
+	pool := v1beta1.ElementalHostPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: poolNamespace.Name,
+		},
+		Spec: v1beta1.ElementalHostPoolSpec{
+			RegistrationRef: &corev1.LocalObjectReference{Name: registration.Name},
+		},
+	}
+	freeHost := v1beta1.ElementalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-free",
+			Namespace: poolNamespace.Name,
+			Labels:    map[string]string{v1beta1.LabelElementalHostInstalled: "true"},
+		},
+	}
+	installingHost := v1beta1.ElementalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-installing",
+			Namespace: poolNamespace.Name,
+		},
+	}
+	unregisteredHost := v1beta1.ElementalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-not-in-pool",
+			Namespace: poolNamespace.Name,
+			Labels:    map[string]string{v1beta1.LabelElementalHostInstalled: "true"},
+		},
+	}
+	cluster := clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: tenantNamespace.Name,
+		},
+	}
+	machine := clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: tenantNamespace.Name,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: cluster.Name},
+		},
+		Spec: clusterv1.MachineSpec{
+			Bootstrap: clusterv1.Bootstrap{
+				DataSecretName: &testBootstrapSecretName,
+			},
+			ClusterName: cluster.Name,
+		},
+	}
+	elementalMachine := v1beta1.ElementalMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: tenantNamespace.Name,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: cluster.Name},
+		},
+		Spec: v1beta1.ElementalMachineSpec{
+			HostPoolRef: &corev1.ObjectReference{Name: pool.Name, Namespace: pool.Namespace},
+		},
+	}
+
+	BeforeAll(func() {
+		Expect(k8sClient.Create(ctx, &poolNamespace)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &tenantNamespace)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &registration)).Should(Succeed())
+		// Hosts registered through the ElementalRegistration are controlled by it
+		for _, host := range []*v1beta1.ElementalHost{&freeHost, &installingHost} {
+			host.OwnerReferences = []metav1.OwnerReference{{
+				APIVersion: v1beta1.GroupVersion.Identifier(),
+				Kind:       "ElementalRegistration",
+				Name:       registration.Name,
+				UID:        registration.UID,
+				Controller: ptr.To(true),
+			}}
+			Expect(k8sClient.Create(ctx, host)).Should(Succeed())
+		}
+		Expect(k8sClient.Create(ctx, &unregisteredHost)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &pool)).Should(Succeed())
+
+		// Create CAPI Cluster and mark it as Infrastructure Ready
+		Expect(k8sClient.Create(ctx, &cluster)).Should(Succeed())
+		clusterStatusPatch := cluster
+		clusterStatusPatch.Status = clusterv1.ClusterStatus{
+			InfrastructureReady: true,
+		}
+		patchObject(ctx, k8sClient, &cluster, &clusterStatusPatch)
+		// Create CAPI Machine and owned ElementalMachine to be associated
+		Expect(k8sClient.Create(ctx, &machine)).Should(Succeed())
+		elementalMachine.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "cluster.x-k8s.io/v1beta1",
+			Kind:       "Machine",
+			Name:       machine.Name,
+			UID:        machine.UID,
+		}}
+		Expect(k8sClient.Create(ctx, &elementalMachine)).Should(Succeed())
+	})
+	AfterAll(func() {
+		Expect(k8sClient.Delete(ctx, &tenantNamespace)).Should(Succeed())
+		Expect(k8sClient.Delete(ctx, &poolNamespace)).Should(Succeed())
+	})
+	It("should not claim hosts from a pool not allowing the namespace", func() {
+		Eventually(func() string {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&elementalMachine), &elementalMachine)).Should(Succeed())
+			return conditions.GetReason(&elementalMachine, v1beta1.AssociationReady)
+		}).WithTimeout(time.Minute).Should(Equal(v1beta1.HostPoolNotAllowedReason))
+		Expect(elementalMachine.Spec.HostRef).Should(BeNil())
+	})
+	It("should report pool status", func() {
+		Eventually(func() v1beta1.ElementalHostPoolStatus {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&pool), &pool)).Should(Succeed())
+			return pool.Status
+		}).WithTimeout(time.Minute).Should(Equal(v1beta1.ElementalHostPoolStatus{FreeHosts: 1, InstallingHosts: 1}))
+	})
+	It("should claim a host from the pool once the namespace is allowed", func() {
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&pool), &pool)).Should(Succeed())
+		pool.Spec.AllowedNamespaces = []string{tenantNamespace.Name}
+		Expect(k8sClient.Update(ctx, &pool)).Should(Succeed())
+
+		Eventually(func() *corev1.ObjectReference {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&elementalMachine), &elementalMachine)).Should(Succeed())
+			return elementalMachine.Spec.HostRef
+		}).WithTimeout(time.Minute).ShouldNot(BeNil(), "HostRef must be updated")
+		Expect(elementalMachine.Spec.HostRef.Name).Should(Equal(freeHost.Name), "Only hosts in the pool should be claimed")
+		Expect(elementalMachine.Spec.HostRef.Namespace).Should(Equal(poolNamespace.Name))
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&freeHost), &freeHost)).Should(Succeed())
+		Expect(freeHost.Spec.MachineRef).ShouldNot(BeNil())
+		Expect(freeHost.Spec.MachineRef.Namespace).Should(Equal(tenantNamespace.Name))
+		Expect(freeHost.Spec.BootstrapSecret.Namespace).Should(Equal(tenantNamespace.Name))
+
+		Eventually(func() v1beta1.ElementalHostPoolStatus {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&pool), &pool)).Should(Succeed())
+			return pool.Status
+		}).WithTimeout(time.Minute).Should(Equal(v1beta1.ElementalHostPoolStatus{ClaimedHosts: 1, InstallingHosts: 1}))
+	})
+})
+
+var _ = Describe("ElementalHostPool controller spares", Label("controller", "elemental-host-pool"), Ordered, func() {
+	ctx := context.Background()
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "elementalhostpool-spares-test",
+		},
+	}
+	osVersionManagement := map[string]runtime.RawExtension{
+		"osImage": {Raw: []byte(`"test-image:v2"`)},
+	}
+	pool := v1beta1.ElementalHostPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: namespace.Name,
+		},
+		Spec: v1beta1.ElementalHostPoolSpec{
+			Spares: &v1beta1.SparePolicy{
+				Count:               1,
+				OSVersionManagement: osVersionManagement,
+			},
+		},
+	}
+	hosts := []v1beta1.ElementalHost{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-host-a",
+				Namespace: namespace.Name,
+				Labels:    map[string]string{v1beta1.LabelElementalHostInstalled: "true"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-host-b",
+				Namespace: namespace.Name,
+				Labels:    map[string]string{v1beta1.LabelElementalHostInstalled: "true"},
+			},
+		},
+	}
+	cluster := clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: namespace.Name,
+		},
+	}
+	machine := clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: namespace.Name,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: cluster.Name},
+		},
+		Spec: clusterv1.MachineSpec{
+			Bootstrap: clusterv1.Bootstrap{
+				DataSecretName: &testBootstrapSecretName,
+			},
+			ClusterName: cluster.Name,
+		},
+	}
+	elementalMachine := v1beta1.ElementalMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: namespace.Name,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: cluster.Name},
+		},
+		Spec: v1beta1.ElementalMachineSpec{
+			HostPoolRef:         &corev1.ObjectReference{Name: pool.Name},
+			OSVersionManagement: osVersionManagement,
+		},
+	}
+	spare := v1beta1.ElementalHost{}
+
+	BeforeAll(func() {
+		Expect(k8sClient.Create(ctx, &namespace)).Should(Succeed())
+		for i := range hosts {
+			Expect(k8sClient.Create(ctx, &hosts[i])).Should(Succeed())
+		}
+		Expect(k8sClient.Create(ctx, &pool)).Should(Succeed())
+	})
+	AfterAll(func() {
+		Expect(k8sClient.Delete(ctx, &namespace)).Should(Succeed())
+	})
+	It("should assign the spares OS version to the desired number of free hosts", func() {
+		Eventually(func() []v1beta1.ElementalHost {
+			spares := []v1beta1.ElementalHost{}
+			for _, host := range hosts {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&host), &host)).Should(Succeed())
+				if len(host.Spec.OSVersionManagement) > 0 {
+					spares = append(spares, host)
+				}
+			}
+			return spares
+		}).WithTimeout(time.Minute).Should(HaveLen(1))
+		Consistently(func() int {
+			count := 0
+			for _, host := range hosts {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&host), &host)).Should(Succeed())
+				if len(host.Spec.OSVersionManagement) > 0 {
+					spare = host
+					count++
+				}
+			}
+			return count
+		}).WithTimeout(5 * time.Second).Should(Equal(1))
+		Expect(spare.Spec.OSVersionManagement).Should(Equal(osVersionManagement))
+		Expect(conditions.GetReason(&spare, v1beta1.OSVersionReady)).Should(Equal(v1beta1.WaitingOSReconcileReason))
+	})
+	It("should count the spares once the OS version is reconciled", func() {
+		// Mimic the elemental-agent reconciling the OS version
+		spareStatusPatch := spare
+		conditions.MarkTrue(&spareStatusPatch, v1beta1.OSVersionReady)
+		patchObject(ctx, k8sClient, &spare, &spareStatusPatch)
+
+		Eventually(func() int32 {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&pool), &pool)).Should(Succeed())
+			return pool.Status.SpareHosts
+		}).WithTimeout(time.Minute).Should(Equal(int32(1)))
+	})
+	It("should prefer the spare when associating an ElementalMachine on the same OS version", func() {
+		// Create CAPI Cluster and mark it as Infrastructure Ready
+		Expect(k8sClient.Create(ctx, &cluster)).Should(Succeed())
+		clusterStatusPatch := cluster
+		clusterStatusPatch.Status = clusterv1.ClusterStatus{
+			InfrastructureReady: true,
+		}
+		patchObject(ctx, k8sClient, &cluster, &clusterStatusPatch)
+		// Create CAPI Machine and owned ElementalMachine to be associated
+		Expect(k8sClient.Create(ctx, &machine)).Should(Succeed())
+		elementalMachine.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "cluster.x-k8s.io/v1beta1",
+			Kind:       "Machine",
+			Name:       machine.Name,
+			UID:        machine.UID,
+		}}
+		Expect(k8sClient.Create(ctx, &elementalMachine)).Should(Succeed())
+
+		Eventually(func() *corev1.ObjectReference {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&elementalMachine), &elementalMachine)).Should(Succeed())
+			return elementalMachine.Spec.HostRef
+		}).WithTimeout(time.Minute).ShouldNot(BeNil(), "HostRef must be updated")
+		Expect(elementalMachine.Spec.HostRef.Name).Should(Equal(spare.Name))
+	})
+})
@@ -0,0 +1,187 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/controller/utils"
+	ilog "github.com/rancher-sandbox/cluster-api-provider-elemental/internal/log"
+)
+
+// ElementalHostReservationReconciler reconciles a ElementalHostReservation object.
+type ElementalHostReservationReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhostreservations,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhostreservations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalregistrations,verbs=get;list;watch
+
+// Reconcile issues the one-time registration token of the ElementalHostReservation.
+func (r *ElementalHostReservationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, rerr error) {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, req.Namespace).
+		WithValues(ilog.KeyElementalHostReservation, req.Name)
+	logger.Info("Reconciling ElementalHostReservation")
+
+	// Fetch the ElementalHostReservation
+	reservation := &infrastructurev1.ElementalHostReservation{}
+	if err := r.Client.Get(ctx, req.NamespacedName, reservation); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("fetching ElementalHostReservation: %w", err)
+	}
+
+	// Return early if the object is paused
+	paused, err := utils.IsPaused(ctx, r.Client, reservation)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("checking if ElementalHostReservation is paused: %w", err)
+	}
+	if paused {
+		logger.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	// Create the patch helper.
+	patchHelper, err := patch.NewHelper(reservation, r.Client)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("initializing patch helper: %w", err)
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, reservation); err != nil {
+			rerr = errors.Join(rerr, fmt.Errorf("patching ElementalHostReservation: %w", err))
+		}
+	}()
+
+	// The token is only issued once. Delete the reservation to revoke it.
+	if len(reservation.Status.Token) > 0 {
+		return ctrl.Result{}, nil
+	}
+
+	registration := &infrastructurev1.ElementalRegistration{}
+	err = r.Client.Get(ctx, client.ObjectKey{Namespace: reservation.Namespace, Name: reservation.Spec.RegistrationRef.Name}, registration)
+	if apierrors.IsNotFound(err) {
+		logger.Info("ElementalRegistration not found", ilog.KeyElementalRegistration, reservation.Spec.RegistrationRef.Name)
+		conditions.Set(reservation, &clusterv1.Condition{
+			Type:     clusterv1.ReadyCondition,
+			Status:   corev1.ConditionFalse,
+			Severity: infrastructurev1.MissingRegistrationReasonSeverity,
+			Reason:   infrastructurev1.MissingRegistrationReason,
+			Message:  fmt.Sprintf("ElementalRegistration '%s' not found", reservation.Spec.RegistrationRef.Name),
+		})
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("fetching ElementalRegistration '%s': %w", reservation.Spec.RegistrationRef.Name, err)
+	}
+	// Wait for the ElementalRegistration to be initialized
+	if len(registration.Spec.Config.Elemental.Registration.URI) == 0 || registration.Spec.PrivateKeyRef == nil {
+		logger.Info("ElementalRegistration is not ready yet", ilog.KeyElementalRegistration, registration.Name)
+		conditions.Set(reservation, &clusterv1.Condition{
+			Type:     clusterv1.ReadyCondition,
+			Status:   corev1.ConditionFalse,
+			Severity: infrastructurev1.MissingRegistrationReasonSeverity,
+			Reason:   infrastructurev1.MissingRegistrationReason,
+			Message:  fmt.Sprintf("ElementalRegistration '%s' is not ready yet", registration.Name),
+		})
+		return ctrl.Result{}, nil
+	}
+
+	// Delete the reservation together with its ElementalRegistration
+	if err := controllerutil.SetOwnerReference(registration, reservation, r.Scheme); err != nil {
+		return ctrl.Result{}, fmt.Errorf("setting owner reference: %w", err)
+	}
+
+	logger.Info("Issuing new one-time registration token")
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		ID:        reservation.Name,
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Issuer:    "ElementalHostReservationReconciler",
+		Subject:   registration.Spec.Config.Elemental.Registration.URI,
+		Audience:  []string{registration.Spec.Config.Elemental.Registration.URI},
+	}
+	if reservation.Spec.TokenDuration != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(reservation.Spec.TokenDuration.Duration))
+	}
+	token, err := signRegistrationToken(ctx, r.Client, *registration, claims)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("signing one-time registration token: %w", err)
+	}
+	reservation.Status.Token = token
+	conditions.Set(reservation, &clusterv1.Condition{
+		Type:   clusterv1.ReadyCondition,
+		Status: corev1.ConditionTrue,
+	})
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ElementalHostReservationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrastructurev1.ElementalHostReservation{}).
+		Watches(
+			&infrastructurev1.ElementalRegistration{},
+			handler.EnqueueRequestsFromMapFunc(r.ElementalRegistrationToElementalHostReservations),
+		).
+		Complete(r); err != nil {
+		return fmt.Errorf("initializing ElementalHostReservationReconciler builder: %w", err)
+	}
+	return nil
+}
+
+// ElementalRegistrationToElementalHostReservations enqueues the ElementalHostReservations referencing the ElementalRegistration,
+// so that their token is issued once the ElementalRegistration is ready.
+func (r *ElementalHostReservationReconciler) ElementalRegistrationToElementalHostReservations(ctx context.Context, obj client.Object) []ctrl.Request {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, obj.GetNamespace()).
+		WithValues(ilog.KeyElementalRegistration, obj.GetName())
+
+	reservations := &infrastructurev1.ElementalHostReservationList{}
+	if err := r.Client.List(ctx, reservations, client.InNamespace(obj.GetNamespace())); err != nil {
+		logger.Error(err, "Could not list ElementalHostReservations")
+		return []ctrl.Request{}
+	}
+	requests := []ctrl.Request{}
+	for _, reservation := range reservations.Items {
+		if reservation.Spec.RegistrationRef.Name == obj.GetName() && len(reservation.Status.Token) == 0 {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&reservation)})
+		}
+	}
+	return requests
+}
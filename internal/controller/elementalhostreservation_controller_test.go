@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/twpayne/go-vfs/v4"
+	"github.com/twpayne/go-vfs/v4/vfst"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/client"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/config"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/identity"
+)
+
+var _ = Describe("ElementalHostReservation controller", Label("controller", "elemental-host-reservation"), Ordered, func() {
+	ctx := context.Background()
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "host-reservation-test",
+		},
+	}
+	registration := v1beta1.ElementalRegistration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-registration",
+			Namespace: namespace.Name,
+		},
+		Spec: v1beta1.ElementalRegistrationSpec{
+			HostLabels: map[string]string{"foo": "bar"},
+			Config: v1beta1.Config{
+				Elemental: v1beta1.Elemental{
+					Registration: v1beta1.Registration{
+						URI: fmt.Sprintf("%s%s%s/namespaces/%s/registrations/%s", serverURL, api.Prefix, api.PrefixV1, namespace.Name, "test-registration"),
+					},
+					Agent: v1beta1.Agent{
+						WorkDir:           "/var/lib/elemental/agent",
+						InsecureAllowHTTP: true,
+					},
+				},
+			},
+		},
+	}
+	reservation := v1beta1.ElementalHostReservation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-reserved-host",
+			Namespace: namespace.Name,
+		},
+		Spec: v1beta1.ElementalHostReservationSpec{
+			RegistrationRef: corev1.LocalObjectReference{Name: registration.Name},
+			MACAddresses:    []string{"52:54:00:12:34:56"},
+			HostLabels:      map[string]string{"example.com/rack": "rack-1"},
+		},
+	}
+	var fs vfs.FS
+	var err error
+	var fsCleanup func()
+	var eClient client.Client
+	var pubKey []byte
+	BeforeAll(func() {
+		fs, fsCleanup, err = vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		Expect(k8sClient.Create(ctx, &namespace)).Should(Succeed())
+	})
+	AfterAll(func() {
+		Expect(k8sClient.Delete(ctx, &namespace)).Should(Succeed())
+	})
+	It("should wait for the ElementalRegistration", func() {
+		Expect(k8sClient.Create(ctx, &reservation)).Should(Succeed())
+		updatedReservation := &v1beta1.ElementalHostReservation{}
+		Eventually(func() string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      reservation.Name,
+				Namespace: reservation.Namespace},
+				updatedReservation)).Should(Succeed())
+			return conditions.GetReason(updatedReservation, clusterv1.ReadyCondition)
+		}).WithTimeout(time.Minute).Should(Equal(v1beta1.MissingRegistrationReason))
+		Expect(updatedReservation.Status.Token).Should(BeEmpty())
+	})
+	It("should issue a one-time registration token", func() {
+		Expect(k8sClient.Create(ctx, &registration)).Should(Succeed())
+		updatedReservation := &v1beta1.ElementalHostReservation{}
+		Eventually(func() bool {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      reservation.Name,
+				Namespace: reservation.Namespace},
+				updatedReservation)).Should(Succeed())
+			return conditions.IsTrue(updatedReservation, clusterv1.ReadyCondition)
+		}).WithTimeout(time.Minute).Should(BeTrue(), "ElementalHostReservation should be ready")
+		Expect(updatedReservation.Status.Token).ShouldNot(BeEmpty())
+		Expect(updatedReservation.OwnerReferences).Should(HaveLen(1))
+		Expect(updatedReservation.OwnerReferences[0].Name).Should(Equal(registration.Name))
+
+		// Initialize the agent client with the one-time token
+		eClient = client.NewClient("v0.0.0-test")
+		conf := config.Config{
+			Registration: registration.Spec.Config.Elemental.Registration,
+			Agent:        registration.Spec.Config.Elemental.Agent,
+		}
+		conf.Registration.Token = updatedReservation.Status.Token
+		idManager := identity.NewManager(fs, registration.Spec.Config.Elemental.Agent.WorkDir)
+		id, err := idManager.LoadSigningKeyOrCreateNew()
+		Expect(err).ToNot(HaveOccurred())
+		pubKey, err = id.MarshalPublic()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(eClient.Init(fs, id, conf)).Should(Succeed())
+	})
+	It("should return the reserved registration", func() {
+		updatedReservation := &v1beta1.ElementalHostReservation{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      reservation.Name,
+			Namespace: reservation.Namespace},
+			updatedReservation)).Should(Succeed())
+		response, err := eClient.GetRegistration()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.HostName).Should(Equal(reservation.Name))
+		Expect(response.HostLabels).Should(Equal(map[string]string{"foo": "bar", "example.com/rack": "rack-1"}))
+		Expect(response.Config.Elemental.Registration.Token).Should(Equal(updatedReservation.Status.Token), "Registration token must not be disclosed")
+	})
+	It("should only register the reserved host", func() {
+		request := api.HostCreateRequest{
+			Name:         "test-other-host",
+			PubKey:       string(pubKey),
+			MACAddresses: []string{"52:54:00:12:34:56"},
+		}
+		Expect(eClient.CreateHost(request)).ShouldNot(Succeed(), "Only the reserved host name can be registered")
+		request.Name = reservation.Name
+		request.MACAddresses = []string{"52:54:00:ab:cd:ef"}
+		Expect(eClient.CreateHost(request)).ShouldNot(Succeed(), "Only the expected MAC addresses can be registered")
+		request.MACAddresses = []string{"52:54:00:12:34:56"}
+		Expect(eClient.CreateHost(request)).Should(Succeed())
+
+		host := &v1beta1.ElementalHost{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      reservation.Name,
+			Namespace: reservation.Namespace},
+			host)).Should(Succeed())
+		Expect(host.Labels).Should(HaveKeyWithValue("example.com/rack", "rack-1"))
+		updatedReservation := &v1beta1.ElementalHostReservation{}
+		Eventually(func() *corev1.ObjectReference {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      reservation.Name,
+				Namespace: reservation.Namespace},
+				updatedReservation)).Should(Succeed())
+			return updatedReservation.Status.HostRef
+		}).WithTimeout(time.Minute).ShouldNot(BeNil(), "One-time token should be consumed")
+		Expect(updatedReservation.Status.HostRef.Name).Should(Equal(reservation.Name))
+	})
+	It("should not register twice with the same token", func() {
+		Expect(k8sClient.Delete(ctx, &v1beta1.ElementalHost{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      reservation.Name,
+				Namespace: reservation.Namespace,
+			},
+		})).Should(Succeed())
+		request := api.HostCreateRequest{
+			Name:         reservation.Name,
+			PubKey:       string(pubKey),
+			MACAddresses: []string{"52:54:00:12:34:56"},
+		}
+		Expect(eClient.CreateHost(request)).ShouldNot(Succeed(), "One-time token must not be reused")
+	})
+})
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -29,7 +30,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
@@ -56,6 +60,8 @@ type ElementalMachineReconciler struct {
 	Scheme        *runtime.Scheme
 	Tracker       utils.RemoteTracker
 	RequeuePeriod time.Duration
+	// Recorder is used to emit events on association and reset of ElementalHosts.
+	Recorder record.EventRecorder
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -206,6 +212,16 @@ func (r *ElementalMachineReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, fmt.Errorf("fetching ElementalMachine: %w", err)
 	}
 
+	// Return early if the object or its Cluster is paused
+	paused, err := utils.IsPaused(ctx, r.Client, elementalMachine)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("checking if ElementalMachine is paused: %w", err)
+	}
+	if paused {
+		logger.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
 	// Create the patch helper.
 	patchHelper, err := patch.NewHelper(elementalMachine, r.Client)
 	if err != nil {
@@ -246,7 +262,12 @@ func (r *ElementalMachineReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	}
 
 	// Reconciliation step #2: If the resource has status.failureReason or status.failureMessage set, exit the reconciliation
-	// TODO: status.failureReason and failureMessage not status.implemented yet.
+	// Note: This check should not be blocking the deletion, so that the associated host can be reset.
+	if elementalMachine.GetDeletionTimestamp().IsZero() &&
+		(elementalMachine.Status.FailureReason != nil || elementalMachine.Status.FailureMessage != nil) {
+		logger.Info("ElementalMachine has a terminal failure, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
 
 	// Fetch the Cluster
 	cluster, err := util.GetClusterFromMetadata(ctx, r.Client, elementalMachine.ObjectMeta)
@@ -307,9 +328,16 @@ func (r *ElementalMachineReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		}
 		// Reconciliation step #7: Reconcile provider-specific machine infrastructure
 		result, err := r.reconcileNormal(ctx, cluster, elementalMachine, *machine)
+		// Reconciliation step #7-1: If they are terminal failures, set status.failureReason and status.failureMessage
+		var terminalErr *TerminalError
+		if errors.As(err, &terminalErr) {
+			logger.Error(err, "ElementalMachine terminal failure", "failureReason", terminalErr.Reason)
+			elementalMachine.Status.Ready = false
+			elementalMachine.Status.FailureReason = &terminalErr.Reason
+			elementalMachine.Status.FailureMessage = ptr.To(err.Error())
+			return ctrl.Result{}, nil
+		}
 		if err != nil {
-			// Reconciliation step #7-1: If they are terminal failures, set status.failureReason and status.failureMessage
-			// TODO: Consider implementing status.failureReason and status.failureMessage
 			return ctrl.Result{}, fmt.Errorf("reconciling ElementalMachine: %w", err)
 		}
 		return result, nil
@@ -351,6 +379,7 @@ func (r *ElementalMachineReconciler) reconcileNormal(ctx context.Context, cluste
 	// Re-association with a new host should happen for this ElementalMachine then.
 	if apierrors.IsNotFound(err) {
 		logger.Info("ElementalHost is not found. Removing association reference", ilog.KeyElementalHost, elementalMachine.Spec.HostRef.Name)
+		r.Recorder.Eventf(elementalMachine, corev1.EventTypeWarning, "Disassociated", "Associated ElementalHost '%s' not found", elementalMachine.Spec.HostRef.Name)
 		conditions.Set(elementalMachine, &clusterv1.Condition{
 			Type:     infrastructurev1.AssociationReady,
 			Status:   corev1.ConditionFalse,
@@ -359,6 +388,7 @@ func (r *ElementalMachineReconciler) reconcileNormal(ctx context.Context, cluste
 			Message:  fmt.Sprintf("Previously associated host not found: %s", elementalMachine.Spec.HostRef.Name),
 		})
 		elementalMachine.Spec.ProviderID = nil
+		elementalMachine.Status.NodeName = ""
 		elementalMachine.Spec.HostRef = nil
 		return ctrl.Result{RequeueAfter: r.RequeuePeriod}, nil
 	}
@@ -383,6 +413,22 @@ func (r *ElementalMachineReconciler) reconcileNormal(ctx context.Context, cluste
 	})
 	logger = logger.WithValues(ilog.KeyElementalHost, host.Name)
 
+	// A terminal failure of the associated ElementalHost is a terminal failure of this ElementalMachine as well.
+	if host.Status.FailureReason != nil {
+		logger.Info("ElementalHost has a terminal failure")
+		conditions.Set(elementalMachine, &clusterv1.Condition{
+			Type:     infrastructurev1.HostReady,
+			Status:   corev1.ConditionFalse,
+			Severity: infrastructurev1.HostFailedReasonSeverity,
+			Reason:   infrastructurev1.HostFailedReason,
+			Message:  fmt.Sprintf("ElementalHost '%s' failed: %s", host.Name, ptr.Deref(host.Status.FailureMessage, "")),
+		})
+		return ctrl.Result{}, &TerminalError{
+			Reason: *host.Status.FailureReason,
+			Err:    fmt.Errorf("associated ElementalHost '%s' failed: %s", host.Name, ptr.Deref(host.Status.FailureMessage, "")),
+		}
+	}
+
 	// Check if the Host is installed and Bootstrapped
 	if value, found := host.Labels[infrastructurev1.LabelElementalHostInstalled]; !found || value != "true" {
 		logger.Info("Waiting for ElementalHost to be installed")
@@ -404,17 +450,30 @@ func (r *ElementalMachineReconciler) reconcileNormal(ctx context.Context, cluste
 			Reason:   infrastructurev1.HostWaitingForBootstrapReason,
 			Message:  fmt.Sprintf("Waiting for ElementalHost '%s' to be bootstrapped", host.Name),
 		})
-		return ctrl.Result{}, nil
+		return r.reconcileBootstrapTimeout(ctx, elementalMachine, host)
 	}
 
-	// Mark the HostReady condition true.
-	// This is different than setting the elementalMachine.Status.Ready flag.
-	// It just highlights that there is nothing to do anymore on the host side.
-	conditions.Set(elementalMachine, &clusterv1.Condition{
-		Type:     infrastructurev1.HostReady,
-		Status:   corev1.ConditionTrue,
-		Severity: clusterv1.ConditionSeverityInfo,
-	})
+	// Reflect the ElementalHost reachability.
+	// An unreachable host does not block the reconciliation, as it may be a transient failure.
+	if conditions.IsFalse(host, infrastructurev1.AgentReachable) {
+		logger.Info("ElementalHost agent is unreachable")
+		conditions.Set(elementalMachine, &clusterv1.Condition{
+			Type:     infrastructurev1.HostReady,
+			Status:   corev1.ConditionFalse,
+			Severity: infrastructurev1.HostUnreachableReasonSeverity,
+			Reason:   infrastructurev1.HostUnreachableReason,
+			Message:  fmt.Sprintf("ElementalHost '%s' is unreachable: %s", host.Name, conditions.GetMessage(host, infrastructurev1.AgentReachable)),
+		})
+	} else {
+		// Mark the HostReady condition true.
+		// This is different than setting the elementalMachine.Status.Ready flag.
+		// It just highlights that there is nothing to do anymore on the host side.
+		conditions.Set(elementalMachine, &clusterv1.Condition{
+			Type:     infrastructurev1.HostReady,
+			Status:   corev1.ConditionTrue,
+			Severity: clusterv1.ConditionSeverityInfo,
+		})
+	}
 
 	// Wait for the Cluster's ControlPlane to be initialized before setting the ProviderID
 	// This controller will need to set the `node.spec.providerID` on the downstream cluster,
@@ -433,7 +492,7 @@ func (r *ElementalMachineReconciler) reconcileNormal(ctx context.Context, cluste
 	}
 
 	// Set the ProviderID on both ElementalMachine and downstream node
-	if err := r.setProviderID(ctx, elementalMachine, cluster); err != nil {
+	if err := r.setProviderID(ctx, elementalMachine, *host, cluster); err != nil {
 		return ctrl.Result{RequeueAfter: r.RequeuePeriod}, fmt.Errorf("setting ProviderID: %w", err)
 	}
 
@@ -441,11 +500,86 @@ func (r *ElementalMachineReconciler) reconcileNormal(ctx context.Context, cluste
 	logger.Info("ElementalMachine is ready")
 	elementalMachine.Status.Ready = true
 
+	// Synchronize the ElementalHost metadata to the downstream node.
+	// This does not affect the ElementalMachine readiness.
+	if err := r.syncNode(ctx, elementalMachine, *host, cluster); err != nil {
+		return ctrl.Result{RequeueAfter: r.RequeuePeriod}, fmt.Errorf("synchronizing downstream node: %w", err)
+	}
+
 	// Reconciliation step #11: Set spec.failureDomain to the provider-specific failure domain the instance is running in (optional)
 	// TODO: Not implemented yet.
 	return ctrl.Result{}, nil
 }
 
+// reconcileBootstrapTimeout enforces the ElementalMachine bootstrap deadline, if any.
+// The deadline starts when the ElementalMachine begins waiting for the associated ElementalHost to be bootstrapped.
+func (r *ElementalMachineReconciler) reconcileBootstrapTimeout(ctx context.Context, elementalMachine *infrastructurev1.ElementalMachine, host *infrastructurev1.ElementalHost) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, elementalMachine.Namespace).
+		WithValues(ilog.KeyElementalMachine, elementalMachine.Name).
+		WithValues(ilog.KeyElementalHost, host.Name)
+
+	timeout := elementalMachine.Spec.BootstrapTimeout
+	if timeout == nil {
+		return ctrl.Result{}, nil
+	}
+	deadline := conditions.GetLastTransitionTime(elementalMachine, infrastructurev1.HostReady).Add(timeout.Duration.Duration)
+	if remaining := time.Until(deadline); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	message := fmt.Sprintf("ElementalHost '%s' was not bootstrapped within %s", host.Name, timeout.Duration.Duration)
+	logger.Info("ElementalHost bootstrap deadline passed", "policy", timeout.Policy)
+	conditions.Set(elementalMachine, &clusterv1.Condition{
+		Type:     infrastructurev1.HostReady,
+		Status:   corev1.ConditionFalse,
+		Severity: infrastructurev1.HostBootstrapTimeoutReasonSeverity,
+		Reason:   infrastructurev1.HostBootstrapTimeoutReason,
+		Message:  message,
+	})
+	if timeout.Policy == infrastructurev1.TimeoutPolicyFail {
+		return ctrl.Result{}, &TerminalError{
+			Reason: capierrors.JoinClusterTimeoutMachineError,
+			Err:    errors.New(message),
+		}
+	}
+
+	// Reset the ElementalHost and remove the association, so that a new ElementalHost can be associated.
+	if err := r.markHostForReset(ctx, host, message); err != nil {
+		return ctrl.Result{}, fmt.Errorf("marking ElementalHost for reset: %w", err)
+	}
+	r.Recorder.Eventf(elementalMachine, corev1.EventTypeWarning, "Disassociated", "%s, triggered its reset", message)
+	conditions.Set(elementalMachine, &clusterv1.Condition{
+		Type:     infrastructurev1.AssociationReady,
+		Status:   corev1.ConditionFalse,
+		Severity: infrastructurev1.HostBootstrapTimeoutReasonSeverity,
+		Reason:   infrastructurev1.HostBootstrapTimeoutReason,
+		Message:  message,
+	})
+	elementalMachine.Spec.ProviderID = nil
+	elementalMachine.Status.NodeName = ""
+	elementalMachine.Spec.HostRef = nil
+	return ctrl.Result{RequeueAfter: r.RequeuePeriod}, nil
+}
+
+// markHostForReset labels the ElementalHost to trigger its reset.
+// The reason is reported in an event on the ElementalHost.
+func (r *ElementalMachineReconciler) markHostForReset(ctx context.Context, host *infrastructurev1.ElementalHost, reason string) error {
+	patchHelper, err := patch.NewHelper(host, r.Client)
+	if err != nil {
+		return fmt.Errorf("initializing patch helper: %w", err)
+	}
+	if host.Labels == nil {
+		host.Labels = map[string]string{}
+	}
+	host.Labels[infrastructurev1.LabelElementalHostNeedsReset] = "true"
+	if err := patchHelper.Patch(ctx, host); err != nil {
+		return fmt.Errorf("patching ElementalHost: %w", err)
+	}
+	r.Recorder.Eventf(host, corev1.EventTypeNormal, "ResetTriggered", "Reset triggered: %s", reason)
+	return nil
+}
+
 // setProviderID updates the ProviderID on the ElementalMachine and on the equivalent downstream cluster node.
 //
 // See: https://cluster-api.sigs.k8s.io/developer/providers/machine-infrastructure
@@ -454,7 +588,7 @@ func (r *ElementalMachineReconciler) reconcileNormal(ctx context.Context, cluste
 // This field is expected to match the value set by the KCM cloud provider in the Nodes.
 // The Machine controller bubbles it up to the Machine CR, and it’s used to find the matching Node.
 // Any other consumers can use the providerID as the source of truth to match both Machines and Nodes.
-func (r *ElementalMachineReconciler) setProviderID(ctx context.Context, elementalMachine *infrastructurev1.ElementalMachine, cluster *clusterv1.Cluster) error {
+func (r *ElementalMachineReconciler) setProviderID(ctx context.Context, elementalMachine *infrastructurev1.ElementalMachine, host infrastructurev1.ElementalHost, cluster *clusterv1.Cluster) error {
 	logger := log.FromContext(ctx).
 		WithValues(ilog.KeyNamespace, elementalMachine.Namespace).
 		WithValues(ilog.KeyElementalMachine, elementalMachine.Name).
@@ -476,9 +610,16 @@ func (r *ElementalMachineReconciler) setProviderID(ctx context.Context, elementa
 
 	// Set the ProviderID on the downstream cluster node
 	logger.Info("Setting providerID on downstream node")
-	err := r.Tracker.SetProviderID(ctx,
+	// The node is expected to be named after the host, unless it was renamed,
+	// for example by the bootstrap provider or the OS.
+	// In that case it is matched by the system UUID or addresses reported by the elemental-agent.
+	nodeName, err := r.Tracker.SetProviderID(ctx,
 		client.ObjectKeyFromObject(cluster),
-		elementalMachine.Spec.HostRef.Name,
+		utils.NodeIdentity{
+			Name:       elementalMachine.Spec.HostRef.Name,
+			SystemUUID: host.Status.SystemUUID,
+			Addresses:  host.Status.Addresses,
+		},
 		providerID)
 	if errors.Is(err, utils.ErrRemoteNodeNotFound) {
 		logger.Error(err, "Remote Node not found")
@@ -489,7 +630,13 @@ func (r *ElementalMachineReconciler) setProviderID(ctx context.Context, elementa
 			Reason:   infrastructurev1.NodeNotFoundReason,
 			Message:  fmt.Sprintf("Downstream cluster node '%s' is not found.", elementalMachine.Spec.HostRef.Name),
 		})
-		return fmt.Errorf("setting provider ID on remote node '%s': %w", elementalMachine.Spec.HostRef.Name, err)
+		err := fmt.Errorf("setting provider ID on remote node '%s': %w", elementalMachine.Spec.HostRef.Name, err)
+		// The node may take a while to join the downstream cluster after the host is bootstrapped.
+		// Consider it a terminal failure only if the node is missing for longer than the grace period.
+		if time.Since(conditions.GetLastTransitionTime(elementalMachine, infrastructurev1.ProviderIDReady).Time) > NodeNotFoundGracePeriod {
+			return &TerminalError{Reason: capierrors.JoinClusterTimeoutMachineError, Err: err}
+		}
+		return err
 	}
 	if err != nil {
 		logger.Error(err, "Could not access remote Node")
@@ -498,6 +645,10 @@ func (r *ElementalMachineReconciler) setProviderID(ctx context.Context, elementa
 
 	// Reconciliation step #8: Set spec.providerID to the provider-specific identifier for the provider’s machine instance
 	elementalMachine.Spec.ProviderID = &providerID
+	elementalMachine.Status.NodeName = nodeName
+	if nodeName != elementalMachine.Spec.HostRef.Name {
+		logger.Info("Downstream cluster node matched by system UUID or addresses", "nodeName", nodeName)
+	}
 	conditions.Set(elementalMachine, &clusterv1.Condition{
 		Type:     infrastructurev1.ProviderIDReady,
 		Status:   corev1.ConditionTrue,
@@ -507,6 +658,64 @@ func (r *ElementalMachineReconciler) setProviderID(ctx context.Context, elementa
 	return nil
 }
 
+// syncNode synchronizes the ElementalHost metadata selected by the NodeSync policy to the downstream cluster node.
+// The sync is always performed, even if no policy is defined, to remove any metadata synchronized previously.
+func (r *ElementalMachineReconciler) syncNode(ctx context.Context, elementalMachine *infrastructurev1.ElementalMachine, host infrastructurev1.ElementalHost, cluster *clusterv1.Cluster) error {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, elementalMachine.Namespace).
+		WithValues(ilog.KeyElementalMachine, elementalMachine.Name).
+		WithValues(ilog.KeyElementalHost, host.Name)
+
+	nodeName := elementalMachine.Status.NodeName
+	if len(nodeName) == 0 {
+		nodeName = host.Name
+	}
+	metadata := utils.NodeMetadata{}
+	policy := elementalMachine.Spec.NodeSync
+	if policy != nil {
+		metadata.Labels = filterByPrefix(host.Labels, policy.LabelPrefixes)
+		metadata.Annotations = filterByPrefix(host.Annotations, policy.AnnotationPrefixes)
+		metadata.Taints = policy.Taints
+	}
+
+	logger.V(ilog.DebugLevel).Info("Synchronizing downstream node", "nodeName", nodeName)
+	if err := r.Tracker.SyncNode(ctx, client.ObjectKeyFromObject(cluster), nodeName, metadata); err != nil {
+		logger.Error(err, "Could not synchronize downstream node")
+		conditions.Set(elementalMachine, &clusterv1.Condition{
+			Type:     infrastructurev1.NodeSyncReady,
+			Status:   corev1.ConditionFalse,
+			Severity: infrastructurev1.NodeSyncFailedReasonSeverity,
+			Reason:   infrastructurev1.NodeSyncFailedReason,
+			Message:  fmt.Sprintf("Could not synchronize downstream cluster node '%s': %s", nodeName, err.Error()),
+		})
+		return fmt.Errorf("synchronizing downstream node '%s': %w", nodeName, err)
+	}
+	if policy == nil {
+		conditions.Delete(elementalMachine, infrastructurev1.NodeSyncReady)
+		return nil
+	}
+	conditions.Set(elementalMachine, &clusterv1.Condition{
+		Type:     infrastructurev1.NodeSyncReady,
+		Status:   corev1.ConditionTrue,
+		Severity: clusterv1.ConditionSeverityInfo,
+	})
+	return nil
+}
+
+// filterByPrefix returns the entries whose key starts with any of the prefixes.
+func filterByPrefix(entries map[string]string, prefixes []string) map[string]string {
+	filtered := map[string]string{}
+	for key, value := range entries {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				filtered[key] = value
+				break
+			}
+		}
+	}
+	return filtered
+}
+
 func (r *ElementalMachineReconciler) associateElementalHost(ctx context.Context, elementalMachine *infrastructurev1.ElementalMachine, machine clusterv1.Machine) (ctrl.Result, error) {
 	logger := log.FromContext(ctx).
 		WithValues(ilog.KeyNamespace, elementalMachine.Namespace).
@@ -514,12 +723,25 @@ func (r *ElementalMachineReconciler) associateElementalHost(ctx context.Context,
 
 	// Find available host for association
 	elementalHostCandidate, err := r.findAvailableHost(ctx, *elementalMachine)
+	if errors.Is(err, ErrHostPoolNotAllowed) {
+		logger.Info("Can not claim hosts from ElementalHostPool", "reason", err.Error())
+		r.Recorder.Event(elementalMachine, corev1.EventTypeWarning, "AssociationFailed", err.Error())
+		conditions.Set(elementalMachine, &clusterv1.Condition{
+			Type:     infrastructurev1.AssociationReady,
+			Status:   corev1.ConditionFalse,
+			Severity: infrastructurev1.HostPoolNotAllowedReasonSeverity,
+			Reason:   infrastructurev1.HostPoolNotAllowedReason,
+			Message:  err.Error(),
+		})
+		return ctrl.Result{RequeueAfter: r.RequeuePeriod}, nil
+	}
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("finding available host for association: %w", err)
 	}
 	// If none available, try again later
 	if elementalHostCandidate == nil {
 		logger.Info("No ElementalHosts available for association. Waiting for new hosts to be provisioned.")
+		r.Recorder.Event(elementalMachine, corev1.EventTypeWarning, "AssociationFailed", "No ElementalHosts available for association")
 		conditions.Set(elementalMachine, &clusterv1.Condition{
 			Type:     infrastructurev1.AssociationReady,
 			Status:   corev1.ConditionFalse,
@@ -537,6 +759,8 @@ func (r *ElementalMachineReconciler) associateElementalHost(ctx context.Context,
 	}
 
 	logger.Info("ElementalHost linked successfully")
+	r.Recorder.Eventf(elementalMachine, corev1.EventTypeNormal, "Associated", "Associated to ElementalHost '%s'", elementalHostCandidate.Name)
+	r.Recorder.Eventf(elementalHostCandidate, corev1.EventTypeNormal, "Associated", "Associated to ElementalMachine '%s/%s'", elementalMachine.Namespace, elementalMachine.Name)
 
 	// Link the ElementalMachine to ElementalHost
 	elementalMachine.Spec.HostRef = &corev1.ObjectReference{
@@ -567,11 +791,7 @@ func (r *ElementalMachineReconciler) associateElementalHost(ctx context.Context,
 }
 
 func (r *ElementalMachineReconciler) linkElementalHostToElementalMachine(ctx context.Context, machine clusterv1.Machine, elementalMachine infrastructurev1.ElementalMachine, elementalHostCandidate *infrastructurev1.ElementalHost) error {
-	// Create the patch helper.
-	patchHelper, err := patch.NewHelper(elementalHostCandidate, r.Client)
-	if err != nil {
-		return fmt.Errorf("initializing patch helper: %w", err)
-	}
+	original := elementalHostCandidate.DeepCopy()
 
 	// Link the ElementalHost to ElementalMachine
 	elementalHostCandidate.Spec.MachineRef = &corev1.ObjectReference{
@@ -604,8 +824,10 @@ func (r *ElementalMachineReconciler) linkElementalHostToElementalMachine(ctx con
 	// Reconciliation step #10: Set status.addresses to the provider-specific set of instance addresses
 	// TODO: Fetch the addresses from ElementalHost to update the associated ElementalMachine
 
-	// Patch the associated ElementalHost
-	if err := patchHelper.Patch(ctx, elementalHostCandidate); err != nil {
+	// Patch the associated ElementalHost.
+	// The optimistic lock guarantees that the ElementalHost is claimed atomically,
+	// even by ElementalMachines in different namespaces sharing the same ElementalHostPool.
+	if err := r.Client.Patch(ctx, elementalHostCandidate, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
 		return fmt.Errorf("patching ElementalHost: %w", err)
 	}
 	return nil
@@ -617,9 +839,18 @@ func (r *ElementalMachineReconciler) findAvailableHost(ctx context.Context, elem
 		WithValues(ilog.KeyElementalMachine, elementalMachine.Name)
 	logger.Info("Finding a suitable ElementalHost to associate")
 
+	// Claim hosts from the referenced ElementalHostPool, if any.
+	var pool *infrastructurev1.ElementalHostPool
+	if elementalMachine.Spec.HostPoolRef != nil {
+		var err error
+		if pool, err = getHostPool(ctx, r.Client, elementalMachine.Namespace, *elementalMachine.Spec.HostPoolRef); err != nil {
+			return nil, fmt.Errorf("getting ElementalHostPool: %w", err)
+		}
+	}
+
 	// First lookup ElementalHosts which may have been already linked before (ElementalMachine <-- ElementalHost).
 	// This can happen if the association process stopped abruptly, before finalizing the ElementalMachine --> ElementalHost link.
-	alreadyAssociatedHost, err := r.lookUpAlreadyLinkedHost(ctx, elementalMachine)
+	alreadyAssociatedHost, err := r.lookUpAlreadyLinkedHost(ctx, elementalMachine, pool)
 	if err != nil {
 		return nil, fmt.Errorf("looking up already associated hosts: %w", err)
 	}
@@ -630,14 +861,14 @@ func (r *ElementalMachineReconciler) findAvailableHost(ctx context.Context, elem
 	}
 
 	// If no already associated ElementalHost is found, find a new one.
-	newHostCandidate, err := r.lookUpNewAvailableHost(ctx, elementalMachine)
+	newHostCandidate, err := r.lookUpNewAvailableHost(ctx, elementalMachine, pool)
 	if err != nil {
 		return nil, fmt.Errorf("looking up new available host: %w", err)
 	}
 	return newHostCandidate, nil
 }
 
-func (r *ElementalMachineReconciler) lookUpNewAvailableHost(ctx context.Context, elementalMachine infrastructurev1.ElementalMachine) (*infrastructurev1.ElementalHost, error) {
+func (r *ElementalMachineReconciler) lookUpNewAvailableHost(ctx context.Context, elementalMachine infrastructurev1.ElementalMachine, pool *infrastructurev1.ElementalHostPool) (*infrastructurev1.ElementalHost, error) {
 	logger := log.FromContext(ctx).
 		WithValues(ilog.KeyNamespace, elementalMachine.Namespace).
 		WithValues(ilog.KeyElementalMachine, elementalMachine.Name)
@@ -650,7 +881,11 @@ func (r *ElementalMachineReconciler) lookUpNewAvailableHost(ctx context.Context,
 	// Use the label selector defined in the ElementalMachine, or select any ElementalHost available if no selector has been defined.
 	if elementalMachine.Spec.Selector != nil {
 		if selector, err = metav1.LabelSelectorAsSelector(elementalMachine.Spec.Selector); err != nil {
-			return nil, fmt.Errorf("converting LabelSelector to Selector: %w", err)
+			// An invalid selector can only be fixed by the user.
+			return nil, &TerminalError{
+				Reason: capierrors.InvalidConfigurationMachineError,
+				Err:    fmt.Errorf("converting LabelSelector to Selector: %w", err),
+			}
 		}
 	} else {
 		selector = labels.NewSelector()
@@ -675,23 +910,44 @@ func (r *ElementalMachineReconciler) lookUpNewAvailableHost(ctx context.Context,
 	}
 	selector = selector.Add(*requirement)
 
-	// Query the available ElementalHosts within the same namespace as the ElementalMachine
-	if err := r.Client.List(ctx, elementalHosts, client.InNamespace(elementalMachine.Namespace), &client.ListOptions{LabelSelector: selector}); err != nil {
+	// Query the available ElementalHosts within the ElementalHostPool, if any,
+	// or within the same namespace as the ElementalMachine.
+	if pool != nil {
+		if elementalHosts.Items, err = listHostPoolHosts(ctx, r.Client, *pool, selector); err != nil {
+			return nil, fmt.Errorf("listing available ElementalHosts in ElementalHostPool: %w", err)
+		}
+	} else if err := r.Client.List(ctx, elementalHosts, client.InNamespace(elementalMachine.Namespace), &client.ListOptions{LabelSelector: selector}); err != nil {
 		return nil, fmt.Errorf("listing available ElementalHosts: %w", err)
 	}
 
 	logger.WithCallDepth(ilog.DebugLevel).Info(fmt.Sprintf("Found %d available hosts", len(elementalHosts.Items)))
 
-	// Return the first one available, if any
-	for _, host := range elementalHosts.Items {
-		return &host, nil
+	// Prefer hosts already on the ElementalMachine target OS version (for example warm spares),
+	// otherwise return the first one available, if any, skipping hosts with a terminal failure or pending approval
+	var firstAvailable *infrastructurev1.ElementalHost
+	for i := range elementalHosts.Items {
+		host := &elementalHosts.Items[i]
+		if host.Status.FailureReason != nil || host.IsPendingApproval() {
+			continue
+		}
+		if isOSVersionManagementEqual(host.Spec.OSVersionManagement, elementalMachine.Spec.OSVersionManagement) &&
+			conditions.IsTrue(host, infrastructurev1.OSVersionReady) {
+			logger.WithCallDepth(ilog.DebugLevel).Info("Found available host already on target OS version", ilog.KeyElementalHost, host.Name)
+			return host, nil
+		}
+		if firstAvailable == nil {
+			firstAvailable = host
+		}
+	}
+	if firstAvailable != nil {
+		return firstAvailable, nil
 	}
 
 	// No hosts available for association
 	return nil, nil
 }
 
-func (r *ElementalMachineReconciler) lookUpAlreadyLinkedHost(ctx context.Context, elementalMachine infrastructurev1.ElementalMachine) (*infrastructurev1.ElementalHost, error) {
+func (r *ElementalMachineReconciler) lookUpAlreadyLinkedHost(ctx context.Context, elementalMachine infrastructurev1.ElementalMachine, pool *infrastructurev1.ElementalHostPool) (*infrastructurev1.ElementalHost, error) {
 	logger := log.FromContext(ctx).
 		WithValues(ilog.KeyNamespace, elementalMachine.Namespace).
 		WithValues(ilog.KeyElementalMachine, elementalMachine.Name)
@@ -712,14 +968,22 @@ func (r *ElementalMachineReconciler) lookUpAlreadyLinkedHost(ctx context.Context
 	}
 	selector = selector.Add(*requirement)
 
-	if err := r.Client.List(ctx, elementalHosts, client.InNamespace(elementalMachine.Namespace), &client.ListOptions{LabelSelector: selector}); err != nil {
+	namespace := elementalMachine.Namespace
+	if pool != nil {
+		namespace = pool.Namespace
+	}
+	if err := r.Client.List(ctx, elementalHosts, client.InNamespace(namespace), &client.ListOptions{LabelSelector: selector}); err != nil {
 		return nil, fmt.Errorf("listing previously linked ElementalHosts: %w", err)
 	}
 
 	logger.WithCallDepth(ilog.DebugLevel).Info(fmt.Sprintf("Found %d already linked hosts", len(elementalHosts.Items)))
 
 	// If there is an already asssociated host, return it to finalize association.
+	// Hosts in a shared pool may be linked to an ElementalMachine with the same name in a different namespace.
 	for _, host := range elementalHosts.Items {
+		if host.Spec.MachineRef != nil && host.Spec.MachineRef.Namespace != elementalMachine.Namespace {
+			continue
+		}
 		return &host, nil
 	}
 
@@ -748,22 +1012,11 @@ func (r *ElementalMachineReconciler) reconcileDelete(ctx context.Context, elemen
 			}
 			return ctrl.Result{}, fmt.Errorf("fetching ElementalHost: %w", err)
 		}
-		// Create the patch helper.
-		patchHelper, err := patch.NewHelper(host, r.Client)
-		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("initializing patch helper: %w", err)
-		}
-
 		// Mark this host for reset
-		if host.Labels == nil {
-			host.Labels = map[string]string{}
-		}
-		host.Labels[infrastructurev1.LabelElementalHostNeedsReset] = "true"
-
-		// Patch
-		if err := patchHelper.Patch(ctx, host); err != nil {
-			return ctrl.Result{}, fmt.Errorf("patching ElementalHost: %w", err)
+		if err := r.markHostForReset(ctx, host, fmt.Sprintf("ElementalMachine '%s' was deleted", elementalMachine.Name)); err != nil {
+			return ctrl.Result{}, fmt.Errorf("marking ElementalHost for reset: %w", err)
 		}
+		r.Recorder.Eventf(elementalMachine, corev1.EventTypeNormal, "Disassociated", "Triggered reset of ElementalHost '%s'", host.Name)
 	}
 
 	controllerutil.RemoveFinalizer(elementalMachine, infrastructurev1.FinalizerElementalMachine)
@@ -14,7 +14,9 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -159,6 +161,9 @@ var _ = Describe("ElementalMachine controller association", Label("controller",
 		}, &installedHost)).Should(Succeed())
 		Expect(installedHost.Labels[v1beta1.LabelElementalHostMachineName]).Should(Equal(machine.Name), "machine-name label must be set")
 		Expect(installedHost.Labels[clusterv1.ClusterNameLabel]).Should(Equal(cluster.Name), "cluster name label must be set")
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElements(
+			fmt.Sprintf("Normal Associated Associated to ElementalHost '%s'", installedHost.Name),
+			fmt.Sprintf("Normal Associated Associated to ElementalMachine '%s/%s'", elementalMachine.Namespace, elementalMachine.Name)))
 	})
 	It("should not mark machine as ready until cluster's controlplane is initialized ", func() {
 		// Mark the host as bootstrapped
@@ -205,6 +210,7 @@ var _ = Describe("ElementalMachine controller association", Label("controller",
 			}
 			return *elementalMachine.Spec.ProviderID
 		}).WithTimeout(time.Minute).Should(Equal(wantProviderID), "ElementalMachine's ProviderID should match")
+		Expect(elementalMachine.Status.NodeName).Should(Equal(wantNodeName), "ElementalMachine's NodeName should match")
 
 		// Ensure ElementalMachine is ready
 		Eventually(func() bool {
@@ -215,6 +221,38 @@ var _ = Describe("ElementalMachine controller association", Label("controller",
 			return elementalMachine.Status.Ready
 		}).WithTimeout(time.Minute).Should(BeTrue(), "ElementalMachine should be ready")
 	})
+	It("should sync selected host labels to the downstream node", func() {
+		wantCluster := types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}
+		// Label the host
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&installedHost), &installedHost)).Should(Succeed())
+		installedHost.Labels["example.com/rack"] = "rack-1"
+		installedHost.Labels["other.com/ignored"] = "true"
+		Expect(k8sClient.Update(ctx, &installedHost)).Should(Succeed())
+		// Define the sync policy
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&elementalMachine), &elementalMachine)).Should(Succeed())
+		elementalMachine.Spec.NodeSync = &v1beta1.NodeSyncPolicy{
+			LabelPrefixes: []string{"example.com/"},
+			Taints:        []corev1.Taint{{Key: "example.com/dedicated", Effect: corev1.TaintEffectNoSchedule}},
+		}
+		Expect(k8sClient.Update(ctx, &elementalMachine)).Should(Succeed())
+
+		Eventually(func() map[string]string {
+			return remoteTrackerMock.GetSyncedMetadata(wantCluster).Labels
+		}).WithTimeout(time.Minute).Should(Equal(map[string]string{"example.com/rack": "rack-1"}), "Only selected labels should be synced")
+		Expect(remoteTrackerMock.GetSyncedMetadata(wantCluster).Taints).Should(Equal(elementalMachine.Spec.NodeSync.Taints))
+		Eventually(func() bool {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&elementalMachine), &elementalMachine)).Should(Succeed())
+			return conditions.IsTrue(&elementalMachine, v1beta1.NodeSyncReady)
+		}).WithTimeout(time.Minute).Should(BeTrue(), "NodeSyncReady condition should be true")
+
+		// Remove the label from the host
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&installedHost), &installedHost)).Should(Succeed())
+		delete(installedHost.Labels, "example.com/rack")
+		Expect(k8sClient.Update(ctx, &installedHost)).Should(Succeed())
+		Eventually(func() map[string]string {
+			return remoteTrackerMock.GetSyncedMetadata(wantCluster).Labels
+		}).WithTimeout(time.Minute).Should(BeEmpty(), "Removed labels should not be synced")
+	})
 	It("should trigger host reset upon deletion", func() {
 		// Delete the ElementalMachine
 		Expect(k8sClient.Delete(ctx, &elementalMachine)).Should(Succeed())
@@ -229,6 +267,7 @@ var _ = Describe("ElementalMachine controller association", Label("controller",
 			}
 			return false
 		}).WithTimeout(time.Minute).Should(BeTrue(), "Host needs.reset label should be set")
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement(fmt.Sprintf("Normal Disassociated Triggered reset of ElementalHost '%s'", installedHost.Name)))
 	})
 })
 
@@ -963,13 +1002,20 @@ var _ = Describe("ElementalMachine controller conditions", Label("controller", "
 		Expect(conditions.Get(&elementalMachine, infrastructurev1.ProviderIDReady).Reason).Should(Equal(infrastructurev1.NodeNotFoundReason))
 		Expect(conditions.Get(&elementalMachine, infrastructurev1.ProviderIDReady).Severity).Should(Equal(clusterv1.ConditionSeverityError))
 	})
-	It("ProviderIDReady should be true", func() {
+	It("ProviderIDReady should be true once the renamed node is matched by system UUID", func() {
 		wantProviderID := fmt.Sprintf("elemental://%s/%s", newHost.Namespace, newHost.Name)
 		wantCluster := types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}
-		wantNodeName := newHost.Name
+		wantNodeName := newHost.Name + ".example.com"
+		wantSystemUUID := "4c4c4544-0044-3510-8052-b4c04f4e5931"
 
-		// Expect call on remote tracker to set downstream node's ProviderID
-		remoteTrackerMock.AddCall(wantCluster, utils.RemoteTrackerMockCall{NodeName: wantNodeName, ProviderID: wantProviderID})
+		// Report the system UUID of the host
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&newHost), &newHost)).Should(Succeed())
+		newHostPatch := newHost
+		newHostPatch.Status.SystemUUID = wantSystemUUID
+		patchObject(ctx, k8sClient, &newHost, &newHostPatch)
+
+		// Expect call on remote tracker to set the renamed downstream node's ProviderID
+		remoteTrackerMock.AddCall(wantCluster, utils.RemoteTrackerMockCall{NodeName: wantNodeName, ProviderID: wantProviderID, SystemUUID: wantSystemUUID})
 
 		Eventually(func() v1.ConditionStatus {
 			Expect(k8sClient.Get(ctx, types.NamespacedName{
@@ -998,5 +1044,406 @@ var _ = Describe("ElementalMachine controller conditions", Label("controller", "
 				&elementalMachine)).Should(Succeed())
 			return elementalMachine.Status.Ready
 		}).WithTimeout(time.Minute).Should(BeTrue(), "ElementalMachine should be ready")
+		Expect(elementalMachine.Status.NodeName).Should(Equal(wantNodeName), "ElementalMachine's NodeName should match the renamed node")
+	})
+})
+
+var _ = Describe("ElementalMachine controller terminal failures", Label("controller", "elemental-machine", "failures"), Ordered, func() {
+	ctx := context.Background()
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "elementalmachine-test-failures",
+		},
+	}
+	cluster := clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: namespace.Name,
+		},
+	}
+	machine := clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: namespace.Name,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: cluster.Name},
+		},
+		Spec: clusterv1.MachineSpec{
+			Bootstrap: clusterv1.Bootstrap{
+				DataSecretName: &testBootstrapSecretName,
+			},
+			ClusterName: cluster.Name,
+		},
+	}
+	elementalMachine := v1beta1.ElementalMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace.Name,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: cluster.Name},
+		},
+	}
+	host := v1beta1.ElementalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace.Name,
+			Labels: map[string]string{
+				v1beta1.LabelElementalHostInstalled:    "true",
+				v1beta1.LabelElementalHostBootstrapped: "true",
+			},
+		},
+	}
+
+	// newOwnedElementalMachine returns an ElementalMachine owned by a new CAPI Machine.
+	newOwnedElementalMachine := func(name string) v1beta1.ElementalMachine {
+		ownerMachine := machine
+		ownerMachine.Name = name
+		ownerMachine.Spec.InfrastructureRef = corev1.ObjectReference{
+			Kind:       "ElementalMachine",
+			APIVersion: v1beta1.GroupVersion.Identifier(),
+			Name:       name,
+			Namespace:  namespace.Name,
+		}
+		Expect(k8sClient.Create(ctx, &ownerMachine)).Should(Succeed())
+		newElementalMachine := elementalMachine
+		newElementalMachine.Name = name
+		newElementalMachine.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "cluster.x-k8s.io/v1beta1",
+			Kind:       "Machine",
+			Name:       ownerMachine.Name,
+			UID:        ownerMachine.UID,
+		}}
+		return newElementalMachine
+	}
+
+	// getFailureReason returns the ElementalMachine failure reason, if any.
+	getFailureReason := func(elementalMachine *v1beta1.ElementalMachine) string {
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      elementalMachine.Name,
+			Namespace: elementalMachine.Namespace},
+			elementalMachine)).Should(Succeed())
+		if elementalMachine.Status.FailureReason == nil {
+			return ""
+		}
+		return string(*elementalMachine.Status.FailureReason)
+	}
+
+	BeforeAll(func() {
+		Expect(k8sClient.Create(ctx, &namespace)).Should(Succeed())
+		// Create CAPI Cluster with an initialized control plane
+		cluster.Spec.ControlPlaneRef = &corev1.ObjectReference{}
+		Expect(k8sClient.Create(ctx, &cluster)).Should(Succeed())
+		clusterStatusPatch := cluster
+		clusterStatusPatch.Status = clusterv1.ClusterStatus{
+			InfrastructureReady: true,
+		}
+		conditions.Set(&clusterStatusPatch, &clusterv1.Condition{
+			Type:   clusterv1.ControlPlaneInitializedCondition,
+			Status: v1.ConditionTrue,
+		})
+		patchObject(ctx, k8sClient, &cluster, &clusterStatusPatch)
+		// Any downstream node lookup will not find the node
+		remoteTrackerMock.AddCall(types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace},
+			utils.RemoteTrackerMockCall{NodeName: "not-found"})
+	})
+	AfterAll(func() {
+		Expect(k8sClient.Delete(ctx, &namespace)).Should(Succeed())
+	})
+	It("should fail with InvalidConfiguration if selector is invalid", func() {
+		invalidSelectorMachine := newOwnedElementalMachine("test-invalid-selector")
+		invalidSelectorMachine.Spec.Selector = &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "foo", Operator: "NotAnOperator"}},
+		}
+		Expect(k8sClient.Create(ctx, &invalidSelectorMachine)).Should(Succeed())
+		Eventually(func() string {
+			return getFailureReason(&invalidSelectorMachine)
+		}).WithTimeout(time.Minute).Should(Equal(string(capierrors.InvalidConfigurationMachineError)))
+		Expect(invalidSelectorMachine.Status.FailureMessage).ShouldNot(BeNil())
+		Expect(invalidSelectorMachine.Status.Ready).Should(BeFalse())
+	})
+	It("should not reconcile ElementalMachine with terminal failure", func() {
+		failedMachine := newOwnedElementalMachine("test-not-reconciled")
+		failedMachine.Spec.Selector = &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "foo", Operator: "NotAnOperator"}},
+		}
+		Expect(k8sClient.Create(ctx, &failedMachine)).Should(Succeed())
+		Eventually(func() string {
+			return getFailureReason(&failedMachine)
+		}).WithTimeout(time.Minute).Should(Equal(string(capierrors.InvalidConfigurationMachineError)))
+		// Fixing the selector does not recover the ElementalMachine
+		availableHost := host
+		availableHost.Name = "test-available"
+		availableHost.Labels = map[string]string{v1beta1.LabelElementalHostInstalled: "true", "foo": "bar"}
+		Expect(k8sClient.Create(ctx, &availableHost)).Should(Succeed())
+		failedMachinePatch := failedMachine
+		failedMachinePatch.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"foo": "bar"}}
+		patchObject(ctx, k8sClient, &failedMachine, &failedMachinePatch)
+		Consistently(func() *corev1.ObjectReference {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      failedMachine.Name,
+				Namespace: failedMachine.Namespace},
+				&failedMachine)).Should(Succeed())
+			return failedMachine.Spec.HostRef
+		}).WithTimeout(5*time.Second).Should(BeNil(), "Failed ElementalMachine should not be associated")
+	})
+	It("should not associate ElementalHost with terminal failure", func() {
+		failedHost := host
+		failedHost.Name = "test-failed-available"
+		failedHost.Labels = map[string]string{v1beta1.LabelElementalHostInstalled: "true", "pool": "failed"}
+		Expect(k8sClient.Create(ctx, &failedHost)).Should(Succeed())
+		failedHostPatch := failedHost
+		failedHostPatch.Status.FailureReason = ptr.To(capierrors.UpdateMachineError)
+		failedHostPatch.Status.FailureMessage = ptr.To("test failure")
+		patchObject(ctx, k8sClient, &failedHost, &failedHostPatch)
+
+		waitingMachine := newOwnedElementalMachine("test-waiting")
+		waitingMachine.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "failed"}}
+		Expect(k8sClient.Create(ctx, &waitingMachine)).Should(Succeed())
+		Eventually(func() string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      waitingMachine.Name,
+				Namespace: waitingMachine.Namespace},
+				&waitingMachine)).Should(Succeed())
+			condition := conditions.Get(&waitingMachine, v1beta1.AssociationReady)
+			if condition == nil {
+				return ""
+			}
+			return condition.Reason
+		}).WithTimeout(time.Minute).Should(Equal(v1beta1.MissingAvailableHostsReason))
+		Expect(waitingMachine.Spec.HostRef).Should(BeNil())
+		Expect(waitingMachine.Status.FailureReason).Should(BeNil())
+	})
+	It("should propagate associated ElementalHost terminal failure", func() {
+		failedHost := host
+		failedHost.Name = "test-failed-associated"
+		Expect(k8sClient.Create(ctx, &failedHost)).Should(Succeed())
+		failedHostPatch := failedHost
+		failedHostPatch.Status.FailureReason = ptr.To(capierrors.UpdateMachineError)
+		failedHostPatch.Status.FailureMessage = ptr.To("test failure")
+		patchObject(ctx, k8sClient, &failedHost, &failedHostPatch)
+
+		associatedMachine := newOwnedElementalMachine("test-failed-host")
+		associatedMachine.Spec.HostRef = &corev1.ObjectReference{
+			Kind:       "ElementalHost",
+			APIVersion: v1beta1.GroupVersion.Identifier(),
+			Name:       failedHost.Name,
+			Namespace:  failedHost.Namespace,
+		}
+		Expect(k8sClient.Create(ctx, &associatedMachine)).Should(Succeed())
+		Eventually(func() string {
+			return getFailureReason(&associatedMachine)
+		}).WithTimeout(time.Minute).Should(Equal(string(capierrors.UpdateMachineError)))
+		Expect(*associatedMachine.Status.FailureMessage).Should(ContainSubstring("test failure"))
+		hostReadyCondition := conditions.Get(&associatedMachine, v1beta1.HostReady)
+		Expect(hostReadyCondition).ShouldNot(BeNil())
+		Expect(hostReadyCondition.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(hostReadyCondition.Reason).Should(Equal(v1beta1.HostFailedReason))
+		Expect(hostReadyCondition.Severity).Should(Equal(v1beta1.HostFailedReasonSeverity))
+
+		// Deleting the failed ElementalMachine still triggers the host reset
+		Expect(k8sClient.Delete(ctx, &associatedMachine)).Should(Succeed())
+		Eventually(func() string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      failedHost.Name,
+				Namespace: failedHost.Namespace},
+				&failedHost)).Should(Succeed())
+			return failedHost.Labels[v1beta1.LabelElementalHostNeedsReset]
+		}).WithTimeout(time.Minute).Should(Equal("true"), "Failed ElementalHost should be reset")
+	})
+	It("should not fail if downstream node is not found within grace period", func() {
+		bootstrappedHost := host
+		bootstrappedHost.Name = "test-node-joining"
+		Expect(k8sClient.Create(ctx, &bootstrappedHost)).Should(Succeed())
+
+		joiningMachine := newOwnedElementalMachine(bootstrappedHost.Name)
+		joiningMachine.Spec.HostRef = &corev1.ObjectReference{
+			Kind:       "ElementalHost",
+			APIVersion: v1beta1.GroupVersion.Identifier(),
+			Name:       bootstrappedHost.Name,
+			Namespace:  bootstrappedHost.Namespace,
+		}
+		Expect(k8sClient.Create(ctx, &joiningMachine)).Should(Succeed())
+		Eventually(func() string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      joiningMachine.Name,
+				Namespace: joiningMachine.Namespace},
+				&joiningMachine)).Should(Succeed())
+			condition := conditions.Get(&joiningMachine, v1beta1.ProviderIDReady)
+			if condition == nil {
+				return ""
+			}
+			return condition.Reason
+		}).WithTimeout(time.Minute).Should(Equal(v1beta1.NodeNotFoundReason))
+		Consistently(func() string {
+			return getFailureReason(&joiningMachine)
+		}).WithTimeout(5*time.Second).Should(BeEmpty(), "ElementalMachine should not fail within grace period")
+	})
+	It("should fail with JoinClusterTimeoutError if downstream node is not found after grace period", func() {
+		bootstrappedHost := host
+		bootstrappedHost.Name = "test-node-not-found"
+		Expect(k8sClient.Create(ctx, &bootstrappedHost)).Should(Succeed())
+
+		// Create the ElementalMachine without owner, so that it is not reconciled past the owner check.
+		notFoundMachine := newOwnedElementalMachine(bootstrappedHost.Name)
+		ownerReferences := notFoundMachine.OwnerReferences
+		notFoundMachine.OwnerReferences = nil
+		notFoundMachine.Spec.HostRef = &corev1.ObjectReference{
+			Kind:       "ElementalHost",
+			APIVersion: v1beta1.GroupVersion.Identifier(),
+			Name:       bootstrappedHost.Name,
+			Namespace:  bootstrappedHost.Namespace,
+		}
+		Expect(k8sClient.Create(ctx, &notFoundMachine)).Should(Succeed())
+		// Mark the node as not found since long ago
+		notFoundMachinePatch := notFoundMachine
+		notFoundMachinePatch.Status.Conditions = clusterv1.Conditions{{
+			Type:               v1beta1.ProviderIDReady,
+			Status:             corev1.ConditionFalse,
+			Severity:           clusterv1.ConditionSeverityError,
+			Reason:             v1beta1.NodeNotFoundReason,
+			Message:            fmt.Sprintf("Downstream cluster node '%s' is not found.", bootstrappedHost.Name),
+			LastTransitionTime: metav1.NewTime(time.Now().Add(-NodeNotFoundGracePeriod * 2)),
+		}}
+		patchObject(ctx, k8sClient, &notFoundMachine, &notFoundMachinePatch)
+		// Set the Machine owner
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      notFoundMachine.Name,
+			Namespace: notFoundMachine.Namespace},
+			&notFoundMachine)).Should(Succeed())
+		notFoundMachinePatch = notFoundMachine
+		notFoundMachinePatch.OwnerReferences = ownerReferences
+		patchObject(ctx, k8sClient, &notFoundMachine, &notFoundMachinePatch)
+
+		Eventually(func() string {
+			return getFailureReason(&notFoundMachine)
+		}).WithTimeout(time.Minute).Should(Equal(capierrors.JoinClusterTimeoutMachineError))
+		Expect(*notFoundMachine.Status.FailureMessage).Should(ContainSubstring(utils.ErrRemoteNodeNotFound.Error()))
+		Expect(notFoundMachine.Status.Ready).Should(BeFalse())
+	})
+})
+
+var _ = Describe("ElementalMachine controller bootstrap timeout", Label("controller", "elemental-machine", "timeout"), Ordered, func() {
+	ctx := context.Background()
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "elementalmachine-test-bootstrap-timeout",
+		},
+	}
+	cluster := clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: namespace.Name,
+		},
+	}
+	machine := clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace.Name,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: cluster.Name},
+		},
+		Spec: clusterv1.MachineSpec{
+			Bootstrap: clusterv1.Bootstrap{
+				DataSecretName: &testBootstrapSecretName,
+			},
+			ClusterName: cluster.Name,
+		},
+	}
+	elementalMachine := v1beta1.ElementalMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace.Name,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: cluster.Name},
+		},
+	}
+	host := v1beta1.ElementalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace.Name,
+		},
+	}
+
+	// createTimingOutMachine creates an ElementalMachine with a short bootstrap timeout,
+	// and an installed ElementalHost available for association that will never be bootstrapped.
+	createTimingOutMachine := func(name string, policy v1beta1.TimeoutPolicy) (v1beta1.ElementalMachine, v1beta1.ElementalHost) {
+		ownerMachine := machine
+		ownerMachine.Name = name
+		ownerMachine.Spec.InfrastructureRef = corev1.ObjectReference{
+			Kind:       "ElementalMachine",
+			APIVersion: v1beta1.GroupVersion.Identifier(),
+			Name:       name,
+			Namespace:  namespace.Name,
+		}
+		Expect(k8sClient.Create(ctx, &ownerMachine)).Should(Succeed())
+		availableHost := host
+		availableHost.Name = name
+		availableHost.Labels = map[string]string{v1beta1.LabelElementalHostInstalled: "true", "pool": name}
+		Expect(k8sClient.Create(ctx, &availableHost)).Should(Succeed())
+		timingOutMachine := elementalMachine
+		timingOutMachine.Name = name
+		timingOutMachine.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "cluster.x-k8s.io/v1beta1",
+			Kind:       "Machine",
+			Name:       ownerMachine.Name,
+			UID:        ownerMachine.UID,
+		}}
+		timingOutMachine.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"pool": name}}
+		timingOutMachine.Spec.BootstrapTimeout = &v1beta1.Timeout{
+			Duration: metav1.Duration{Duration: time.Second},
+			Policy:   policy,
+		}
+		Expect(k8sClient.Create(ctx, &timingOutMachine)).Should(Succeed())
+		return timingOutMachine, availableHost
+	}
+
+	BeforeAll(func() {
+		Expect(k8sClient.Create(ctx, &namespace)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &cluster)).Should(Succeed())
+		clusterStatusPatch := cluster
+		clusterStatusPatch.Status = clusterv1.ClusterStatus{
+			InfrastructureReady: true,
+		}
+		patchObject(ctx, k8sClient, &cluster, &clusterStatusPatch)
+	})
+	AfterAll(func() {
+		Expect(k8sClient.Delete(ctx, &namespace)).Should(Succeed())
+	})
+	It("should reset the host and remove the association after bootstrap timeout", func() {
+		timingOutMachine, timingOutHost := createTimingOutMachine("test-reset", v1beta1.TimeoutPolicyReset)
+		Eventually(func() string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      timingOutHost.Name,
+				Namespace: timingOutHost.Namespace},
+				&timingOutHost)).Should(Succeed())
+			return timingOutHost.Labels[v1beta1.LabelElementalHostNeedsReset]
+		}).WithTimeout(time.Minute).Should(Equal("true"), "ElementalHost should be reset")
+		Eventually(func() *corev1.ObjectReference {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      timingOutMachine.Name,
+				Namespace: timingOutMachine.Namespace},
+				&timingOutMachine)).Should(Succeed())
+			return timingOutMachine.Spec.HostRef
+		}).WithTimeout(time.Minute).Should(BeNil(), "HostRef should have been removed")
+		Expect(timingOutMachine.Status.FailureReason).Should(BeNil())
+		// The host is reset, so it must not be associated again
+		Consistently(func() *corev1.ObjectReference {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      timingOutMachine.Name,
+				Namespace: timingOutMachine.Namespace},
+				&timingOutMachine)).Should(Succeed())
+			return timingOutMachine.Spec.HostRef
+		}).WithTimeout(5*time.Second).Should(BeNil(), "Reset host should not be associated again")
+	})
+	It("should fail with JoinClusterTimeoutError after bootstrap timeout", func() {
+		timingOutMachine, timingOutHost := createTimingOutMachine("test-fail", v1beta1.TimeoutPolicyFail)
+		Eventually(func() *capierrors.MachineStatusError {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      timingOutMachine.Name,
+				Namespace: timingOutMachine.Namespace},
+				&timingOutMachine)).Should(Succeed())
+			return timingOutMachine.Status.FailureReason
+		}).WithTimeout(time.Minute).Should(Equal(ptr.To(capierrors.MachineStatusError(capierrors.JoinClusterTimeoutMachineError))))
+		hostReadyCondition := conditions.Get(&timingOutMachine, v1beta1.HostReady)
+		Expect(hostReadyCondition).ShouldNot(BeNil())
+		Expect(hostReadyCondition.Reason).Should(Equal(v1beta1.HostBootstrapTimeoutReason))
+		Expect(hostReadyCondition.Severity).Should(Equal(v1beta1.HostBootstrapTimeoutReasonSeverity))
+		Expect(timingOutMachine.Spec.HostRef).ShouldNot(BeNil(), "Failed ElementalMachine should still be associated")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      timingOutHost.Name,
+			Namespace: timingOutHost.Namespace},
+			&timingOutHost)).Should(Succeed())
+		Expect(timingOutHost.Labels).ShouldNot(HaveKey(v1beta1.LabelElementalHostNeedsReset))
 	})
 })
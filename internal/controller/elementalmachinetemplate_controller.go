@@ -18,14 +18,29 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/controller/utils"
+	ilog "github.com/rancher-sandbox/cluster-api-provider-elemental/internal/log"
 )
 
 // ElementalMachineTemplateReconciler reconciles a ElementalMachineTemplate object.
@@ -37,15 +52,89 @@ type ElementalMachineTemplateReconciler struct {
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalmachinetemplates,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalmachinetemplates/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalmachinetemplates/finalizers,verbs=update
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhosts,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.15.0/pkg/reconcile
-func (r *ElementalMachineTemplateReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+//
+// The ElementalMachineTemplate status is computed from the matching ElementalHosts,
+// so that the cluster-autoscaler can scale from zero.
+// See: https://github.com/kubernetes-sigs/cluster-api/blob/main/docs/proposals/20210310-opt-in-autoscaling-from-zero.md
+func (r *ElementalMachineTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, rerr error) {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, req.Namespace).
+		WithValues(ilog.KeyElementalMachineTemplate, req.Name)
+	logger.Info("Reconciling ElementalMachineTemplate")
+
+	// Fetch the ElementalMachineTemplate
+	template := &infrastructurev1.ElementalMachineTemplate{}
+	if err := r.Client.Get(ctx, req.NamespacedName, template); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("fetching ElementalMachineTemplate: %w", err)
+	}
+
+	// Return early if the object is paused
+	paused, err := utils.IsPaused(ctx, r.Client, template)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("checking if ElementalMachineTemplate is paused: %w", err)
+	}
+	if paused {
+		logger.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	// Create the patch helper.
+	patchHelper, err := patch.NewHelper(template, r.Client)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("initializing patch helper: %w", err)
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, template); err != nil {
+			rerr = errors.Join(rerr, fmt.Errorf("patching ElementalMachineTemplate: %w", err))
+		}
+	}()
 
+	// List the ElementalHosts matching the template selector
+	selector := labels.Everything()
+	if template.Spec.Template.Spec.Selector != nil {
+		if selector, err = metav1.LabelSelectorAsSelector(template.Spec.Template.Spec.Selector); err != nil {
+			logger.Error(err, "Invalid selector, status can not be computed")
+			template.Status = infrastructurev1.ElementalMachineTemplateStatus{}
+			return ctrl.Result{}, nil
+		}
+	}
+	// Hosts are selected from the referenced ElementalHostPool, if any.
+	hosts := []infrastructurev1.ElementalHost{}
+	if poolRef := template.Spec.Template.Spec.HostPoolRef; poolRef != nil {
+		pool, err := getHostPool(ctx, r.Client, template.Namespace, *poolRef)
+		if errors.Is(err, ErrHostPoolNotAllowed) {
+			logger.Error(err, "Can not claim hosts from ElementalHostPool, status can not be computed")
+			template.Status = infrastructurev1.ElementalMachineTemplateStatus{}
+			return ctrl.Result{}, nil
+		}
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("getting ElementalHostPool: %w", err)
+		}
+		if hosts, err = listHostPoolHosts(ctx, r.Client, *pool, selector); err != nil {
+			return ctrl.Result{}, fmt.Errorf("listing matching ElementalHosts in ElementalHostPool: %w", err)
+		}
+	} else {
+		hostList := &infrastructurev1.ElementalHostList{}
+		if err := r.Client.List(ctx, hostList, client.InNamespace(template.Namespace), &client.ListOptions{LabelSelector: selector}); err != nil {
+			return ctrl.Result{}, fmt.Errorf("listing matching ElementalHosts: %w", err)
+		}
+		hosts = hostList.Items
+	}
+
+	template.Status = computeTemplateStatus(template.Spec.Template.Spec, hosts)
+	logger.V(ilog.DebugLevel).Info("ElementalMachineTemplate status computed",
+		"matchingHosts", template.Status.MatchingHosts,
+		"availableHosts", template.Status.AvailableHosts)
 	return ctrl.Result{}, nil
 }
 
@@ -53,8 +142,140 @@ func (r *ElementalMachineTemplateReconciler) Reconcile(ctx context.Context, _ ct
 func (r *ElementalMachineTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if err := ctrl.NewControllerManagedBy(mgr).
 		For(&infrastructurev1.ElementalMachineTemplate{}).
+		Watches(
+			&infrastructurev1.ElementalHost{},
+			handler.EnqueueRequestsFromMapFunc(r.ElementalHostToElementalMachineTemplates),
+			builder.WithPredicates(templateStatusChangedPredicate()),
+		).
 		Complete(r); err != nil {
 		return fmt.Errorf("initializing ElementalMachineTemplateReconciler builder: %w", err)
 	}
 	return nil
 }
+
+// ElementalHostToElementalMachineTemplates enqueues all the ElementalMachineTemplates that may select the ElementalHost,
+// those in the ElementalHost namespace, and those claiming hosts from an ElementalHostPool in the ElementalHost namespace.
+func (r *ElementalMachineTemplateReconciler) ElementalHostToElementalMachineTemplates(ctx context.Context, obj client.Object) []ctrl.Request {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, obj.GetNamespace()).
+		WithValues(ilog.KeyElementalHost, obj.GetName())
+
+	templates := &infrastructurev1.ElementalMachineTemplateList{}
+	if err := r.Client.List(ctx, templates); err != nil {
+		logger.Error(err, "Could not list ElementalMachineTemplates")
+		return []ctrl.Request{}
+	}
+	requests := []ctrl.Request{}
+	for _, template := range templates.Items {
+		poolRef := template.Spec.Template.Spec.HostPoolRef
+		if template.Namespace == obj.GetNamespace() || (poolRef != nil && poolRef.Namespace == obj.GetNamespace()) {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&template)})
+		}
+	}
+	return requests
+}
+
+// templateStatusChangedPredicate filters the ElementalHost updates that may change the ElementalMachineTemplate status,
+// so that the frequent heartbeat and condition updates do not trigger any reconciliation.
+// Creations and deletions are always passed through.
+func templateStatusChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldHost, ok := e.ObjectOld.(*infrastructurev1.ElementalHost)
+			if !ok {
+				return false
+			}
+			newHost, ok := e.ObjectNew.(*infrastructurev1.ElementalHost)
+			if !ok {
+				return false
+			}
+			return !maps.Equal(oldHost.Labels, newHost.Labels) ||
+				oldHost.IsPendingApproval() != newHost.IsPendingApproval() ||
+				!equality.Semantic.DeepEqual(oldHost.Status.Capacity, newHost.Status.Capacity) ||
+				!equality.Semantic.DeepEqual(oldHost.Status.FailureReason, newHost.Status.FailureReason)
+		},
+	}
+}
+
+// computeTemplateStatus computes the ElementalMachineTemplate status from the matching ElementalHosts.
+// The capacity is the lowest one reported by any host, so that the cluster-autoscaler never overestimates a new node.
+// Hosts that did not report any capacity yet are not taken into account.
+func computeTemplateStatus(spec infrastructurev1.ElementalMachineSpec, hosts []infrastructurev1.ElementalHost) infrastructurev1.ElementalMachineTemplateStatus {
+	status := infrastructurev1.ElementalMachineTemplateStatus{
+		MatchingHosts: int32(len(hosts)),
+	}
+	var capacity corev1.ResourceList
+	var nodeLabels map[string]string
+	for _, host := range hosts {
+		if isHostAvailable(host) {
+			status.AvailableHosts++
+		}
+		if host.Status.Capacity != nil {
+			capacity = minCapacity(capacity, host.Status.Capacity)
+		}
+		hostLabels := map[string]string{}
+		if spec.NodeSync != nil {
+			hostLabels = filterByPrefix(host.Labels, spec.NodeSync.LabelPrefixes)
+		}
+		nodeLabels = commonLabels(nodeLabels, hostLabels)
+	}
+	if capacity != nil {
+		capacity[corev1.ResourcePods] = *resource.NewQuantity(DefaultNodeMaxPods, resource.DecimalSI)
+		status.Capacity = capacity
+	}
+	if len(nodeLabels) > 0 {
+		status.NodeLabels = nodeLabels
+	}
+	return status
+}
+
+// isHostAvailable returns true if the ElementalHost can be associated to a new ElementalMachine.
+func isHostAvailable(host infrastructurev1.ElementalHost) bool {
+	if host.Labels[infrastructurev1.LabelElementalHostInstalled] != "true" {
+		return false
+	}
+	if _, found := host.Labels[infrastructurev1.LabelElementalHostNeedsReset]; found {
+		return false
+	}
+	if _, found := host.Labels[infrastructurev1.LabelElementalHostMachineName]; found {
+		return false
+	}
+	return host.Status.FailureReason == nil && !host.IsPendingApproval()
+}
+
+// minCapacity returns the lowest quantity of each resource included in both lists.
+// If current is nil, a copy of reported is returned.
+func minCapacity(current corev1.ResourceList, reported corev1.ResourceList) corev1.ResourceList {
+	if current == nil {
+		return reported.DeepCopy()
+	}
+	for name, quantity := range current {
+		reportedQuantity, found := reported[name]
+		if !found {
+			delete(current, name)
+			continue
+		}
+		if reportedQuantity.Cmp(quantity) < 0 {
+			current[name] = reportedQuantity.DeepCopy()
+		}
+	}
+	return current
+}
+
+// commonLabels returns the labels with the same value in both maps.
+// If current is nil, a copy of reported is returned.
+func commonLabels(current map[string]string, reported map[string]string) map[string]string {
+	if current == nil {
+		common := make(map[string]string, len(reported))
+		for key, value := range reported {
+			common[key] = value
+		}
+		return common
+	}
+	for key, value := range current {
+		if reportedValue, found := reported[key]; !found || reportedValue != value {
+			delete(current, key)
+		}
+	}
+	return current
+}
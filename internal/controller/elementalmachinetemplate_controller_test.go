@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+)
+
+var _ = Describe("ElementalMachineTemplate controller", Label("controller", "elemental-machine-template"), Ordered, func() {
+	ctx := context.Background()
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "elementalmachinetemplate-test",
+		},
+	}
+	template := v1beta1.ElementalMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-template",
+			Namespace: namespace.Name,
+		},
+		Spec: v1beta1.ElementalMachineTemplateSpec{
+			Template: v1beta1.InfraMachineTemplateResource{
+				Spec: v1beta1.ElementalMachineSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"example.com/class": "small"}},
+					NodeSync: &v1beta1.NodeSyncPolicy{LabelPrefixes: []string{"example.com/"}},
+				},
+			},
+		},
+	}
+	availableHost := v1beta1.ElementalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-available",
+			Namespace: namespace.Name,
+			Labels: map[string]string{
+				"example.com/class":                 "small",
+				"example.com/rack":                  "rack-1",
+				v1beta1.LabelElementalHostInstalled: "true",
+			},
+		},
+	}
+	associatedHost := v1beta1.ElementalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-associated",
+			Namespace: namespace.Name,
+			Labels: map[string]string{
+				"example.com/class":                   "small",
+				"example.com/rack":                    "rack-2",
+				v1beta1.LabelElementalHostInstalled:   "true",
+				v1beta1.LabelElementalHostMachineName: "test-machine",
+			},
+		},
+	}
+	otherHost := v1beta1.ElementalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-other",
+			Namespace: namespace.Name,
+			Labels: map[string]string{
+				"example.com/class":                 "large",
+				v1beta1.LabelElementalHostInstalled: "true",
+			},
+		},
+	}
+	BeforeAll(func() {
+		Expect(k8sClient.Create(ctx, &namespace)).Should(Succeed())
+		for _, host := range []*v1beta1.ElementalHost{&availableHost, &associatedHost, &otherHost} {
+			Expect(k8sClient.Create(ctx, host)).Should(Succeed())
+		}
+		setHostCapacity(ctx, &availableHost, "8", "16Gi")
+		setHostCapacity(ctx, &associatedHost, "4", "32Gi")
+		setHostCapacity(ctx, &otherHost, "2", "4Gi")
+		Expect(k8sClient.Create(ctx, &template)).Should(Succeed())
+	})
+	AfterAll(func() {
+		Expect(k8sClient.Delete(ctx, &namespace)).Should(Succeed())
+	})
+	It("should compute status from matching hosts", func() {
+		Eventually(func() int32 {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&template), &template)).Should(Succeed())
+			return template.Status.MatchingHosts
+		}).WithTimeout(time.Minute).Should(Equal(int32(2)))
+		Expect(template.Status.AvailableHosts).Should(Equal(int32(1)))
+		Expect(template.Status.Capacity.Cpu().Equal(resource.MustParse("4"))).Should(BeTrue(), "Lowest cpu capacity should be used")
+		Expect(template.Status.Capacity.Memory().Equal(resource.MustParse("16Gi"))).Should(BeTrue(), "Lowest memory capacity should be used")
+		Expect(template.Status.Capacity.Pods().Value()).Should(Equal(int64(DefaultNodeMaxPods)))
+		Expect(template.Status.NodeLabels).Should(Equal(map[string]string{"example.com/class": "small"}), "Only common synced labels should be included")
+	})
+	It("should update status when hosts change", func() {
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&associatedHost), &associatedHost)).Should(Succeed())
+		delete(associatedHost.Labels, v1beta1.LabelElementalHostMachineName)
+		Expect(k8sClient.Update(ctx, &associatedHost)).Should(Succeed())
+		Eventually(func() int32 {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&template), &template)).Should(Succeed())
+			return template.Status.AvailableHosts
+		}).WithTimeout(time.Minute).Should(Equal(int32(2)))
+	})
+	It("should only pass through ElementalHost updates changing the status", func() {
+		predicate := templateStatusChangedPredicate()
+		oldHost := &v1beta1.ElementalHost{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1beta1.LabelElementalHostInstalled: "true"},
+			},
+		}
+		Expect(predicate.Create(event.CreateEvent{Object: oldHost})).Should(BeTrue())
+		Expect(predicate.Delete(event.DeleteEvent{Object: oldHost})).Should(BeTrue())
+
+		heartbeat := oldHost.DeepCopy()
+		heartbeat.Status.LastSeen = &metav1.Time{Time: time.Now()}
+		Expect(predicate.Update(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: heartbeat})).Should(BeFalse(), "Heartbeats should be filtered")
+
+		labeled := oldHost.DeepCopy()
+		labeled.Labels[v1beta1.LabelElementalHostMachineName] = "test"
+		Expect(predicate.Update(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: labeled})).Should(BeTrue())
+
+		withCapacity := oldHost.DeepCopy()
+		withCapacity.Status.Capacity = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}
+		Expect(predicate.Update(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: withCapacity})).Should(BeTrue())
+
+		failed := oldHost.DeepCopy()
+		failed.Status.FailureReason = ptr.To(capierrors.CreateMachineError)
+		Expect(predicate.Update(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: failed})).Should(BeTrue())
+	})
+})
+
+func setHostCapacity(ctx context.Context, host *v1beta1.ElementalHost, cpu string, memory string) {
+	hostPatch := host.DeepCopy()
+	hostPatch.Status.Capacity = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}
+	patchObject(ctx, k8sClient, host, hostPatch)
+}
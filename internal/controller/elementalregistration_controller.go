@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -28,18 +29,26 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/cluster-api/api/v1beta1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	"github.com/golang-jwt/jwt/v5"
 	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/cert"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/controller/utils"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/identity"
 	ilog "github.com/rancher-sandbox/cluster-api-provider-elemental/internal/log"
 )
@@ -55,12 +64,28 @@ type ElementalRegistrationReconciler struct {
 	Scheme        *runtime.Scheme
 	APIUrl        *url.URL
 	DefaultCACert string
+	// Recorder is used to emit events on signing key and token generation.
+	Recorder record.EventRecorder
 }
 
 // SetupWithManager sets up the controller with the Manager.
-func (r *ElementalRegistrationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+func (r *ElementalRegistrationReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &infrastructurev1.ElementalHost{}, utils.ElementalHostRegistrationIndexKey, utils.IndexElementalHostByRegistration); err != nil {
+		return fmt.Errorf("indexing ElementalHosts by ElementalRegistration: %w", err)
+	}
 	if err := ctrl.NewControllerManagedBy(mgr).
 		For(&infrastructurev1.ElementalRegistration{}).
+		Watches(
+			&infrastructurev1.ElementalHost{},
+			handler.EnqueueRequestForOwner(mgr.GetScheme(), mgr.GetRESTMapper(), &infrastructurev1.ElementalRegistration{}, handler.OnlyControllerOwner()),
+			// The host counters only change on creation, deletion, when the installed label is set,
+			// or when the trusted CAs change during a CA rotation.
+			builder.WithPredicates(predicate.Or(predicate.LabelChangedPredicate{}, trustedCAsChangedPredicate())),
+		).
+		Watches(
+			&infrastructurev1.ElementalMachine{},
+			handler.EnqueueRequestsFromMapFunc(r.ElementalMachineToElementalRegistration),
+		).
 		Complete(r); err != nil {
 		return fmt.Errorf("initializing ElementalRegistrationReconciler builder: %w", err)
 	}
@@ -70,7 +95,10 @@ func (r *ElementalRegistrationReconciler) SetupWithManager(mgr ctrl.Manager) err
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalregistrations,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalregistrations/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalregistrations/finalizers,verbs=update
-//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalhosts,verbs=get;list;watch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalmachines,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -92,6 +120,16 @@ func (r *ElementalRegistrationReconciler) Reconcile(ctx context.Context, req ctr
 		return ctrl.Result{}, fmt.Errorf("fetching ElementalRegistration: %w", err)
 	}
 
+	// Return early if the object or its Cluster is paused
+	paused, err := utils.IsPaused(ctx, r.Client, registration)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("checking if ElementalRegistration is paused: %w", err)
+	}
+	if paused {
+		logger.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
 	// Create the patch helper.
 	patchHelper, err := patch.NewHelper(registration, r.Client)
 	if err != nil {
@@ -117,12 +155,21 @@ func (r *ElementalRegistrationReconciler) Reconcile(ctx context.Context, req ctr
 		registration.Spec.Config.Elemental.Registration.CACert = r.DefaultCACert
 	}
 
+	// Rotate the trusted CA, if requested.
+	if err := r.reconcileCARotation(ctx, registration); err != nil {
+		return ctrl.Result{}, fmt.Errorf("reconciling CA rotation: %w", err)
+	}
+
 	// Generate new token signing key if secret does not exists yet.
 	if registration.Spec.PrivateKeyRef == nil {
 		logger.Info("Generating new signing key")
 		if err := r.generateNewIdentity(ctx, registration); err != nil {
 			return ctrl.Result{}, fmt.Errorf("generating new identity: %w", err)
 		}
+		r.Recorder.Event(registration, corev1.EventTypeNormal, "SigningKeyGenerated", "Generated new registration token signing key")
+	} else if err := r.setSigningKeyOwner(ctx, registration); err != nil {
+		// Ensure the signing key is owned by the registration, so that it is carried over by 'clusterctl move'.
+		return ctrl.Result{}, fmt.Errorf("setting signing key owner: %w", err)
 	}
 
 	// Generate new token if does not exist yet.
@@ -131,6 +178,12 @@ func (r *ElementalRegistrationReconciler) Reconcile(ctx context.Context, req ctr
 		if err := r.setNewToken(ctx, registration); err != nil {
 			return ctrl.Result{}, fmt.Errorf("refreshing registration token: %w", err)
 		}
+		r.Recorder.Event(registration, corev1.EventTypeNormal, "TokenGenerated", "Generated new registration token")
+	}
+
+	// Report the registered ElementalHosts
+	if err := r.setHostsStatus(ctx, registration); err != nil {
+		return ctrl.Result{}, fmt.Errorf("reporting registered ElementalHosts: %w", err)
 	}
 
 	// Set Ready condition
@@ -143,6 +196,186 @@ func (r *ElementalRegistrationReconciler) Reconcile(ctx context.Context, req ctr
 	return ctrl.Result{}, nil
 }
 
+// ElementalMachineToElementalRegistration enqueues the ElementalRegistration of the ElementalHost associated to the ElementalMachine,
+// so that orphaned ElementalHosts are reported when the ElementalMachine is deleted.
+func (r *ElementalRegistrationReconciler) ElementalMachineToElementalRegistration(ctx context.Context, obj client.Object) []ctrl.Request {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, obj.GetNamespace()).
+		WithValues(ilog.KeyElementalMachine, obj.GetName())
+
+	machine, ok := obj.(*infrastructurev1.ElementalMachine)
+	if !ok {
+		logger.Error(ErrEnqueueing, fmt.Sprintf("Expected a ElementalMachine object, but got %T", obj))
+		return []ctrl.Request{}
+	}
+	if machine.Spec.HostRef == nil {
+		return []ctrl.Request{}
+	}
+	host := &infrastructurev1.ElementalHost{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: machine.Spec.HostRef.Namespace, Name: machine.Spec.HostRef.Name}, host); err != nil {
+		if !apierrors.IsNotFound(err) {
+			logger.Error(err, "Could not fetch ElementalHost", ilog.KeyElementalHost, machine.Spec.HostRef.Name)
+		}
+		return []ctrl.Request{}
+	}
+	owner := metav1.GetControllerOf(host)
+	if owner == nil || owner.Kind != "ElementalRegistration" {
+		return []ctrl.Request{}
+	}
+	return []ctrl.Request{{NamespacedName: client.ObjectKey{Namespace: host.Namespace, Name: owner.Name}}}
+}
+
+// setHostsStatus updates the number of registered and installed ElementalHosts,
+// and the list of ElementalHosts associated to ElementalMachines that do not exist anymore.
+func (r *ElementalRegistrationReconciler) setHostsStatus(ctx context.Context, registration *infrastructurev1.ElementalRegistration) error {
+	hosts := &infrastructurev1.ElementalHostList{}
+	if err := r.Client.List(ctx, hosts,
+		client.InNamespace(registration.Namespace),
+		client.MatchingFields{utils.ElementalHostRegistrationIndexKey: registration.Name}); err != nil {
+		return fmt.Errorf("listing ElementalHosts: %w", err)
+	}
+	var installedHosts int32
+	orphanedHosts := []string{}
+	for _, host := range hosts.Items {
+		if value, found := host.Labels[infrastructurev1.LabelElementalHostInstalled]; found && value == "true" {
+			installedHosts++
+		}
+		if host.Spec.MachineRef == nil {
+			continue
+		}
+		machine := &infrastructurev1.ElementalMachine{}
+		err := r.Client.Get(ctx, client.ObjectKey{Namespace: host.Spec.MachineRef.Namespace, Name: host.Spec.MachineRef.Name}, machine)
+		if apierrors.IsNotFound(err) {
+			orphanedHosts = append(orphanedHosts, host.Name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("fetching ElementalMachine '%s': %w", host.Spec.MachineRef.Name, err)
+		}
+	}
+	registration.Status.RegisteredHosts = int32(len(hosts.Items))
+	registration.Status.InstalledHosts = installedHosts
+	if registration.Status.CARotation != nil {
+		registration.Status.CARotation.PendingHosts = countPendingCAHosts(hosts.Items, registration.Status.CARotation.NextCAFingerprints)
+	}
+	registration.Status.OrphanedHosts = nil
+	if len(orphanedHosts) > 0 {
+		registration.Status.OrphanedHosts = orphanedHosts
+	}
+	return nil
+}
+
+// reconcileCARotation appends the next CA to the registration CA bundle, so that the ElementalHosts trust both the current and the next CA.
+// Once the rotation is complete, the bundle is replaced by the next CA only.
+// Completing the rotation is refused as long as any installed ElementalHost does not trust the next CA yet.
+func (r *ElementalRegistrationReconciler) reconcileCARotation(ctx context.Context, registration *infrastructurev1.ElementalRegistration) error {
+	rotation := registration.Spec.CARotation
+	if rotation == nil {
+		registration.Status.CARotation = nil
+		conditions.Delete(registration, infrastructurev1.CARotationReady)
+		return nil
+	}
+	nextFingerprints, err := cert.Fingerprints([]byte(rotation.NextCACert))
+	if err != nil {
+		r.rejectCARotation(registration, fmt.Sprintf("Could not parse the next CA certificate: %s", err.Error()))
+		return nil
+	}
+	// The agent also accepts a CA certificate file path, that can not be rotated by the controller.
+	caCert := registration.Spec.Config.Elemental.Registration.CACert
+	var currentFingerprints []string
+	if len(caCert) > 0 {
+		if currentFingerprints, err = cert.Fingerprints([]byte(caCert)); err != nil {
+			r.rejectCARotation(registration, fmt.Sprintf("The registration caCert is not a PEM encoded certificate: %s", err.Error()))
+			return nil
+		}
+	}
+	if registration.Status.CARotation == nil {
+		registration.Status.CARotation = &infrastructurev1.CARotationStatus{}
+	}
+	registration.Status.CARotation.NextCAFingerprints = nextFingerprints
+
+	conditions.Set(registration, &clusterv1.Condition{
+		Type:     infrastructurev1.CARotationReady,
+		Status:   corev1.ConditionTrue,
+		Severity: clusterv1.ConditionSeverityInfo,
+	})
+
+	if rotation.Complete {
+		if caCert != rotation.NextCACert {
+			hosts := &infrastructurev1.ElementalHostList{}
+			if err := r.Client.List(ctx, hosts,
+				client.InNamespace(registration.Namespace),
+				client.MatchingFields{utils.ElementalHostRegistrationIndexKey: registration.Name}); err != nil {
+				return fmt.Errorf("listing ElementalHosts: %w", err)
+			}
+			if pendingHosts := countPendingCAHosts(hosts.Items, nextFingerprints); pendingHosts > 0 {
+				message := fmt.Sprintf("%d ElementalHosts do not trust the next CA yet, not completing the CA rotation", pendingHosts)
+				r.Recorder.Event(registration, corev1.EventTypeWarning, "CARotationBlocked", message)
+				conditions.Set(registration, &clusterv1.Condition{
+					Type:     infrastructurev1.CARotationReady,
+					Status:   corev1.ConditionFalse,
+					Severity: infrastructurev1.CARotationPendingHostsReasonSeverity,
+					Reason:   infrastructurev1.CARotationPendingHostsReason,
+					Message:  message,
+				})
+				return nil
+			}
+			r.Recorder.Event(registration, corev1.EventTypeNormal, "CARotationCompleted", "Removed the previous CA from the trusted CA bundle")
+			registration.Spec.Config.Elemental.Registration.CACert = rotation.NextCACert
+		}
+		return nil
+	}
+	if !cert.ContainsAll(currentFingerprints, nextFingerprints) {
+		r.Recorder.Event(registration, corev1.EventTypeNormal, "CARotationStarted", "Added the next CA to the trusted CA bundle")
+		registration.Spec.Config.Elemental.Registration.CACert = cert.Bundle(caCert, rotation.NextCACert)
+	}
+	return nil
+}
+
+// rejectCARotation reports a CA rotation that can not be executed, leaving the registration CA bundle untouched.
+func (r *ElementalRegistrationReconciler) rejectCARotation(registration *infrastructurev1.ElementalRegistration, message string) {
+	r.Recorder.Event(registration, corev1.EventTypeWarning, "CARotationRejected", message)
+	conditions.Set(registration, &clusterv1.Condition{
+		Type:     infrastructurev1.CARotationReady,
+		Status:   corev1.ConditionFalse,
+		Severity: infrastructurev1.CARotationInvalidCertificateReasonSeverity,
+		Reason:   infrastructurev1.CARotationInvalidCertificateReason,
+		Message:  message,
+	})
+}
+
+// countPendingCAHosts returns the number of installed ElementalHosts that do not trust all the given CA fingerprints.
+// Hosts that are not installed yet do not report their trusted CAs, they are counted once installed.
+func countPendingCAHosts(hosts []infrastructurev1.ElementalHost, fingerprints []string) int32 {
+	var pendingHosts int32
+	for _, host := range hosts {
+		if value, found := host.Labels[infrastructurev1.LabelElementalHostInstalled]; !found || value != "true" {
+			continue
+		}
+		if !cert.ContainsAll(host.Status.TrustedCAs, fingerprints) {
+			pendingHosts++
+		}
+	}
+	return pendingHosts
+}
+
+// trustedCAsChangedPredicate filters the ElementalHost updates that change the trusted CAs.
+func trustedCAsChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldHost, ok := e.ObjectOld.(*infrastructurev1.ElementalHost)
+			if !ok {
+				return false
+			}
+			newHost, ok := e.ObjectNew.(*infrastructurev1.ElementalHost)
+			if !ok {
+				return false
+			}
+			return !slices.Equal(oldHost.Status.TrustedCAs, newHost.Status.TrustedCAs)
+		},
+	}
+}
+
 func (r *ElementalRegistrationReconciler) setURI(registration *infrastructurev1.ElementalRegistration) error {
 	if r.APIUrl == nil {
 		return ErrAPIEndpointNil
@@ -196,25 +429,28 @@ func (r *ElementalRegistrationReconciler) generateNewIdentity(ctx context.Contex
 	return nil
 }
 
-func (r *ElementalRegistrationReconciler) setNewToken(ctx context.Context, registration *infrastructurev1.ElementalRegistration) error {
+func (r *ElementalRegistrationReconciler) setSigningKeyOwner(ctx context.Context, registration *infrastructurev1.ElementalRegistration) error {
 	secret := &corev1.Secret{}
 	if err := r.Client.Get(ctx, types.NamespacedName{
-		Name:      registration.Name,
+		Name:      registration.Spec.PrivateKeyRef.Name,
 		Namespace: registration.Namespace,
 	}, secret); err != nil {
 		return fmt.Errorf("fetching signing key secret: %w", err)
 	}
-
-	privKeyPem, found := secret.Data["privKey"]
-	if !found {
-		return ErrNoPrivateKey
+	patchHelper, err := patch.NewHelper(secret, r.Client)
+	if err != nil {
+		return fmt.Errorf("initializing patch helper: %w", err)
 	}
-
-	id := identity.Ed25519Identity{}
-	if err := id.Unmarshal([]byte(privKeyPem)); err != nil {
-		return fmt.Errorf("parsing private key PEM: %w", err)
+	if err := controllerutil.SetOwnerReference(registration, secret, r.Scheme); err != nil {
+		return fmt.Errorf("setting owner reference: %w", err)
 	}
+	if err := patchHelper.Patch(ctx, secret); err != nil {
+		return fmt.Errorf("patching signing key secret: %w", err)
+	}
+	return nil
+}
 
+func (r *ElementalRegistrationReconciler) setNewToken(ctx context.Context, registration *infrastructurev1.ElementalRegistration) error {
 	now := time.Now()
 	claims := jwt.RegisteredClaims{
 		IssuedAt:  jwt.NewNumericDate(now),
@@ -226,11 +462,38 @@ func (r *ElementalRegistrationReconciler) setNewToken(ctx context.Context, regis
 	if registration.Spec.Config.Elemental.Registration.TokenDuration != 0 {
 		claims.ExpiresAt = jwt.NewNumericDate(now.Add(registration.Spec.Config.Elemental.Registration.TokenDuration))
 	}
-	token, err := id.Sign(claims)
+	token, err := signRegistrationToken(ctx, r.Client, *registration, claims)
 	if err != nil {
-		return fmt.Errorf("signing JWT claims: %w", err)
+		return fmt.Errorf("signing registration token: %w", err)
 	}
 
 	registration.Spec.Config.Elemental.Registration.Token = token
 	return nil
 }
+
+// signRegistrationToken signs the JWT claims with the ElementalRegistration signing key.
+func signRegistrationToken(ctx context.Context, reader client.Reader, registration infrastructurev1.ElementalRegistration, claims jwt.Claims) (string, error) {
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, types.NamespacedName{
+		Name:      registration.Name,
+		Namespace: registration.Namespace,
+	}, secret); err != nil {
+		return "", fmt.Errorf("fetching signing key secret: %w", err)
+	}
+
+	privKeyPem, found := secret.Data["privKey"]
+	if !found {
+		return "", ErrNoPrivateKey
+	}
+
+	id := identity.Ed25519Identity{}
+	if err := id.Unmarshal([]byte(privKeyPem)); err != nil {
+		return "", fmt.Errorf("parsing private key PEM: %w", err)
+	}
+
+	token, err := id.Sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("signing JWT claims: %w", err)
+	}
+	return token, nil
+}
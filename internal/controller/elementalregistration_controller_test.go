@@ -23,6 +23,7 @@ import (
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/client"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/agent/config"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/api"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/cert"
 	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/identity"
 )
 
@@ -99,6 +100,32 @@ var _ = Describe("ElementalRegistration controller", Label("controller", "elemen
 			return updatedRegistration.Spec.Config.Elemental.Registration.URI
 		}).WithTimeout(time.Minute).Should(Equal(registrationWithURI.Spec.Config.Elemental.Registration.URI))
 	})
+	It("should own the signing key secret", func() {
+		secret := &corev1.Secret{}
+		Eventually(func() []metav1.OwnerReference {
+			if err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      registration.Name,
+				Namespace: registration.Namespace},
+				secret); err != nil {
+				return nil
+			}
+			return secret.OwnerReferences
+		}).WithTimeout(time.Minute).Should(ContainElement(HaveField("Name", registration.Name)), "signing key secret should be owned by the registration")
+	})
+	It("should not reconcile a paused registration", func() {
+		pausedRegistration := registration
+		pausedRegistration.Name = registration.Name + "-paused"
+		pausedRegistration.Annotations = map[string]string{clusterv1.PausedAnnotation: "true"}
+		Expect(k8sClient.Create(ctx, &pausedRegistration)).Should(Succeed())
+		updatedRegistration := &v1beta1.ElementalRegistration{}
+		Consistently(func() string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      pausedRegistration.Name,
+				Namespace: pausedRegistration.Namespace},
+				updatedRegistration)).Should(Succeed())
+			return updatedRegistration.Spec.Config.Elemental.Registration.URI
+		}).WithTimeout(5*time.Second).Should(BeEmpty(), "paused registration should not be reconciled")
+	})
 	It("should create non-expirable registration token by default", func() {
 		// Initial Registration has empty token.
 		// This is the normal state.
@@ -315,10 +342,15 @@ wcHkvD3kEU33TR9VnkHUwgC9jDyDa62sef84S5MUAiAJfWf5G5PqtN+AE4XJgg2K
 						Token:  registrationToken,
 					},
 					Agent: v1beta1.Agent{
-						WorkDir:           "/var/lib/elemental/agent",
-						Debug:             true,
-						OSPlugin:          "/usr/lib/elemental/plugins/elemental.so",
-						Reconciliation:    10000000000,
+						WorkDir:        "/var/lib/elemental/agent",
+						Debug:          true,
+						OSPlugin:       "/usr/lib/elemental/plugins/elemental.so",
+						Reconciliation: 10000000000,
+						Backoff: v1beta1.Backoff{
+							Max:    300000000000,
+							Factor: 2,
+							Jitter: ptr.To(20),
+						},
 						InsecureAllowHTTP: true,
 					},
 				},
@@ -349,3 +381,321 @@ wcHkvD3kEU33TR9VnkHUwgC9jDyDa62sef84S5MUAiAJfWf5G5PqtN+AE4XJgg2K
 		Expect(err).To(HaveOccurred())
 	})
 })
+
+var _ = Describe("ElementalRegistration quotas", Label("api", "elemental-registration"), Ordered, func() {
+	ctx := context.Background()
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "registration-quotas-test",
+		},
+	}
+	registration := v1beta1.ElementalRegistration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-quotas",
+			Namespace: namespace.Name,
+		},
+		Spec: v1beta1.ElementalRegistrationSpec{
+			MaxHosts:                  ptr.To(int32(3)),
+			MaxRegistrationsPerMinute: 2,
+			Config: v1beta1.Config{
+				Elemental: v1beta1.Elemental{
+					Registration: v1beta1.Registration{
+						URI: fmt.Sprintf("%s%s%s/namespaces/%s/registrations/%s", serverURL, api.Prefix, api.PrefixV1, namespace.Name, "test-quotas"),
+					},
+					Agent: v1beta1.Agent{
+						WorkDir:           "/var/lib/elemental/agent",
+						InsecureAllowHTTP: true,
+					},
+				},
+			},
+		},
+	}
+	var eClient client.Client
+	var pubKey []byte
+	BeforeAll(func() {
+		fs, fsCleanup, err := vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		Expect(k8sClient.Create(ctx, &namespace)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &registration)).Should(Succeed())
+		updatedRegistration := &v1beta1.ElementalRegistration{}
+		Eventually(func() string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      registration.Name,
+				Namespace: registration.Namespace},
+				updatedRegistration)).Should(Succeed())
+			return updatedRegistration.Spec.Config.Elemental.Registration.Token
+		}).WithTimeout(time.Minute).ShouldNot(BeEmpty(), "missing registration token")
+		eClient = client.NewClient("v0.0.0-test")
+		conf := config.Config{
+			Registration: updatedRegistration.Spec.Config.Elemental.Registration,
+			Agent:        updatedRegistration.Spec.Config.Elemental.Agent,
+		}
+		idManager := identity.NewManager(fs, registration.Spec.Config.Elemental.Agent.WorkDir)
+		id, err := idManager.LoadSigningKeyOrCreateNew()
+		Expect(err).ToNot(HaveOccurred())
+		pubKey, err = id.MarshalPublic()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(eClient.Init(fs, id, conf)).Should(Succeed())
+	})
+	AfterAll(func() {
+		Expect(k8sClient.Delete(ctx, &namespace)).Should(Succeed())
+	})
+	It("should limit the registrations rate", func() {
+		Expect(eClient.CreateHost(api.HostCreateRequest{Name: "test-quotas-1", PubKey: string(pubKey)})).Should(Succeed())
+		Expect(eClient.CreateHost(api.HostCreateRequest{Name: "test-quotas-2", PubKey: string(pubKey)})).Should(Succeed())
+		Expect(eClient.CreateHost(api.HostCreateRequest{Name: "test-quotas-3", PubKey: string(pubKey)})).
+			Should(MatchError(ContainSubstring("'429'")), "Registrations rate should be exceeded")
+	})
+	It("should count registered and installed hosts", func() {
+		host := &v1beta1.ElementalHost{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      "test-quotas-1",
+			Namespace: namespace.Name},
+			host)).Should(Succeed())
+		host.Labels[v1beta1.LabelElementalHostInstalled] = "true"
+		Expect(k8sClient.Update(ctx, host)).Should(Succeed())
+		updatedRegistration := &v1beta1.ElementalRegistration{}
+		Eventually(func() v1beta1.ElementalRegistrationStatus {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      registration.Name,
+				Namespace: registration.Namespace},
+				updatedRegistration)).Should(Succeed())
+			return updatedRegistration.Status
+		}).WithTimeout(time.Minute).Should(And(
+			HaveField("RegisteredHosts", int32(2)),
+			HaveField("InstalledHosts", int32(1)),
+		))
+	})
+	It("should report orphaned hosts", func() {
+		host := &v1beta1.ElementalHost{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      "test-quotas-2",
+			Namespace: namespace.Name},
+			host)).Should(Succeed())
+		patchedHost := host.DeepCopy()
+		patchedHost.Spec.MachineRef = &corev1.ObjectReference{
+			APIVersion: v1beta1.GroupVersion.String(),
+			Kind:       "ElementalMachine",
+			Name:       "does-not-exist",
+			Namespace:  namespace.Name,
+		}
+		// Change a label to trigger the ElementalRegistration reconciliation
+		patchedHost.Labels = map[string]string{"test-orphaned": "true"}
+		patchObject(ctx, k8sClient, host, patchedHost)
+		updatedRegistration := &v1beta1.ElementalRegistration{}
+		Eventually(func() []string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      registration.Name,
+				Namespace: registration.Namespace},
+				updatedRegistration)).Should(Succeed())
+			return updatedRegistration.Status.OrphanedHosts
+		}).WithTimeout(time.Minute).Should(Equal([]string{"test-quotas-2"}))
+	})
+	It("should limit the number of hosts", func() {
+		updatedRegistration := &v1beta1.ElementalRegistration{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      registration.Name,
+			Namespace: registration.Namespace},
+			updatedRegistration)).Should(Succeed())
+		updatedRegistration.Spec.MaxRegistrationsPerMinute = 0
+		Expect(k8sClient.Update(ctx, updatedRegistration)).Should(Succeed())
+		Expect(eClient.CreateHost(api.HostCreateRequest{Name: "test-quotas-2", PubKey: string(pubKey)})).
+			Should(MatchError(ContainSubstring("'409'")), "Already existing hosts should be rejected")
+		Expect(eClient.CreateHost(api.HostCreateRequest{Name: "test-quotas-3", PubKey: string(pubKey)})).Should(Succeed())
+		// Wait for the registered hosts to be counted
+		Eventually(func() int32 {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      registration.Name,
+				Namespace: registration.Namespace},
+				updatedRegistration)).Should(Succeed())
+			return updatedRegistration.Status.RegisteredHosts
+		}).WithTimeout(time.Minute).Should(Equal(int32(3)))
+		Expect(eClient.CreateHost(api.HostCreateRequest{Name: "test-quotas-4", PubKey: string(pubKey)})).
+			Should(MatchError(ContainSubstring("'403'")), "Maximum number of hosts should be reached")
+	})
+})
+
+var _ = Describe("ElementalRegistration CA rotation", Label("api", "elemental-registration"), Ordered, func() {
+	ctx := context.Background()
+	// Static test certificate authorities, they are not used to serve any certificate.
+	currentCA := `-----BEGIN CERTIFICATE-----
+MIIBlzCCAT2gAwIBAgIUfQg20l7HlU2KVzRyVIon8kbTPN4wCgYIKoZIzj0EAwIw
+IDEeMBwGA1UEAwwVZWxlbWVudGFsLXRlc3Qtb2xkLWNhMCAXDTI2MTAxODIwMjc0
+NFoYDzIxMjYwOTI0MjAyNzQ0WjAgMR4wHAYDVQQDDBVlbGVtZW50YWwtdGVzdC1v
+bGQtY2EwWTATBgcqhkjOPQIBBggqhkjOPQMBBwNCAARhhPOiy9QhGvbDaCWxldqb
+3K3N3uDBWDZU7G0yeynyB/DzFfCBC9oDg/iH1NHzi9Se8v2kHlhwNxD/Z9cjZcAO
+o1MwUTAdBgNVHQ4EFgQUF4piDa5Fkjwaf2NnsM4zgo0nigAwHwYDVR0jBBgwFoAU
+F4piDa5Fkjwaf2NnsM4zgo0nigAwDwYDVR0TAQH/BAUwAwEB/zAKBggqhkjOPQQD
+AgNIADBFAiEA4/2UktDyrKDYu3DdGjII4X2VvhX6DdQ4MlfiLC3JmAkCIHzw9MvL
+XdoCQ996KiOtMJGvAYYJI813EeDEiOmG2JMw
+-----END CERTIFICATE-----
+`
+	nextCA := `-----BEGIN CERTIFICATE-----
+MIIBlzCCAT2gAwIBAgIUX5Spk7qEc1mlCUubHjJEmoyOypUwCgYIKoZIzj0EAwIw
+IDEeMBwGA1UEAwwVZWxlbWVudGFsLXRlc3QtbmV3LWNhMCAXDTI2MTAxODIwMjc0
+NFoYDzIxMjYwOTI0MjAyNzQ0WjAgMR4wHAYDVQQDDBVlbGVtZW50YWwtdGVzdC1u
+ZXctY2EwWTATBgcqhkjOPQIBBggqhkjOPQMBBwNCAAQRiYGrWAqPgqHzfRSbWvt0
+sCy0FSh5Acgf6SDRYjZteVQcgo11vpXE0fVaSTU21jEHkVLB6dLxi2643uU3YXsi
+o1MwUTAdBgNVHQ4EFgQUNhD2nSU+7Vtp02w6yHfC9GAuWuswHwYDVR0jBBgwFoAU
+NhD2nSU+7Vtp02w6yHfC9GAuWuswDwYDVR0TAQH/BAUwAwEB/zAKBggqhkjOPQQD
+AgNIADBFAiAZJuHxkfwDrki5B6szjyXc3+LMQKWLfQFGM3M1UO3kGgIhAKeC6yea
+k8Bdi1v4jPCJqtAw86R25RoYW6QA/lzBanAi
+-----END CERTIFICATE-----
+`
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "registration-ca-rotation-test",
+		},
+	}
+	registration := v1beta1.ElementalRegistration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ca-rotation",
+			Namespace: namespace.Name,
+		},
+		Spec: v1beta1.ElementalRegistrationSpec{
+			Config: v1beta1.Config{
+				Elemental: v1beta1.Elemental{
+					Registration: v1beta1.Registration{
+						URI:    fmt.Sprintf("%s%s%s/namespaces/%s/registrations/%s", serverURL, api.Prefix, api.PrefixV1, namespace.Name, "test-ca-rotation"),
+						CACert: currentCA,
+					},
+					Agent: v1beta1.Agent{
+						WorkDir:           "/var/lib/elemental/agent",
+						InsecureAllowHTTP: true,
+					},
+				},
+			},
+		},
+	}
+	hostName := "test-ca-rotation-host"
+	var currentFingerprints []string
+	var nextFingerprints []string
+	var eClient client.Client
+	getRegistration := func() *v1beta1.ElementalRegistration {
+		updatedRegistration := &v1beta1.ElementalRegistration{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      registration.Name,
+			Namespace: registration.Namespace},
+			updatedRegistration)).Should(Succeed())
+		return updatedRegistration
+	}
+	BeforeAll(func() {
+		var err error
+		currentFingerprints, err = cert.Fingerprints([]byte(currentCA))
+		Expect(err).ToNot(HaveOccurred())
+		nextFingerprints, err = cert.Fingerprints([]byte(nextCA))
+		Expect(err).ToNot(HaveOccurred())
+		fs, fsCleanup, err := vfst.NewTestFS(map[string]interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(fsCleanup)
+		Expect(k8sClient.Create(ctx, &namespace)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &registration)).Should(Succeed())
+		Eventually(func() string {
+			return getRegistration().Spec.Config.Elemental.Registration.Token
+		}).WithTimeout(time.Minute).ShouldNot(BeEmpty(), "missing registration token")
+		updatedRegistration := getRegistration()
+		eClient = client.NewClient("v0.0.0-test")
+		conf := config.Config{
+			Registration: updatedRegistration.Spec.Config.Elemental.Registration,
+			Agent:        updatedRegistration.Spec.Config.Elemental.Agent,
+		}
+		idManager := identity.NewManager(fs, registration.Spec.Config.Elemental.Agent.WorkDir)
+		id, err := idManager.LoadSigningKeyOrCreateNew()
+		Expect(err).ToNot(HaveOccurred())
+		pubKey, err := id.MarshalPublic()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(eClient.Init(fs, id, conf)).Should(Succeed())
+		Expect(eClient.CreateHost(api.HostCreateRequest{Name: hostName, PubKey: string(pubKey)})).Should(Succeed())
+		_, err = eClient.PatchHost(api.HostPatchRequest{TrustedCAs: currentFingerprints}, hostName)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterAll(func() {
+		Expect(k8sClient.Delete(ctx, &namespace)).Should(Succeed())
+	})
+	It("should not report any rotation by default", func() {
+		Consistently(func() *v1beta1.CARotationStatus {
+			return getRegistration().Status.CARotation
+		}).WithTimeout(2 * time.Second).Should(BeNil())
+	})
+	It("should add the next CA to the trusted CA bundle", func() {
+		updatedRegistration := getRegistration()
+		updatedRegistration.Spec.CARotation = &v1beta1.CARotation{NextCACert: nextCA}
+		Expect(k8sClient.Update(ctx, updatedRegistration)).Should(Succeed())
+		Eventually(func() string {
+			return getRegistration().Spec.Config.Elemental.Registration.CACert
+		}).WithTimeout(time.Minute).Should(Equal(cert.Bundle(currentCA, nextCA)))
+		bundleFingerprints, err := cert.Fingerprints([]byte(getRegistration().Spec.Config.Elemental.Registration.CACert))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bundleFingerprints).Should(Equal(append(currentFingerprints, nextFingerprints...)), "Both the current and next CA must be trusted")
+		Eventually(func() *v1beta1.CARotationStatus {
+			return getRegistration().Status.CARotation
+		}).WithTimeout(time.Minute).Should(Equal(&v1beta1.CARotationStatus{
+			NextCAFingerprints: nextFingerprints,
+			PendingHosts:       0,
+		}), "The host is not installed yet")
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement("Normal CARotationStarted Added the next CA to the trusted CA bundle"))
+	})
+	It("should deliver the CA bundle to registered hosts", func() {
+		response, err := eClient.GetHostRegistration(hostName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Config.Elemental.Registration.CACert).Should(Equal(cert.Bundle(currentCA, nextCA)))
+	})
+	It("should count the installed hosts not trusting the next CA", func() {
+		_, err := eClient.PatchHost(api.HostPatchRequest{Installed: ptr.To(true)}, hostName)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(func() int32 {
+			return getRegistration().Status.CARotation.PendingHosts
+		}).WithTimeout(time.Minute).Should(Equal(int32(1)))
+	})
+	It("should refuse to complete the rotation while hosts are pending", func() {
+		updatedRegistration := getRegistration()
+		updatedRegistration.Spec.CARotation.Complete = true
+		Expect(k8sClient.Update(ctx, updatedRegistration)).Should(Succeed())
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement("Warning CARotationBlocked 1 ElementalHosts do not trust the next CA yet, not completing the CA rotation"))
+		Eventually(func() *clusterv1.Condition {
+			return conditions.Get(getRegistration(), v1beta1.CARotationReady)
+		}).WithTimeout(time.Minute).Should(And(
+			HaveField("Status", corev1.ConditionFalse),
+			HaveField("Reason", v1beta1.CARotationPendingHostsReason),
+		))
+		Expect(getRegistration().Spec.Config.Elemental.Registration.CACert).Should(Equal(cert.Bundle(currentCA, nextCA)), "The previous CA must still be trusted")
+	})
+	It("should remove the previous CA once no host is pending", func() {
+		_, err := eClient.PatchHost(api.HostPatchRequest{TrustedCAs: append(currentFingerprints, nextFingerprints...)}, hostName)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(func() string {
+			return getRegistration().Spec.Config.Elemental.Registration.CACert
+		}).WithTimeout(time.Minute).Should(Equal(nextCA))
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement("Normal CARotationCompleted Removed the previous CA from the trusted CA bundle"))
+		Expect(getRegistration().Status.CARotation.PendingHosts).Should(Equal(int32(0)))
+		Expect(conditions.IsTrue(getRegistration(), v1beta1.CARotationReady)).Should(BeTrue())
+	})
+	It("should clear the rotation status once the rotation is removed", func() {
+		updatedRegistration := getRegistration()
+		updatedRegistration.Spec.CARotation = nil
+		Expect(k8sClient.Update(ctx, updatedRegistration)).Should(Succeed())
+		Eventually(func() *v1beta1.CARotationStatus {
+			return getRegistration().Status.CARotation
+		}).WithTimeout(time.Minute).Should(BeNil())
+		Expect(getRegistration().Spec.Config.Elemental.Registration.CACert).Should(Equal(nextCA))
+		Expect(conditions.Has(getRegistration(), v1beta1.CARotationReady)).Should(BeFalse())
+	})
+	It("should reject the rotation if the CA certificate is a file path", func() {
+		caCertPath := "/etc/elemental/ca.pem"
+		updatedRegistration := getRegistration()
+		updatedRegistration.Spec.Config.Elemental.Registration.CACert = caCertPath
+		updatedRegistration.Spec.CARotation = &v1beta1.CARotation{NextCACert: nextCA}
+		Expect(k8sClient.Update(ctx, updatedRegistration)).Should(Succeed())
+		Eventually(recordedEvents).WithTimeout(time.Minute).Should(ContainElement(HavePrefix("Warning CARotationRejected The registration caCert is not a PEM encoded certificate")))
+		Eventually(func() *clusterv1.Condition {
+			return conditions.Get(getRegistration(), v1beta1.CARotationReady)
+		}).WithTimeout(time.Minute).Should(And(
+			HaveField("Status", corev1.ConditionFalse),
+			HaveField("Reason", v1beta1.CARotationInvalidCertificateReason),
+		))
+		Expect(getRegistration().Spec.Config.Elemental.Registration.CACert).Should(Equal(caCertPath), "The CA certificate path must not be rotated")
+		Expect(conditions.IsTrue(getRegistration(), clusterv1.ReadyCondition)).Should(BeTrue(), "The registration must still be reconciled")
+		Expect(getRegistration().Status.RegisteredHosts).Should(Equal(int32(1)))
+	})
+})
@@ -0,0 +1,323 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/internal/controller/utils"
+	ilog "github.com/rancher-sandbox/cluster-api-provider-elemental/internal/log"
+)
+
+// DefaultRemediationTimeout is the time to wait after each remediation step, if not configured otherwise.
+const DefaultRemediationTimeout = 10 * time.Minute
+
+// ElementalRemediationReconciler reconciles a ElementalRemediation object.
+type ElementalRemediationReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ElementalRemediationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrastructurev1.ElementalRemediation{}).
+		Complete(r); err != nil {
+		return fmt.Errorf("initializing ElementalRemediationReconciler builder: %w", err)
+	}
+	return nil
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalremediations,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalremediations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=elementalremediationtemplates,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;watch;delete
+
+// Reconcile handles the remediation of unhealthy Machines, as requested by the CAPI MachineHealthCheck controller.
+// Remediation escalates in steps: the ElementalHost is first rebooted (up to the configured retry limit),
+// then reset, and finally marked as failed, deleting the Machine so that it can be replaced.
+// Each step waits for the configured timeout before escalating.
+// If the reset replaced the ElementalHost, a new remediation cycle is started for the new host.
+// If the Machine recovers in the meantime, the MachineHealthCheck controller deletes the ElementalRemediation.
+func (r *ElementalRemediationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, rerr error) {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, req.Namespace).
+		WithValues(ilog.KeyElementalRemediation, req.Name)
+	logger.Info("Reconciling ElementalRemediation")
+
+	// Fetch the ElementalRemediation
+	remediation := &infrastructurev1.ElementalRemediation{}
+	if err := r.Client.Get(ctx, req.NamespacedName, remediation); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("fetching ElementalRemediation: %w", err)
+	}
+
+	// Return early if the object or its Cluster is paused
+	paused, err := utils.IsPaused(ctx, r.Client, remediation)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("checking if ElementalRemediation is paused: %w", err)
+	}
+	if paused {
+		logger.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	// Nothing to do if the remediation is going away or already failed
+	if !remediation.GetDeletionTimestamp().IsZero() || remediation.Status.Phase == infrastructurev1.PhaseRemediationFailed {
+		return ctrl.Result{}, nil
+	}
+
+	// Create the patch helper.
+	patchHelper, err := patch.NewHelper(remediation, r.Client)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("initializing patch helper: %w", err)
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, remediation); err != nil {
+			rerr = errors.Join(rerr, fmt.Errorf("patching ElementalRemediation: %w", err))
+		}
+	}()
+
+	// Fetch the Machine to remediate
+	machine, err := util.GetOwnerMachine(ctx, r.Client, remediation.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("getting Machine owner: %w", err)
+	}
+	if machine == nil {
+		logger.Info("ElementalRemediation resource has no Machine owner")
+		return ctrl.Result{}, nil
+	}
+	logger = logger.WithValues(ilog.KeyMachine, machine.Name)
+
+	// Fetch the ElementalHost associated to the Machine
+	host, err := r.getElementalHost(ctx, machine)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("fetching ElementalHost: %w", err)
+	}
+	if host == nil {
+		// A successful reset deletes the ElementalHost, give the Machine a chance to recover with a new one.
+		if remediation.Status.Phase == infrastructurev1.PhaseRemediationResetting {
+			return r.waitOrEscalate(ctx, remediation, machine, nil), nil
+		}
+		logger.Info("No ElementalHost associated to the Machine")
+		return ctrl.Result{}, r.failRemediation(ctx, remediation, machine, nil)
+	}
+	logger = logger.WithValues(ilog.KeyElementalHost, host.Name)
+	// A successful reset replaces the ElementalHost associated to the Machine.
+	// The new host must never be blamed for the previous one: a new remediation cycle is started,
+	// giving the new host the timeout to become healthy before taking any step.
+	if hostRef := remediation.Status.HostRef; hostRef != nil && hostRef.UID != host.UID {
+		logger.Info("ElementalHost was replaced, starting a new remediation cycle", "previousElementalHost", hostRef.Name)
+		remediation.Status.Phase = ""
+		remediation.Status.RetryCount = 0
+		remediation.Status.LastRemediated = &metav1.Time{Time: time.Now()}
+	}
+	remediation.Status.HostRef = &corev1.ObjectReference{
+		APIVersion: host.APIVersion,
+		Kind:       host.Kind,
+		Namespace:  host.Namespace,
+		Name:       host.Name,
+		UID:        host.UID,
+	}
+
+	// First remediation step
+	if remediation.Status.Phase == "" {
+		if remediation.Status.LastRemediated != nil {
+			if remaining := remediationTimeout(remediation) - time.Since(remediation.Status.LastRemediated.Time); remaining > 0 {
+				logger.Info("Waiting for the replaced ElementalHost to become healthy")
+				return ctrl.Result{RequeueAfter: remaining}, nil
+			}
+		}
+		if remediation.Spec.Strategy.RetryLimit > 0 {
+			logger.Info("Rebooting ElementalHost")
+			if err := r.reboot(ctx, remediation, host); err != nil {
+				return ctrl.Result{}, fmt.Errorf("rebooting ElementalHost: %w", err)
+			}
+		} else {
+			logger.Info("Resetting ElementalHost")
+			if err := r.reset(ctx, remediation, host); err != nil {
+				return ctrl.Result{}, fmt.Errorf("resetting ElementalHost: %w", err)
+			}
+		}
+		return ctrl.Result{RequeueAfter: remediationTimeout(remediation)}, nil
+	}
+
+	return r.waitOrEscalate(ctx, remediation, machine, host), nil
+}
+
+// waitOrEscalate waits for the remediation timeout to expire, then escalates to the next remediation step.
+// Escalation errors are logged and retried, since the remediation status is patched regardless.
+func (r *ElementalRemediationReconciler) waitOrEscalate(ctx context.Context, remediation *infrastructurev1.ElementalRemediation, machine *clusterv1.Machine, host *infrastructurev1.ElementalHost) ctrl.Result {
+	logger := log.FromContext(ctx).
+		WithValues(ilog.KeyNamespace, remediation.Namespace).
+		WithValues(ilog.KeyElementalRemediation, remediation.Name)
+
+	timeout := remediationTimeout(remediation)
+	if remediation.Status.LastRemediated != nil {
+		if elapsed := time.Since(remediation.Status.LastRemediated.Time); elapsed < timeout {
+			logger.Info("Waiting for remediation step to take effect", "phase", remediation.Status.Phase)
+			return ctrl.Result{RequeueAfter: timeout - elapsed}
+		}
+	}
+
+	var err error
+	switch {
+	case host == nil:
+		logger.Info("ElementalHost was not replaced after reset")
+		err = r.failRemediation(ctx, remediation, machine, nil)
+	case remediation.Status.Phase == infrastructurev1.PhaseRemediationRebooting && remediation.Status.RetryCount < remediation.Spec.Strategy.RetryLimit:
+		logger.Info("Rebooting ElementalHost again", "retryCount", remediation.Status.RetryCount)
+		err = r.reboot(ctx, remediation, host)
+	case remediation.Status.Phase == infrastructurev1.PhaseRemediationRebooting:
+		logger.Info("Rebooting did not remediate, resetting ElementalHost")
+		err = r.reset(ctx, remediation, host)
+	default:
+		logger.Info("Resetting did not remediate, marking ElementalHost as failed")
+		err = r.failRemediation(ctx, remediation, machine, host)
+	}
+	if err != nil {
+		logger.Error(err, "Escalating remediation")
+		return ctrl.Result{RequeueAfter: DefaultRequeuePeriod}
+	}
+	if remediation.Status.Phase == infrastructurev1.PhaseRemediationFailed {
+		return ctrl.Result{}
+	}
+	return ctrl.Result{RequeueAfter: timeout}
+}
+
+func remediationTimeout(remediation *infrastructurev1.ElementalRemediation) time.Duration {
+	if remediation.Spec.Strategy.Timeout != nil {
+		return remediation.Spec.Strategy.Timeout.Duration
+	}
+	return DefaultRemediationTimeout
+}
+
+// reboot requests a reboot operation on the ElementalHost.
+// The Node is not drained, since the Machine is already unhealthy.
+func (r *ElementalRemediationReconciler) reboot(ctx context.Context, remediation *infrastructurev1.ElementalRemediation, host *infrastructurev1.ElementalHost) error {
+	patchHelper, err := patch.NewHelper(host, r.Client)
+	if err != nil {
+		return fmt.Errorf("initializing patch helper: %w", err)
+	}
+	host.Spec.Operation = &infrastructurev1.HostOperation{
+		ID:   fmt.Sprintf("%s-%d", remediation.UID, remediation.Status.RetryCount+1),
+		Type: infrastructurev1.HostOperationReboot,
+	}
+	if err := patchHelper.Patch(ctx, host); err != nil {
+		return fmt.Errorf("patching ElementalHost: %w", err)
+	}
+	remediation.Status.Phase = infrastructurev1.PhaseRemediationRebooting
+	remediation.Status.RetryCount++
+	remediation.Status.LastRemediated = &metav1.Time{Time: time.Now()}
+	return nil
+}
+
+// reset marks the ElementalHost for reset.
+func (r *ElementalRemediationReconciler) reset(ctx context.Context, remediation *infrastructurev1.ElementalRemediation, host *infrastructurev1.ElementalHost) error {
+	patchHelper, err := patch.NewHelper(host, r.Client)
+	if err != nil {
+		return fmt.Errorf("initializing patch helper: %w", err)
+	}
+	if host.Labels == nil {
+		host.Labels = map[string]string{}
+	}
+	host.Labels[infrastructurev1.LabelElementalHostNeedsReset] = "true"
+	if err := patchHelper.Patch(ctx, host); err != nil {
+		return fmt.Errorf("patching ElementalHost: %w", err)
+	}
+	remediation.Status.Phase = infrastructurev1.PhaseRemediationResetting
+	remediation.Status.LastRemediated = &metav1.Time{Time: time.Now()}
+	return nil
+}
+
+// failRemediation marks the ElementalHost as failed, if any, and deletes the Machine so that it can be replaced.
+func (r *ElementalRemediationReconciler) failRemediation(ctx context.Context, remediation *infrastructurev1.ElementalRemediation, machine *clusterv1.Machine, host *infrastructurev1.ElementalHost) error {
+	if host != nil {
+		patchHelper, err := patch.NewHelper(host, r.Client)
+		if err != nil {
+			return fmt.Errorf("initializing patch helper: %w", err)
+		}
+		conditions.Set(host, &clusterv1.Condition{
+			Type:     infrastructurev1.RemediationReady,
+			Status:   corev1.ConditionFalse,
+			Severity: clusterv1.ConditionSeverityError,
+			Reason:   infrastructurev1.RemediationFailedReason,
+			Message:  fmt.Sprintf("Machine %s could not be remediated", machine.Name),
+		})
+		host.Status.FailureReason = ptr.To(capierrors.UpdateMachineError)
+		host.Status.FailureMessage = ptr.To(fmt.Sprintf("Machine %s could not be remediated", machine.Name))
+		conditions.SetSummary(host)
+		if err := patchHelper.Patch(ctx, host); err != nil {
+			return fmt.Errorf("patching ElementalHost: %w", err)
+		}
+	}
+	if err := r.Client.Delete(ctx, machine); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("deleting Machine: %w", err)
+	}
+	remediation.Status.Phase = infrastructurev1.PhaseRemediationFailed
+	remediation.Status.LastRemediated = &metav1.Time{Time: time.Now()}
+	return nil
+}
+
+// getElementalHost returns the ElementalHost associated to the Machine infrastructure, or nil if none is found.
+func (r *ElementalRemediationReconciler) getElementalHost(ctx context.Context, machine *clusterv1.Machine) (*infrastructurev1.ElementalHost, error) {
+	elementalMachine := &infrastructurev1.ElementalMachine{}
+	if err := r.Client.Get(ctx, types.NamespacedName{
+		Namespace: machine.Namespace,
+		Name:      machine.Spec.InfrastructureRef.Name,
+	}, elementalMachine); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fetching ElementalMachine: %w", err)
+	}
+	if elementalMachine.Spec.HostRef == nil {
+		return nil, nil
+	}
+	host := &infrastructurev1.ElementalHost{}
+	if err := r.Client.Get(ctx, types.NamespacedName{
+		Namespace: elementalMachine.Spec.HostRef.Namespace,
+		Name:      elementalMachine.Spec.HostRef.Name,
+	}, host); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fetching ElementalHost: %w", err)
+	}
+	return host, nil
+}
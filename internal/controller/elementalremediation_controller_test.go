@@ -0,0 +1,260 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	"github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+)
+
+// One CAPI Machine is linked to an ElementalMachine associated to an ElementalHost.
+// An ElementalRemediation owned by the Machine is created, as the MachineHealthCheck controller would do.
+//
+// Machine <--> ElementalMachine <--> ElementalHost
+//
+// ElementalRemediation (this test coverage)
+var _ = Describe("ElementalRemediation controller", Label("controller", "elemental-remediation"), Ordered, func() {
+	ctx := context.Background()
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "elementalremediation-test",
+		},
+	}
+	host := v1beta1.ElementalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-host",
+			Namespace: namespace.Name,
+			Labels:    map[string]string{v1beta1.LabelElementalHostInstalled: "true"},
+		},
+	}
+	elementalMachine := v1beta1.ElementalMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-elemental-machine",
+			Namespace: namespace.Name,
+		},
+		Spec: v1beta1.ElementalMachineSpec{
+			HostRef: &corev1.ObjectReference{
+				APIVersion: v1beta1.GroupVersion.Identifier(),
+				Kind:       "ElementalHost",
+				Namespace:  namespace.Name,
+				Name:       host.Name,
+			},
+		},
+	}
+	machine := clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-machine",
+			Namespace: namespace.Name,
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: "test",
+			InfrastructureRef: corev1.ObjectReference{
+				APIVersion: v1beta1.GroupVersion.Identifier(),
+				Kind:       "ElementalMachine",
+				Namespace:  namespace.Name,
+				Name:       elementalMachine.Name,
+			},
+		},
+	}
+	remediation := v1beta1.ElementalRemediation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      machine.Name,
+			Namespace: namespace.Name,
+		},
+		Spec: v1beta1.ElementalRemediationSpec{
+			Strategy: v1beta1.RemediationStrategy{
+				RetryLimit: 1,
+				Timeout:    &metav1.Duration{Duration: 2 * time.Second},
+			},
+		},
+	}
+	BeforeAll(func() {
+		Expect(k8sClient.Create(ctx, &namespace)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &host)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &elementalMachine)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &machine)).Should(Succeed())
+	})
+	AfterAll(func() {
+		Expect(k8sClient.Delete(ctx, &namespace)).Should(Succeed())
+	})
+	It("should have default strategy", func() {
+		defaultRemediation := v1beta1.ElementalRemediation{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-default",
+				Namespace: namespace.Name,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &defaultRemediation)).Should(Succeed())
+		Expect(defaultRemediation.Spec.Strategy.RetryLimit).Should(Equal(1))
+		Expect(defaultRemediation.Spec.Strategy.Timeout).Should(Equal(&metav1.Duration{Duration: DefaultRemediationTimeout}))
+	})
+	It("should escalate from reboot to reset to failure", func() {
+		remediation.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Machine",
+			Name:       machine.Name,
+			UID:        machine.UID,
+		}}
+		Expect(k8sClient.Create(ctx, &remediation)).Should(Succeed())
+
+		// Reboot
+		Eventually(func() v1beta1.RemediationPhase {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: remediation.Name, Namespace: remediation.Namespace}, &remediation)).Should(Succeed())
+			return remediation.Status.Phase
+		}).WithTimeout(time.Minute).Should(Equal(v1beta1.PhaseRemediationRebooting))
+		Expect(remediation.Status.RetryCount).Should(Equal(1))
+		Expect(remediation.Status.HostRef).ShouldNot(BeNil())
+		Expect(remediation.Status.HostRef.Name).Should(Equal(host.Name))
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: host.Name, Namespace: host.Namespace}, &host)).Should(Succeed())
+		Expect(host.Spec.Operation).ShouldNot(BeNil(), "Reboot operation should be requested")
+		Expect(host.Spec.Operation.Type).Should(Equal(v1beta1.HostOperationReboot))
+		Expect(host.Spec.Operation.Drain).Should(BeFalse())
+
+		// Reset
+		Eventually(func() v1beta1.RemediationPhase {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: remediation.Name, Namespace: remediation.Namespace}, &remediation)).Should(Succeed())
+			return remediation.Status.Phase
+		}).WithTimeout(time.Minute).Should(Equal(v1beta1.PhaseRemediationResetting))
+		Expect(remediation.Status.RetryCount).Should(Equal(1))
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: host.Name, Namespace: host.Namespace}, &host)).Should(Succeed())
+		Expect(host.Labels[v1beta1.LabelElementalHostNeedsReset]).Should(Equal("true"))
+
+		// Failure
+		Eventually(func() v1beta1.RemediationPhase {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: remediation.Name, Namespace: remediation.Namespace}, &remediation)).Should(Succeed())
+			return remediation.Status.Phase
+		}).WithTimeout(time.Minute).Should(Equal(v1beta1.PhaseRemediationFailed))
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: host.Name, Namespace: host.Namespace}, &host)).Should(Succeed())
+		remediationCondition := conditions.Get(&host, v1beta1.RemediationReady)
+		Expect(remediationCondition).ShouldNot(BeNil())
+		Expect(remediationCondition.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(remediationCondition.Reason).Should(Equal(v1beta1.RemediationFailedReason))
+		Expect(host.Status.FailureReason).ShouldNot(BeNil())
+		Expect(*host.Status.FailureReason).Should(Equal(capierrors.UpdateMachineError))
+		Expect(host.Status.FailureMessage).ShouldNot(BeNil())
+		err := k8sClient.Get(ctx, types.NamespacedName{Name: machine.Name, Namespace: machine.Namespace}, &machine)
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue(), "Machine should be deleted")
+	})
+})
+
+// The reset ElementalHost is replaced by a new one, associated to the same ElementalMachine.
+// The new ElementalHost must not be marked as failed once the reset timeout expires.
+var _ = Describe("ElementalRemediation controller with replaced host", Label("controller", "elemental-remediation"), Ordered, func() {
+	ctx := context.Background()
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "elementalremediation-replaced-test",
+		},
+	}
+	host := v1beta1.ElementalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-host",
+			Namespace: namespace.Name,
+			Labels:    map[string]string{v1beta1.LabelElementalHostInstalled: "true"},
+		},
+	}
+	newHost := v1beta1.ElementalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-new-host",
+			Namespace: namespace.Name,
+			Labels:    map[string]string{v1beta1.LabelElementalHostInstalled: "true"},
+		},
+	}
+	elementalMachine := v1beta1.ElementalMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-elemental-machine",
+			Namespace: namespace.Name,
+		},
+		Spec: v1beta1.ElementalMachineSpec{
+			HostRef: &corev1.ObjectReference{
+				APIVersion: v1beta1.GroupVersion.Identifier(),
+				Kind:       "ElementalHost",
+				Namespace:  namespace.Name,
+				Name:       host.Name,
+			},
+		},
+	}
+	machine := clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-machine",
+			Namespace: namespace.Name,
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: "test",
+			InfrastructureRef: corev1.ObjectReference{
+				APIVersion: v1beta1.GroupVersion.Identifier(),
+				Kind:       "ElementalMachine",
+				Namespace:  namespace.Name,
+				Name:       elementalMachine.Name,
+			},
+		},
+	}
+	remediation := v1beta1.ElementalRemediation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      machine.Name,
+			Namespace: namespace.Name,
+		},
+		Spec: v1beta1.ElementalRemediationSpec{
+			Strategy: v1beta1.RemediationStrategy{
+				RetryLimit: 0,
+				Timeout:    &metav1.Duration{Duration: 5 * time.Second},
+			},
+		},
+	}
+	BeforeAll(func() {
+		Expect(k8sClient.Create(ctx, &namespace)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &host)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &newHost)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &elementalMachine)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &machine)).Should(Succeed())
+	})
+	AfterAll(func() {
+		Expect(k8sClient.Delete(ctx, &namespace)).Should(Succeed())
+	})
+	It("should start a new remediation cycle for the replaced host", func() {
+		remediation.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Machine",
+			Name:       machine.Name,
+			UID:        machine.UID,
+		}}
+		Expect(k8sClient.Create(ctx, &remediation)).Should(Succeed())
+
+		// Reset
+		Eventually(func() v1beta1.RemediationPhase {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: remediation.Name, Namespace: remediation.Namespace}, &remediation)).Should(Succeed())
+			return remediation.Status.Phase
+		}).WithTimeout(time.Minute).Should(Equal(v1beta1.PhaseRemediationResetting))
+		Expect(remediation.Status.HostRef.UID).Should(Equal(host.UID))
+
+		// A new host is associated to the ElementalMachine
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: elementalMachine.Name, Namespace: elementalMachine.Namespace}, &elementalMachine)).Should(Succeed())
+		elementalMachinePatch := elementalMachine.DeepCopy()
+		elementalMachinePatch.Spec.HostRef.Name = newHost.Name
+		patchObject(ctx, k8sClient, &elementalMachine, elementalMachinePatch)
+
+		// The timeout passes, the new host is remediated from the first step
+		Eventually(func() types.UID {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: remediation.Name, Namespace: remediation.Namespace}, &remediation)).Should(Succeed())
+			return remediation.Status.HostRef.UID
+		}).WithTimeout(time.Minute).Should(Equal(newHost.UID))
+		Eventually(func() string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: newHost.Name, Namespace: newHost.Namespace}, &newHost)).Should(Succeed())
+			return newHost.Labels[v1beta1.LabelElementalHostNeedsReset]
+		}).WithTimeout(time.Minute).Should(Equal("true"), "New remediation cycle should reset the new host")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: remediation.Name, Namespace: remediation.Namespace}, &remediation)).Should(Succeed())
+		Expect(remediation.Status.Phase).Should(Equal(v1beta1.PhaseRemediationResetting))
+		Expect(newHost.Status.FailureReason).Should(BeNil(), "New host must not be marked as failed")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: machine.Name, Namespace: machine.Namespace}, &machine)).Should(Succeed(), "Machine must not be deleted")
+	})
+})
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net/url"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -62,6 +64,9 @@ var (
 	server            *api.Server
 	serverURL         = fmt.Sprintf("http://localhost:%d", elementalAPIPort)
 	remoteTrackerMock *utils.RemoteTrackerMock
+	eventRecorder     = record.NewFakeRecorder(1024)
+	eventsLock        sync.Mutex
+	events            []string
 )
 
 func TestControllers(t *testing.T) {
@@ -104,8 +109,22 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).ToNot(HaveOccurred())
 
+	// Collect the recorded events, so that the FakeRecorder never blocks
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-eventRecorder.Events:
+				eventsLock.Lock()
+				events = append(events, event)
+				eventsLock.Unlock()
+			}
+		}
+	}()
+
 	// Start the Elemental API server
-	server = api.NewServer(ctx, k8sClient, elementalAPIPort, false, "", "")
+	server = api.NewServer(ctx, indexedClient{Client: k8sClient, cache: k8sManager.GetCache()}, eventRecorder, elementalAPIPort, DefaultHeartbeatGracePeriod, nil)
 	go func() {
 		defer GinkgoRecover()
 		err := server.Start(ctx)
@@ -132,7 +151,8 @@ func setupAllWithManager(k8sManager manager.Manager) {
 		Scheme:        k8sManager.GetScheme(),
 		APIUrl:        apiEndpoint,
 		DefaultCACert: testCAValue,
-	}).SetupWithManager(k8sManager)
+		Recorder:      eventRecorder,
+	}).SetupWithManager(ctx, k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
 	remoteTrackerMock = utils.NewRemoteTrackerMock()
@@ -140,11 +160,38 @@ func setupAllWithManager(k8sManager manager.Manager) {
 		Client:        k8sManager.GetClient(),
 		Scheme:        k8sManager.GetScheme(),
 		Tracker:       remoteTrackerMock,
+		Recorder:      eventRecorder,
 		RequeuePeriod: time.Second,
 	}).SetupWithManager(ctx, k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
 	err = (&ElementalHostReconciler{
+		Client:   k8sManager.GetClient(),
+		Scheme:   k8sManager.GetScheme(),
+		Tracker:  remoteTrackerMock,
+		Recorder: eventRecorder,
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&ElementalMachineTemplateReconciler{
+		Client: k8sManager.GetClient(),
+		Scheme: k8sManager.GetScheme(),
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&ElementalHostPoolReconciler{
+		Client: k8sManager.GetClient(),
+		Scheme: k8sManager.GetScheme(),
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&ElementalHostReservationReconciler{
+		Client: k8sManager.GetClient(),
+		Scheme: k8sManager.GetScheme(),
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&ElementalHostDiagnosticReconciler{
 		Client: k8sManager.GetClient(),
 		Scheme: k8sManager.GetScheme(),
 	}).SetupWithManager(k8sManager)
@@ -155,6 +202,12 @@ func setupAllWithManager(k8sManager manager.Manager) {
 		Scheme: k8sManager.GetScheme(),
 	}).SetupWithManager(ctx, k8sManager)
 	Expect(err).ToNot(HaveOccurred())
+
+	err = (&ElementalRemediationReconciler{
+		Client: k8sManager.GetClient(),
+		Scheme: k8sManager.GetScheme(),
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
 }
 
 var _ = AfterSuite(func() {
@@ -164,8 +217,31 @@ var _ = AfterSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 })
 
+// recordedEvents returns all the events recorded so far, formatted as "Type Reason Message".
+func recordedEvents() []string {
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+	return append([]string{}, events...)
+}
+
 func patchObject(ctx context.Context, k8sClient client.Client, obj client.Object, patchObj client.Object) {
 	patchHelper, err := patch.NewHelper(obj, k8sClient)
 	Expect(err).ToNot(HaveOccurred())
 	Expect(patchHelper.Patch(ctx, patchObj)).Should(Succeed())
 }
+
+// indexedClient reads directly from the API server, like k8sClient,
+// except for the lists filtered by field index, that only the manager cache can serve.
+type indexedClient struct {
+	client.Client
+	cache client.Reader
+}
+
+func (c indexedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.FieldSelector != nil && !listOpts.FieldSelector.Empty() {
+		return c.cache.List(ctx, list, opts...)
+	}
+	return c.Client.List(ctx, list, opts...)
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ElementalHostRegistrationIndexKey indexes ElementalHosts by the name of their controlling ElementalRegistration.
+const ElementalHostRegistrationIndexKey = ".metadata.controller.elementalRegistration"
+
+// IndexElementalHostByRegistration returns the name of the ElementalRegistration controlling the ElementalHost, if any.
+func IndexElementalHostByRegistration(obj client.Object) []string {
+	owner := metav1.GetControllerOf(obj)
+	if owner == nil || owner.Kind != "ElementalRegistration" {
+		return nil
+	}
+	return []string{owner.Name}
+}
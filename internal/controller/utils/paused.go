@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+)
+
+// IsPaused returns true if the object has the 'cluster.x-k8s.io/paused' annotation,
+// or if the CAPI Cluster it belongs to is paused.
+// The Cluster is looked up through the 'cluster.x-k8s.io/cluster-name' label, or through the owner references.
+func IsPaused(ctx context.Context, reader client.Reader, obj client.Object) (bool, error) {
+	return isPaused(ctx, reader, obj, obj.GetNamespace())
+}
+
+// IsElementalHostPaused returns true if the ElementalHost has the 'cluster.x-k8s.io/paused' annotation,
+// or if the CAPI Cluster it belongs to is paused.
+// The Cluster is looked up in the namespace returned by ElementalHostClusterNamespace.
+func IsElementalHostPaused(ctx context.Context, reader client.Reader, host *infrastructurev1.ElementalHost) (bool, error) {
+	return isPaused(ctx, reader, host, ElementalHostClusterNamespace(host))
+}
+
+// ElementalHostClusterNamespace returns the namespace of the CAPI Cluster the ElementalHost belongs to.
+// This is the namespace of the associated ElementalMachine,
+// that may differ from the ElementalHost namespace when claimed from an ElementalHostPool.
+func ElementalHostClusterNamespace(host *infrastructurev1.ElementalHost) string {
+	if host.Spec.MachineRef != nil && len(host.Spec.MachineRef.Namespace) > 0 {
+		return host.Spec.MachineRef.Namespace
+	}
+	return host.Namespace
+}
+
+func isPaused(ctx context.Context, reader client.Reader, obj client.Object, clusterNamespace string) (bool, error) {
+	if annotations.HasPaused(obj) {
+		return true, nil
+	}
+
+	clusterName, found := obj.GetLabels()[clusterv1.ClusterNameLabel]
+	if !found {
+		for _, ref := range obj.GetOwnerReferences() {
+			groupVersion, err := schema.ParseGroupVersion(ref.APIVersion)
+			if err == nil && groupVersion.Group == clusterv1.GroupVersion.Group && ref.Kind == "Cluster" {
+				clusterName = ref.Name
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		return false, nil
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: clusterNamespace, Name: clusterName}, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("fetching Cluster '%s/%s': %w", clusterNamespace, clusterName, err)
+	}
+	return cluster.Spec.Paused, nil
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+)
+
+var _ = Describe("IsPaused", Label("utils", "paused"), func() {
+	ctx := context.TODO()
+
+	pausedCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "paused",
+			Namespace: "test",
+		},
+		Spec: clusterv1.ClusterSpec{Paused: true},
+	}
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "unpaused",
+			Namespace: "test",
+		},
+	}
+
+	// Cluster with the same name as the paused one, in the namespace of an ElementalHostPool.
+	poolNamespaceCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "paused",
+			Namespace: "pool",
+		},
+	}
+
+	Expect(clusterv1.AddToScheme(scheme.Scheme)).Should(Succeed())
+	fakeClient := fake.NewClientBuilder().WithObjects(pausedCluster, cluster, poolNamespaceCluster).Build()
+
+	It("should be paused if annotated", func() {
+		obj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "test",
+			Annotations: map[string]string{clusterv1.PausedAnnotation: ""},
+		}}
+		Expect(IsPaused(ctx, fakeClient, obj)).Should(BeTrue())
+	})
+	It("should be paused if labeled cluster is paused", func() {
+		obj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: pausedCluster.Name},
+		}}
+		Expect(IsPaused(ctx, fakeClient, obj)).Should(BeTrue())
+		obj.Labels[clusterv1.ClusterNameLabel] = cluster.Name
+		Expect(IsPaused(ctx, fakeClient, obj)).Should(BeFalse())
+	})
+	It("should be paused if owner cluster is paused", func() {
+		obj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "Cluster",
+				Name:       pausedCluster.Name,
+			}},
+		}}
+		Expect(IsPaused(ctx, fakeClient, obj)).Should(BeTrue())
+	})
+	It("should not be paused if cluster is not found", func() {
+		obj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: "not-found"},
+		}}
+		Expect(IsPaused(ctx, fakeClient, obj)).Should(BeFalse())
+		Expect(IsPaused(ctx, fakeClient, &corev1.Secret{})).Should(BeFalse())
+	})
+	It("should look up the cluster of pool hosts in the ElementalMachine namespace", func() {
+		host := &infrastructurev1.ElementalHost{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "pool",
+				Labels:    map[string]string{clusterv1.ClusterNameLabel: pausedCluster.Name},
+			},
+			Spec: infrastructurev1.ElementalHostSpec{
+				MachineRef: &corev1.ObjectReference{Namespace: "test", Name: "test-machine"},
+			},
+		}
+		Expect(ElementalHostClusterNamespace(host)).Should(Equal("test"))
+		Expect(IsElementalHostPaused(ctx, fakeClient, host)).Should(BeTrue())
+		Expect(IsPaused(ctx, fakeClient, host)).Should(BeFalse(), "The cluster in the host namespace is not paused")
+		host.Spec.MachineRef = nil
+		Expect(ElementalHostClusterNamespace(host)).Should(Equal("pool"))
+		Expect(IsElementalHostPaused(ctx, fakeClient, host)).Should(BeFalse())
+	})
+})
@@ -4,9 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
+	"golang.org/x/exp/maps"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/kubernetes/pkg/util/taints"
@@ -20,28 +26,166 @@ var (
 	ErrRemoteNodeNotFound = errors.New("remote node not found")
 )
 
+// podNodeNameField is the field selector matching the pods scheduled on a node.
+const podNodeNameField = "spec.nodeName"
+
 var (
 	uninitializedTaint = corev1.Taint{Key: "node.cloudprovider.kubernetes.io/uninitialized", Effect: corev1.TaintEffectNoSchedule}
 )
 
+// NodeIdentity identifies a downstream cluster node.
+type NodeIdentity struct {
+	// Name is the expected node name.
+	Name string
+	// SystemUUID is matched against the node status.nodeInfo.systemUUID, if no node with the expected name is found.
+	SystemUUID string
+	// Addresses are matched against the node status.addresses, if no node with the expected name is found.
+	Addresses []string
+}
+
+// NodeMetadata is the metadata synchronized to a downstream cluster node.
+type NodeMetadata struct {
+	Labels      map[string]string
+	Annotations map[string]string
+	Taints      []corev1.Taint
+}
+
 // RemoteTracker wraps a remote.ClusterCacheTracker for easier testing.
 type RemoteTracker interface {
-	SetProviderID(ctx context.Context, cluster types.NamespacedName, nodeName string, providerID string) error
+	// SetProviderID sets the providerID on the downstream cluster node matching the identity.
+	// It returns the name of the matching node.
+	SetProviderID(ctx context.Context, cluster types.NamespacedName, identity NodeIdentity, providerID string) (string, error)
+	// SyncNode applies the metadata to the downstream cluster node.
+	// Metadata applied by a previous call, but no longer included, is removed.
+	SyncNode(ctx context.Context, cluster types.NamespacedName, nodeName string, metadata NodeMetadata) error
+	// DrainNode cordons the downstream cluster node and evicts its pods.
+	// It returns true once there are no more pods to be evicted.
+	// The downstream cluster is read directly, so that ErrRemoteNodeNotFound confirms the node is gone.
+	DrainNode(ctx context.Context, cluster types.NamespacedName, nodeName string) (bool, error)
 }
 
 var _ RemoteTracker = (*remoteTracker)(nil)
 
 func NewRemoteTracker(tracker *remote.ClusterCacheTracker) RemoteTracker {
-	return &remoteTracker{
+	r := &remoteTracker{
 		Tracker: tracker,
 	}
+	r.newUncachedClient = r.uncachedClient
+	return r
 }
 
 type remoteTracker struct {
 	Tracker *remote.ClusterCacheTracker
+	// newUncachedClient returns a client reading directly from the downstream cluster API server.
+	newUncachedClient func(ctx context.Context, cluster types.NamespacedName) (client.Client, error)
+}
+
+// uncachedClient returns a client bypassing the ClusterCacheTracker cache.
+// It must be used to read objects that should not be watched on the whole downstream cluster, like pods.
+func (r *remoteTracker) uncachedClient(ctx context.Context, cluster types.NamespacedName) (client.Client, error) {
+	cachedClient, err := r.Tracker.GetClient(ctx, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("getting remote client for cluster '%s/%s': %w", cluster.Namespace, cluster.Name, err)
+	}
+	restConfig, err := r.Tracker.GetRESTConfig(ctx, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("getting remote REST config for cluster '%s/%s': %w", cluster.Namespace, cluster.Name, err)
+	}
+	remoteClient, err := client.New(restConfig, client.Options{
+		Scheme: cachedClient.Scheme(),
+		Mapper: cachedClient.RESTMapper(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating uncached remote client for cluster '%s/%s': %w", cluster.Namespace, cluster.Name, err)
+	}
+	return remoteClient, nil
 }
 
-func (r *remoteTracker) SetProviderID(ctx context.Context, cluster types.NamespacedName, nodeName string, providerID string) error {
+func (r *remoteTracker) SetProviderID(ctx context.Context, cluster types.NamespacedName, identity NodeIdentity, providerID string) (string, error) {
+	remoteClient, err := r.Tracker.GetClient(ctx, cluster)
+	if err != nil {
+		return "", fmt.Errorf("getting remote client for cluster '%s/%s': %w", cluster.Namespace, cluster.Name, err)
+	}
+
+	node, err := findNode(ctx, remoteClient, identity, providerID)
+	if err != nil {
+		return "", fmt.Errorf("finding downstream cluster node: %w", err)
+	}
+
+	// Initialize Node patch helper
+	patchHelper, err := patch.NewHelper(node, remoteClient)
+	if err != nil {
+		return "", fmt.Errorf("initializing node patch helper: %w", err)
+	}
+
+	// Set the spec.providerID on the node
+	node.Spec.ProviderID = providerID
+
+	// Remove taint if needed
+	node, _, err = taints.RemoveTaint(node, &uninitializedTaint)
+	if err != nil {
+		return "", fmt.Errorf("removing '%s' taint from node: %w", &uninitializedTaint, err)
+	}
+
+	if err := patchHelper.Patch(ctx, node); err != nil {
+		return "", fmt.Errorf("patching downstream cluster node: %w", err)
+	}
+	return node.Name, nil
+}
+
+// findNode returns the downstream cluster node with the expected name.
+// If the node was renamed, for example by the bootstrap provider or the OS,
+// the node matching the system UUID or any of the addresses is returned instead.
+// Nodes already claimed by a different providerID are never matched.
+func findNode(ctx context.Context, remoteClient client.Client, identity NodeIdentity, providerID string) (*corev1.Node, error) {
+	node := &corev1.Node{}
+	nodeKey := client.ObjectKey{Name: identity.Name}
+	err := remoteClient.Get(ctx, nodeKey, node)
+	if err == nil {
+		return node, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("getting downstream cluster node '%s': %w", nodeKey.Name, err)
+	}
+	if len(identity.SystemUUID) == 0 && len(identity.Addresses) == 0 {
+		return nil, fmt.Errorf("getting node '%s': %w: %w", nodeKey.Name, ErrRemoteNodeNotFound, err)
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := remoteClient.List(ctx, nodes); err != nil {
+		return nil, fmt.Errorf("listing downstream cluster nodes: %w", err)
+	}
+	var addressMatches []*corev1.Node
+	for i := range nodes.Items {
+		candidate := &nodes.Items[i]
+		if len(candidate.Spec.ProviderID) > 0 && candidate.Spec.ProviderID != providerID {
+			continue
+		}
+		if len(identity.SystemUUID) > 0 && strings.EqualFold(candidate.Status.NodeInfo.SystemUUID, identity.SystemUUID) {
+			return candidate, nil
+		}
+		if hasAnyAddress(candidate, identity.Addresses) {
+			addressMatches = append(addressMatches, candidate)
+		}
+	}
+	// Addresses may be reused, only trust them if they identify a single node.
+	if len(addressMatches) == 1 {
+		return addressMatches[0], nil
+	}
+	return nil, fmt.Errorf("matching node '%s' by system UUID or addresses: %w", nodeKey.Name, ErrRemoteNodeNotFound)
+}
+
+// hasAnyAddress returns true if any of the node status addresses is included in the addresses.
+func hasAnyAddress(node *corev1.Node, addresses []string) bool {
+	for _, nodeAddress := range node.Status.Addresses {
+		if slices.Contains(addresses, nodeAddress.Address) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *remoteTracker) SyncNode(ctx context.Context, cluster types.NamespacedName, nodeName string, metadata NodeMetadata) error {
 	remoteClient, err := r.Tracker.GetClient(ctx, cluster)
 	if err != nil {
 		return fmt.Errorf("getting remote client for cluster '%s/%s': %w", cluster.Namespace, cluster.Name, err)
@@ -57,23 +201,154 @@ func (r *remoteTracker) SetProviderID(ctx context.Context, cluster types.Namespa
 		return fmt.Errorf("getting downstream cluster node '%s': %w", nodeKey.Name, err)
 	}
 
-	// Initialize Node patch helper
 	patchHelper, err := patch.NewHelper(node, remoteClient)
 	if err != nil {
 		return fmt.Errorf("initializing node patch helper: %w", err)
 	}
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
 
-	// Set the spec.providerID on the node
-	node.Spec.ProviderID = providerID
+	// The keys synchronized by previous calls are tracked on the node annotations,
+	// so that they can be removed once no longer included.
+	syncedLabels := syncedKeys(node, infrastructurev1.AnnotationNodeSyncedLabels)
+	syncedAnnotations := syncedKeys(node, infrastructurev1.AnnotationNodeSyncedAnnotations)
+	syncedTaints := syncedKeys(node, infrastructurev1.AnnotationNodeSyncedTaints)
 
-	// Remove taint if needed
-	node, _, err = taints.RemoveTaint(node, &uninitializedTaint)
-	if err != nil {
-		return fmt.Errorf("removing '%s' taint from node: %w", &uninitializedTaint, err)
+	syncMap(node.Labels, syncedLabels, metadata.Labels)
+	syncMap(node.Annotations, syncedAnnotations, metadata.Annotations)
+	syncTaints(node, syncedTaints, metadata.Taints)
+
+	setSyncedKeys(node, infrastructurev1.AnnotationNodeSyncedLabels, maps.Keys(metadata.Labels))
+	setSyncedKeys(node, infrastructurev1.AnnotationNodeSyncedAnnotations, maps.Keys(metadata.Annotations))
+	taintKeys := make([]string, 0, len(metadata.Taints))
+	for _, taint := range metadata.Taints {
+		taintKeys = append(taintKeys, taintKey(taint))
 	}
+	setSyncedKeys(node, infrastructurev1.AnnotationNodeSyncedTaints, taintKeys)
 
 	if err := patchHelper.Patch(ctx, node); err != nil {
 		return fmt.Errorf("patching downstream cluster node: %w", err)
 	}
 	return nil
 }
+
+// syncMap removes the previously synced keys no longer desired, then applies the desired values.
+func syncMap(current map[string]string, synced []string, desired map[string]string) {
+	for _, key := range synced {
+		if _, found := desired[key]; !found {
+			delete(current, key)
+		}
+	}
+	maps.Copy(current, desired)
+}
+
+// syncTaints removes the previously synced taints no longer desired, then applies the desired taints.
+// Taints are identified by key and effect.
+func syncTaints(node *corev1.Node, synced []string, desired []corev1.Taint) {
+	taints := []corev1.Taint{}
+	for _, taint := range node.Spec.Taints {
+		if slices.Contains(synced, taintKey(taint)) || slices.ContainsFunc(desired, func(desiredTaint corev1.Taint) bool { return desiredTaint.MatchTaint(&taint) }) {
+			continue
+		}
+		taints = append(taints, taint)
+	}
+	node.Spec.Taints = append(taints, desired...)
+}
+
+// taintKey returns the identifier of a taint in the 'key:effect' format.
+func taintKey(taint corev1.Taint) string {
+	return fmt.Sprintf("%s:%s", taint.Key, taint.Effect)
+}
+
+// syncedKeys returns the keys tracked in the node annotation.
+func syncedKeys(node *corev1.Node, annotation string) []string {
+	value, found := node.Annotations[annotation]
+	if !found || len(value) == 0 {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
+
+// setSyncedKeys tracks the keys in the node annotation.
+// The annotation is removed if there are no keys to track.
+func setSyncedKeys(node *corev1.Node, annotation string, keys []string) {
+	if len(keys) == 0 {
+		delete(node.Annotations, annotation)
+		return
+	}
+	slices.Sort(keys)
+	node.Annotations[annotation] = strings.Join(keys, ",")
+}
+
+func (r *remoteTracker) DrainNode(ctx context.Context, cluster types.NamespacedName, nodeName string) (bool, error) {
+	remoteClient, err := r.newUncachedClient(ctx, cluster)
+	if err != nil {
+		return false, fmt.Errorf("getting uncached remote client: %w", err)
+	}
+
+	node := &corev1.Node{}
+	nodeKey := client.ObjectKey{Name: nodeName}
+	err = remoteClient.Get(ctx, nodeKey, node)
+	if apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("getting node '%s': %w: %w", nodeKey.Name, ErrRemoteNodeNotFound, err)
+	}
+	if err != nil {
+		return false, fmt.Errorf("getting downstream cluster node '%s': %w", nodeKey.Name, err)
+	}
+
+	// Cordon the node
+	if !node.Spec.Unschedulable {
+		patchHelper, err := patch.NewHelper(node, remoteClient)
+		if err != nil {
+			return false, fmt.Errorf("initializing node patch helper: %w", err)
+		}
+		node.Spec.Unschedulable = true
+		if err := patchHelper.Patch(ctx, node); err != nil {
+			return false, fmt.Errorf("cordoning downstream cluster node: %w", err)
+		}
+	}
+
+	// Evict the pods running on the node
+	pods := &corev1.PodList{}
+	if err := remoteClient.List(ctx, pods, client.MatchingFields{podNodeNameField: nodeName}); err != nil {
+		return false, fmt.Errorf("listing downstream cluster node pods: %w", err)
+	}
+	drained := true
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !isEvictable(pod) {
+			continue
+		}
+		drained = false
+		eviction := &policyv1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+			},
+		}
+		// Evictions blocked by PodDisruptionBudgets (429) will be retried on the next attempt.
+		if err := remoteClient.SubResource("eviction").Create(ctx, pod, eviction); err != nil && !apierrors.IsNotFound(err) && !apierrors.IsTooManyRequests(err) {
+			return false, fmt.Errorf("evicting pod '%s/%s': %w", pod.Namespace, pod.Name, err)
+		}
+	}
+	return drained, nil
+}
+
+// isEvictable returns false for pods that should not be evicted when draining a node,
+// like terminated pods, mirror pods, and pods managed by DaemonSets.
+func isEvictable(pod *corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if _, found := pod.Annotations[corev1.MirrorPodAnnotationKey]; found {
+		return false
+	}
+	if owner := metav1.GetControllerOf(pod); owner != nil && owner.Kind == "DaemonSet" {
+		return false
+	}
+	return true
+}
@@ -27,6 +27,12 @@ type RemoteTrackerMock struct {
 type RemoteTrackerMockCall struct {
 	NodeName   string
 	ProviderID string
+	// SystemUUID matches SetProviderID calls for a node with a different name.
+	SystemUUID string
+	// Drained is returned on DrainNode calls.
+	Drained bool
+	// Metadata is the last metadata received on SyncNode calls.
+	Metadata NodeMetadata
 }
 
 func (r *RemoteTrackerMock) AddCall(cluster types.NamespacedName, call RemoteTrackerMockCall) {
@@ -35,7 +41,24 @@ func (r *RemoteTrackerMock) AddCall(cluster types.NamespacedName, call RemoteTra
 	r.calls[cluster] = call
 }
 
-func (r *RemoteTrackerMock) SetProviderID(_ context.Context, cluster types.NamespacedName, nodeName string, providerID string) error {
+func (r *RemoteTrackerMock) SetProviderID(_ context.Context, cluster types.NamespacedName, identity NodeIdentity, providerID string) (string, error) {
+	r.lock.TryLock()
+	defer r.lock.Unlock()
+	call, found := r.calls[cluster]
+	if !found {
+		return "", fmt.Errorf("Cluster %s not found", cluster.String())
+	}
+
+	if call.NodeName != identity.Name && (len(call.SystemUUID) == 0 || call.SystemUUID != identity.SystemUUID) {
+		return "", ErrRemoteNodeNotFound
+	}
+	if call.ProviderID != providerID {
+		return "", fmt.Errorf("Want ProviderID '%s', but got '%s'", call.ProviderID, providerID)
+	}
+	return call.NodeName, nil
+}
+
+func (r *RemoteTrackerMock) SyncNode(_ context.Context, cluster types.NamespacedName, nodeName string, metadata NodeMetadata) error {
 	r.lock.TryLock()
 	defer r.lock.Unlock()
 	call, found := r.calls[cluster]
@@ -46,8 +69,28 @@ func (r *RemoteTrackerMock) SetProviderID(_ context.Context, cluster types.Names
 	if call.NodeName != nodeName {
 		return ErrRemoteNodeNotFound
 	}
-	if call.ProviderID != providerID {
-		return fmt.Errorf("Want ProviderID '%s', but got '%s'", call.ProviderID, providerID)
-	}
+	call.Metadata = metadata
+	r.calls[cluster] = call
 	return nil
 }
+
+// GetSyncedMetadata returns the last metadata received on SyncNode calls.
+func (r *RemoteTrackerMock) GetSyncedMetadata(cluster types.NamespacedName) NodeMetadata {
+	r.lock.TryLock()
+	defer r.lock.Unlock()
+	return r.calls[cluster].Metadata
+}
+
+func (r *RemoteTrackerMock) DrainNode(_ context.Context, cluster types.NamespacedName, nodeName string) (bool, error) {
+	r.lock.TryLock()
+	defer r.lock.Unlock()
+	call, found := r.calls[cluster]
+	if !found {
+		return false, fmt.Errorf("Cluster %s not found", cluster.String())
+	}
+
+	if call.NodeName != nodeName {
+		return false, ErrRemoteNodeNotFound
+	}
+	return call.Drained, nil
+}
@@ -6,10 +6,13 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	infrastructurev1 "github.com/rancher-sandbox/cluster-api-provider-elemental/api/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -47,25 +50,95 @@ var _ = Describe("Remote Tracker", Label("utils", "remote tracker"), Ordered, fu
 		},
 	}
 
+	renamedNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-renamed.example.com",
+		},
+		Status: corev1.NodeStatus{
+			NodeInfo: corev1.NodeSystemInfo{SystemUUID: "4C4C4544-0044-3510-8052-B4C04F4E5931"},
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "test-renamed.example.com"},
+				{Type: corev1.NodeInternalIP, Address: "192.168.122.10"},
+			},
+		},
+	}
+
+	claimedNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-claimed",
+		},
+		Spec: corev1.NodeSpec{ProviderID: "elemental://testNamespace/otherName"},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "192.168.122.20"},
+			},
+		},
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{NodeName: node.Name},
+	}
+
+	daemonSetPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-daemonset-pod",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "DaemonSet",
+				Name:       "test-daemonset",
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: corev1.PodSpec{NodeName: node.Name},
+	}
+
+	otherNodePod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-other-node-pod",
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{NodeName: taintedNode.Name},
+	}
+
 	Expect(clusterv1.AddToScheme(scheme.Scheme)).Should(Succeed())
 	logger := zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true))
-	fakeClient := fake.NewClientBuilder().WithObjects(cluster, node, taintedNode).Build()
+	fakeClient := fake.NewClientBuilder().
+		WithObjects(cluster, node, taintedNode, renamedNode, claimedNode, pod, daemonSetPod, otherNodePod).
+		WithIndex(&corev1.Pod{}, podNodeNameField, func(obj client.Object) []string {
+			return []string{obj.(*corev1.Pod).Spec.NodeName}
+		}).
+		Build()
 	tracker := remote.NewTestClusterCacheTracker(logger, fakeClient, fakeClient, scheme.Scheme, types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name})
 
-	remoteTracker := NewRemoteTracker(tracker)
+	// The test tracker has no REST config, the fake client is used to read the downstream cluster directly.
+	testTracker := NewRemoteTracker(tracker).(*remoteTracker)
+	testTracker.newUncachedClient = func(ctx context.Context, cluster types.NamespacedName) (client.Client, error) {
+		if _, err := tracker.GetClient(ctx, cluster); err != nil {
+			return nil, err
+		}
+		return fakeClient, nil
+	}
+	remoteTracker := RemoteTracker(testTracker)
 	It("should return error if cluster not found", func() {
-		Expect(remoteTracker.SetProviderID(ctx,
+		_, err := remoteTracker.SetProviderID(ctx,
 			types.NamespacedName{Name: "not", Namespace: "found"},
-			"foo",
-			"bar")).ShouldNot(Succeed())
+			NodeIdentity{Name: "foo"},
+			"bar")
+		Expect(err).Should(HaveOccurred())
 		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(node), node)).Should(Succeed())
 		Expect(node.Spec.ProviderID).Should(BeEmpty())
 	})
 	It("should return ErrRemoteNodeNotFound if node not found", func() {
-		Expect(remoteTracker.SetProviderID(ctx,
+		_, err := remoteTracker.SetProviderID(ctx,
 			client.ObjectKeyFromObject(cluster),
-			"foo",
-			"bar")).Should(MatchError(ErrRemoteNodeNotFound))
+			NodeIdentity{Name: "foo"},
+			"bar")
+		Expect(err).Should(MatchError(ErrRemoteNodeNotFound))
 		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(node), node)).Should(Succeed())
 		Expect(node.Spec.ProviderID).Should(BeEmpty())
 	})
@@ -73,8 +146,8 @@ var _ = Describe("Remote Tracker", Label("utils", "remote tracker"), Ordered, fu
 		wantProviderID := "elemental://testNamespace/testName"
 		Expect(remoteTracker.SetProviderID(ctx,
 			client.ObjectKeyFromObject(cluster),
-			node.Name,
-			wantProviderID)).Should(Succeed())
+			NodeIdentity{Name: node.Name},
+			wantProviderID)).Should(Equal(node.Name))
 		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(node), node)).Should(Succeed())
 		Expect(node.Spec.ProviderID).Should(Equal(wantProviderID))
 	})
@@ -82,10 +155,102 @@ var _ = Describe("Remote Tracker", Label("utils", "remote tracker"), Ordered, fu
 		wantProviderID := "elemental://testNamespace/testName"
 		Expect(remoteTracker.SetProviderID(ctx,
 			client.ObjectKeyFromObject(cluster),
-			taintedNode.Name,
-			wantProviderID)).Should(Succeed())
+			NodeIdentity{Name: taintedNode.Name},
+			wantProviderID)).Should(Equal(taintedNode.Name))
 		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(taintedNode), taintedNode)).Should(Succeed())
 		Expect(taintedNode.Spec.ProviderID).Should(Equal(wantProviderID))
 		Expect(taintedNode.Spec.Taints).Should(BeEmpty())
 	})
+	It("should return ErrRemoteNodeNotFound if no node matches system UUID or addresses", func() {
+		_, err := remoteTracker.SetProviderID(ctx,
+			client.ObjectKeyFromObject(cluster),
+			NodeIdentity{Name: "foo", SystemUUID: "not-found", Addresses: []string{"10.0.0.1"}},
+			"bar")
+		Expect(err).Should(MatchError(ErrRemoteNodeNotFound))
+	})
+	It("should not match nodes claimed by a different providerID", func() {
+		_, err := remoteTracker.SetProviderID(ctx,
+			client.ObjectKeyFromObject(cluster),
+			NodeIdentity{Name: "foo", Addresses: []string{"192.168.122.20"}},
+			"elemental://testNamespace/testName")
+		Expect(err).Should(MatchError(ErrRemoteNodeNotFound))
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(claimedNode), claimedNode)).Should(Succeed())
+		Expect(claimedNode.Spec.ProviderID).Should(Equal("elemental://testNamespace/otherName"))
+	})
+	It("should patch ProviderID on remote node matching the addresses", func() {
+		wantProviderID := "elemental://testNamespace/testAddress"
+		Expect(remoteTracker.SetProviderID(ctx,
+			client.ObjectKeyFromObject(cluster),
+			NodeIdentity{Name: "test-renamed", Addresses: []string{"fd00::10", "192.168.122.10"}},
+			wantProviderID)).Should(Equal(renamedNode.Name))
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(renamedNode), renamedNode)).Should(Succeed())
+		Expect(renamedNode.Spec.ProviderID).Should(Equal(wantProviderID))
+	})
+	It("should patch ProviderID on remote node matching the system UUID", func() {
+		wantProviderID := "elemental://testNamespace/testAddress"
+		Expect(remoteTracker.SetProviderID(ctx,
+			client.ObjectKeyFromObject(cluster),
+			NodeIdentity{Name: "test-renamed", SystemUUID: "4c4c4544-0044-3510-8052-b4c04f4e5931"},
+			wantProviderID)).Should(Equal(renamedNode.Name))
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(renamedNode), renamedNode)).Should(Succeed())
+		Expect(renamedNode.Spec.ProviderID).Should(Equal(wantProviderID))
+	})
+	It("should sync metadata on remote node", func() {
+		gpuTaint := corev1.Taint{Key: "example.com/gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+		Expect(remoteTracker.SyncNode(ctx, client.ObjectKeyFromObject(cluster), node.Name, NodeMetadata{
+			Labels:      map[string]string{"topology.kubernetes.io/zone": "zone-a", "example.com/rack": "rack-1"},
+			Annotations: map[string]string{"example.com/owner": "team-a"},
+			Taints:      []corev1.Taint{gpuTaint},
+		})).Should(Succeed())
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(node), node)).Should(Succeed())
+		Expect(node.Labels).Should(HaveKeyWithValue("topology.kubernetes.io/zone", "zone-a"))
+		Expect(node.Labels).Should(HaveKeyWithValue("example.com/rack", "rack-1"))
+		Expect(node.Annotations).Should(HaveKeyWithValue("example.com/owner", "team-a"))
+		Expect(node.Spec.Taints).Should(ConsistOf(gpuTaint))
+
+		// Node metadata not managed by the sync must be preserved
+		nodePatch := node.DeepCopy()
+		nodePatch.Labels["kubernetes.io/hostname"] = node.Name
+		nodePatch.Spec.Taints = append(nodePatch.Spec.Taints, corev1.Taint{Key: "example.com/maintenance", Effect: corev1.TaintEffectNoExecute})
+		Expect(fakeClient.Update(ctx, nodePatch)).Should(Succeed())
+
+		Expect(remoteTracker.SyncNode(ctx, client.ObjectKeyFromObject(cluster), node.Name, NodeMetadata{
+			Labels: map[string]string{"topology.kubernetes.io/zone": "zone-b"},
+		})).Should(Succeed())
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(node), node)).Should(Succeed())
+		Expect(node.Labels).Should(HaveKeyWithValue("topology.kubernetes.io/zone", "zone-b"))
+		Expect(node.Labels).Should(HaveKeyWithValue("kubernetes.io/hostname", node.Name))
+		Expect(node.Labels).ShouldNot(HaveKey("example.com/rack"), "Label must be removed")
+		Expect(node.Annotations).ShouldNot(HaveKey("example.com/owner"), "Annotation must be removed")
+		Expect(node.Spec.Taints).Should(ConsistOf(corev1.Taint{Key: "example.com/maintenance", Effect: corev1.TaintEffectNoExecute}))
+
+		// Tracking annotations must be removed once there is nothing left to sync
+		Expect(remoteTracker.SyncNode(ctx, client.ObjectKeyFromObject(cluster), node.Name, NodeMetadata{})).Should(Succeed())
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(node), node)).Should(Succeed())
+		Expect(node.Labels).ShouldNot(HaveKey("topology.kubernetes.io/zone"))
+		Expect(node.Annotations).ShouldNot(HaveKey(infrastructurev1.AnnotationNodeSyncedLabels))
+		Expect(node.Annotations).ShouldNot(HaveKey(infrastructurev1.AnnotationNodeSyncedAnnotations))
+		Expect(node.Annotations).ShouldNot(HaveKey(infrastructurev1.AnnotationNodeSyncedTaints))
+	})
+	It("should return ErrRemoteNodeNotFound when syncing unknown node", func() {
+		Expect(remoteTracker.SyncNode(ctx, client.ObjectKeyFromObject(cluster), "foo", NodeMetadata{})).Should(MatchError(ErrRemoteNodeNotFound))
+	})
+	It("should cordon and drain remote node", func() {
+		drained, err := remoteTracker.DrainNode(ctx, client.ObjectKeyFromObject(cluster), node.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drained).Should(BeFalse(), "Node should not be drained until all pods are evicted")
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(node), node)).Should(Succeed())
+		Expect(node.Spec.Unschedulable).Should(BeTrue(), "Node should be cordoned")
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, client.ObjectKeyFromObject(pod), pod))).Should(BeTrue(), "Pod should be evicted")
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(daemonSetPod), daemonSetPod)).Should(Succeed(), "DaemonSet pod should not be evicted")
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(otherNodePod), otherNodePod)).Should(Succeed(), "Pods on other nodes should not be evicted")
+
+		drained, err = remoteTracker.DrainNode(ctx, client.ObjectKeyFromObject(cluster), node.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drained).Should(BeTrue(), "Node should be drained")
+	})
+	It("should return ErrRemoteNodeNotFound when draining unknown node", func() {
+		_, err := remoteTracker.DrainNode(ctx, client.ObjectKeyFromObject(cluster), "foo")
+		Expect(err).Should(MatchError(ErrRemoteNodeNotFound))
+	})
 })
@@ -18,10 +18,22 @@ const (
 	KeyCluster = "Cluster"
 	// The ElementalMachine name.
 	KeyElementalMachine = "ElementalMachine"
+	// The ElementalMachineTemplate name.
+	KeyElementalMachineTemplate = "ElementalMachineTemplate"
 	// The CAPI Machine name.
 	KeyMachine = "Machine"
 	// The ElementalHost name.
 	KeyElementalHost = "ElementalHost"
+	// The ElementalHostPool name.
+	KeyElementalHostPool = "ElementalHostPool"
+	// The ElementalHostReservation name.
+	KeyElementalHostReservation = "ElementalHostReservation"
+	// The ElementalHostDiagnostic name.
+	KeyElementalHostDiagnostic = "ElementalHostDiagnostic"
+	// The ElementalRemediation name.
+	KeyElementalRemediation = "ElementalRemediation"
 	// The Bootstrap Secret name.
 	KeyBootstrapSecret = "BootstrapSecret"
+	// The Support Bundle Secret name.
+	KeySupportBundleSecret = "SupportBundleSecret"
 )
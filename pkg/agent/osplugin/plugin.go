@@ -1,6 +1,7 @@
 package osplugin
 
 import (
+	"context"
 	"fmt"
 	"plugin"
 )
@@ -53,6 +54,21 @@ type Plugin interface {
 	Reboot() error
 }
 
+// SupportBundleCollector is an optional interface a Plugin can implement,
+// to include OS specific diagnostic files in the host support bundle.
+type SupportBundleCollector interface {
+	// CollectSupportFiles should return the diagnostic files to be included in the support bundle, by file name.
+	// File names must be valid Secret keys.
+	CollectSupportFiles() (map[string][]byte, error)
+}
+
+// InterruptibleInstaller is an optional interface a Plugin can implement,
+// to let the agent interrupt a running installation, for example when the ElementalHost needs reset.
+type InterruptibleInstaller interface {
+	// InstallContext should behave like Install, stopping the installation as soon as the context is done.
+	InstallContext(ctx context.Context, input []byte) error
+}
+
 // Loader is a simple plugin loader.
 type Loader interface {
 	Load(string) (Plugin, error)
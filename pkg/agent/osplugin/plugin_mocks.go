@@ -16,16 +16,17 @@
 //
 
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin (interfaces: Loader,Plugin)
+// Source: github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin (interfaces: Loader,Plugin,SupportBundleCollector,InterruptibleInstaller)
 //
 // Generated by this command:
 //
-//	mockgen -copyright_file=hack/boilerplate.go.txt -destination=pkg/agent/osplugin/plugin_mocks.go -package=osplugin github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin Loader,Plugin
+//	mockgen -copyright_file=hack/boilerplate.go.txt -destination=pkg/agent/osplugin/plugin_mocks.go -package=osplugin github.com/rancher-sandbox/cluster-api-provider-elemental/pkg/agent/osplugin Loader,Plugin,SupportBundleCollector,InterruptibleInstaller
 //
 // Package osplugin is a generated GoMock package.
 package osplugin
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -261,3 +262,78 @@ func (mr *MockPluginMockRecorder) TriggerReset() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TriggerReset", reflect.TypeOf((*MockPlugin)(nil).TriggerReset))
 }
+
+// MockSupportBundleCollector is a mock of SupportBundleCollector interface.
+type MockSupportBundleCollector struct {
+	ctrl     *gomock.Controller
+	recorder *MockSupportBundleCollectorMockRecorder
+}
+
+// MockSupportBundleCollectorMockRecorder is the mock recorder for MockSupportBundleCollector.
+type MockSupportBundleCollectorMockRecorder struct {
+	mock *MockSupportBundleCollector
+}
+
+// NewMockSupportBundleCollector creates a new mock instance.
+func NewMockSupportBundleCollector(ctrl *gomock.Controller) *MockSupportBundleCollector {
+	mock := &MockSupportBundleCollector{ctrl: ctrl}
+	mock.recorder = &MockSupportBundleCollectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSupportBundleCollector) EXPECT() *MockSupportBundleCollectorMockRecorder {
+	return m.recorder
+}
+
+// CollectSupportFiles mocks base method.
+func (m *MockSupportBundleCollector) CollectSupportFiles() (map[string][]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CollectSupportFiles")
+	ret0, _ := ret[0].(map[string][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CollectSupportFiles indicates an expected call of CollectSupportFiles.
+func (mr *MockSupportBundleCollectorMockRecorder) CollectSupportFiles() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CollectSupportFiles", reflect.TypeOf((*MockSupportBundleCollector)(nil).CollectSupportFiles))
+}
+
+// MockInterruptibleInstaller is a mock of InterruptibleInstaller interface.
+type MockInterruptibleInstaller struct {
+	ctrl     *gomock.Controller
+	recorder *MockInterruptibleInstallerMockRecorder
+}
+
+// MockInterruptibleInstallerMockRecorder is the mock recorder for MockInterruptibleInstaller.
+type MockInterruptibleInstallerMockRecorder struct {
+	mock *MockInterruptibleInstaller
+}
+
+// NewMockInterruptibleInstaller creates a new mock instance.
+func NewMockInterruptibleInstaller(ctrl *gomock.Controller) *MockInterruptibleInstaller {
+	mock := &MockInterruptibleInstaller{ctrl: ctrl}
+	mock.recorder = &MockInterruptibleInstallerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInterruptibleInstaller) EXPECT() *MockInterruptibleInstallerMockRecorder {
+	return m.recorder
+}
+
+// InstallContext mocks base method.
+func (m *MockInterruptibleInstaller) InstallContext(arg0 context.Context, arg1 []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallContext", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallContext indicates an expected call of InstallContext.
+func (mr *MockInterruptibleInstallerMockRecorder) InstallContext(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallContext", reflect.TypeOf((*MockInterruptibleInstaller)(nil).InstallContext), arg0, arg1)
+}